@@ -0,0 +1,217 @@
+package audit
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	"universalsdk/config"
+	"universalsdk/envelope"
+	"universalsdk/models"
+)
+
+type AuditSuite struct {
+	suite.Suite
+	dir string
+}
+
+func TestAuditSuite(t *testing.T) {
+	suite.Run(t, new(AuditSuite))
+}
+
+func (suite *AuditSuite) SetupTest() {
+	dir, err := ioutil.TempDir("", "usdk-audit")
+	suite.Require().NoError(err)
+	suite.dir = dir
+}
+
+func (suite *AuditSuite) TearDownTest() {
+	os.RemoveAll(suite.dir)
+}
+
+func (suite *AuditSuite) TestHashChain() {
+	logger, err := NewLogger(NewMemorySink(), nil)
+	suite.Require().NoError(err)
+
+	for i := 0; i < 3; i++ {
+		suite.Require().NoError(logger.Log(mockRecord("key-" + string(rune('a'+i)))))
+	}
+	suite.NoError(logger.Verify())
+
+	records, err := logger.Query(Query{})
+	suite.Require().NoError(err)
+	suite.Len(records, 3)
+	suite.Equal("", records[0].PrevHash)
+	suite.Equal(records[0].Hash, records[1].PrevHash)
+
+	// Tampering with an outcome must break the chain
+	records[1].Outcome = OutcomeFail
+	suite.Error(VerifyChain(records, nil))
+
+	// As must removing a record
+	records, _ = logger.Query(Query{})
+	suite.Error(VerifyChain([]*Record{records[0], records[2]}, nil))
+}
+
+func (suite *AuditSuite) TestKeyedHashChain() {
+	key := []byte("chain-key")
+	logger, err := NewLogger(NewMemorySink(), nil, WithChainKey(key))
+	suite.Require().NoError(err)
+	for i := 0; i < 2; i++ {
+		suite.Require().NoError(logger.Log(mockRecord("key-" + string(rune('a'+i)))))
+	}
+	suite.NoError(logger.Verify())
+
+	records, _ := logger.Query(Query{})
+	suite.NoError(VerifyChain(records, key))
+	suite.Error(VerifyChain(records, nil))
+
+	// Recomputing the chain after an edit needs the key
+	records[1].Outcome = OutcomeFail
+	records[1].Hash, _ = records[1].computeHash(nil)
+	suite.Error(VerifyChain(records, key))
+	records[1].Hash, _ = records[1].computeHash(key)
+	suite.NoError(VerifyChain(records, key))
+}
+
+func (suite *AuditSuite) TestFileSinkRotationAndResume() {
+	sink, err := NewFileSink(suite.dir, 600, 0)
+	suite.Require().NoError(err)
+	logger, err := NewLogger(sink, nil)
+	suite.Require().NoError(err)
+
+	for i := 0; i < 10; i++ {
+		suite.Require().NoError(logger.Log(mockRecord("key-" + string(rune('a'+i)))))
+	}
+	suite.Require().NoError(logger.Close())
+
+	rotated, _ := filepath.Glob(filepath.Join(suite.dir, "audit-*.jsonl"))
+	suite.NotEmpty(rotated, "expected the audit file to be rotated")
+
+	// Reopening continues the existing chain
+	sink, err = NewFileSink(suite.dir, 600, 0)
+	suite.Require().NoError(err)
+	logger, err = NewLogger(sink, nil)
+	suite.Require().NoError(err)
+	defer logger.Close()
+
+	rec := mockRecord("key-z")
+	suite.Require().NoError(logger.Log(rec))
+	suite.Equal(uint64(11), rec.Sequence)
+	suite.NoError(logger.Verify())
+}
+
+func (suite *AuditSuite) TestFileSinkPrune() {
+	sink, err := NewFileSink(suite.dir, 600, 2)
+	suite.Require().NoError(err)
+	logger, err := NewLogger(sink, nil)
+	suite.Require().NoError(err)
+	defer logger.Close()
+
+	for i := 0; i < 20; i++ {
+		suite.Require().NoError(logger.Log(mockRecord("key")))
+	}
+
+	rotated, _ := filepath.Glob(filepath.Join(suite.dir, "audit-*.jsonl"))
+	suite.Len(rotated, 2)
+
+	// The remaining records still chain to each other
+	suite.NoError(logger.Verify())
+}
+
+func (suite *AuditSuite) TestQuery() {
+	sink, err := NewFileSink(suite.dir, 0, 0)
+	suite.Require().NoError(err)
+	logger, err := NewLogger(sink, nil)
+	suite.Require().NoError(err)
+	defer logger.Close()
+
+	base := time.Date(2019, 7, 1, 10, 0, 0, 0, time.UTC)
+	for i, key := range []string{"111", "222", "111", "333"} {
+		rec := mockRecord(key)
+		rec.Time = base.Add(time.Duration(i) * time.Hour)
+		suite.Require().NoError(logger.Log(rec))
+	}
+
+	records, err := logger.Query(Query{SessionKey: "111"})
+	suite.Require().NoError(err)
+	suite.Len(records, 2)
+
+	records, err = logger.Query(Query{From: base.Add(time.Hour), To: base.Add(2 * time.Hour)})
+	suite.Require().NoError(err)
+	suite.Len(records, 2)
+	suite.Equal("222", records[0].Checks[0].CheckSessionKey)
+}
+
+func (suite *AuditSuite) TestValuePolicy() {
+	policy := &ValuePolicy{Modes: map[string]string{"general": ValueHash, "pii": ValueRedact, "result": ValuePlain}}
+	logger, err := NewLogger(NewMemorySink(), policy)
+	suite.Require().NoError(err)
+
+	collection := models.DeviceCheckDetailsObjectCollection{
+		{CheckSessionKey: "1", ActivityData: []*models.KeyValuePairObject{
+			{KvpKey: "ip.address", KvpValue: "1.23.45.123", KvpType: models.EnumKVPTypeGeneralString},
+			{KvpKey: "name", KvpValue: "Jane Citizen", KvpType: models.EnumKVPType("pii.name")},
+			{KvpKey: "code", KvpValue: "A1", KvpType: models.EnumKVPType("result.code")},
+		}},
+	}
+
+	kvps := logger.Checks(collection)[0].ActivityData
+	suite.True(strings.HasPrefix(kvps[0].Value, "sha256:"))
+	suite.NotContains(kvps[0].Value, "1.23.45.123")
+	suite.Equal(redacted, kvps[1].Value)
+	suite.Equal("A1", kvps[2].Value)
+
+	data, _ := json.Marshal(kvps)
+	suite.NotContains(string(data), "Jane")
+}
+
+func (suite *AuditSuite) TestEncryptedValuePolicy() {
+	keyFile := filepath.Join(suite.dir, "keys.json")
+	_, err := envelope.RotateKeyFile(keyFile)
+	suite.Require().NoError(err)
+	provider, err := envelope.NewLocalKeyProvider(keyFile)
+	suite.Require().NoError(err)
+	encryptor := envelope.NewEncryptor(provider)
+
+	policy := &ValuePolicy{Modes: map[string]string{"pii": ValueEncrypt, "id": ValueToken}, Encryptor: encryptor}
+	name := policy.Apply(models.EnumKVPTypePiiName, "Jane Citizen")
+	suite.True(envelope.IsEnvelope(name))
+	plaintext, err := encryptor.Decrypt(string(models.EnumKVPTypePiiName), name)
+	suite.NoError(err)
+	suite.Equal("Jane Citizen", plaintext)
+
+	token, _ := encryptor.Token(string(models.EnumKVPTypeIDExternal), "A-123")
+	suite.Equal(token, policy.Apply(models.EnumKVPTypeIDExternal, "A-123"))
+
+	// Without keys the value is redacted rather than written in plain text
+	policy.Encryptor = nil
+	suite.Equal(redacted, policy.Apply(models.EnumKVPTypePiiName, "Jane Citizen"))
+
+	_, err = NewLoggerFromConfig(config.AuditConfig{Dir: suite.dir, ChainKey: "k", ValuePolicy: map[string]string{"pii": ValueEncrypt}}, nil)
+	suite.Error(err)
+}
+
+func (suite *AuditSuite) TestNewLoggerFromConfig() {
+	_, err := NewLoggerFromConfig(config.AuditConfig{Enabled: true, Dir: suite.dir}, nil)
+	suite.ErrorContains(err, "chain key is required")
+
+	logger, err := NewLoggerFromConfig(config.AuditConfig{Enabled: true, Dir: suite.dir, ChainKey: "k"}, nil)
+	suite.Require().NoError(err)
+	defer logger.Close()
+	suite.Equal([]byte("k"), logger.chainKey)
+}
+
+func mockRecord(sessionKey string) *Record {
+	return &Record{
+		Caller:  "test",
+		Checks:  []CheckRecord{{CheckSessionKey: sessionKey, CheckType: "DEVICE", ActivityType: "SIGNUP"}},
+		Outcome: OutcomePass,
+		Latency: time.Millisecond,
+	}
+}
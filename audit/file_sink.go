@@ -0,0 +1,246 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	activeFileName = "audit.jsonl"
+	rotatedPrefix  = "audit-"
+	fileSuffix     = ".jsonl"
+)
+
+// FileSink writes audit records as JSON lines to dir/audit.jsonl.
+// Once the active file grows beyond maxBytes it is renamed to
+// audit-<last sequence>.jsonl and a new active file is started.
+type FileSink struct {
+	mu       sync.Mutex
+	dir      string
+	maxBytes int64
+	maxFiles int
+	file     *os.File
+	size     int64
+	lastSeq  uint64
+}
+
+// NewFileSink opens (or creates) the audit trail in dir. maxBytes <= 0
+// disables rotation, maxFiles <= 0 keeps every rotated file.
+func NewFileSink(dir string, maxBytes int64, maxFiles int) (*FileSink, error) {
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return nil, err
+	}
+
+	s := &FileSink{dir: dir, maxBytes: maxBytes, maxFiles: maxFiles}
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+
+	last, err := s.Last()
+	if err != nil {
+		s.Close()
+		return nil, err
+	}
+	if last != nil {
+		s.lastSeq = last.Sequence
+	}
+	return s, nil
+}
+
+func (s *FileSink) open() error {
+	f, err := os.OpenFile(filepath.Join(s.dir, activeFileName), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0640)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	s.file = f
+	s.size = info.Size()
+	return nil
+}
+
+func (s *FileSink) Write(rec *Record) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil {
+		return fmt.Errorf("audit file sink is closed")
+	}
+
+	if s.maxBytes > 0 && s.size > 0 && s.size+int64(len(data)) > s.maxBytes {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+	}
+
+	n, err := s.file.Write(data)
+	s.size += int64(n)
+	if err != nil {
+		return err
+	}
+	s.lastSeq = rec.Sequence
+	return nil
+}
+
+func (s *FileSink) rotate() error {
+	if err := s.file.Close(); err != nil {
+		return err
+	}
+	s.file = nil
+
+	rotated := filepath.Join(s.dir, fmt.Sprintf("%s%020d%s", rotatedPrefix, s.lastSeq, fileSuffix))
+	if err := os.Rename(filepath.Join(s.dir, activeFileName), rotated); err != nil {
+		return err
+	}
+
+	if err := s.prune(); err != nil {
+		return err
+	}
+	return s.open()
+}
+
+// prune removes the oldest rotated files above maxFiles
+func (s *FileSink) prune() error {
+	if s.maxFiles <= 0 {
+		return nil
+	}
+	rotated, err := s.rotatedFiles()
+	if err != nil {
+		return err
+	}
+	for len(rotated) > s.maxFiles {
+		if err := os.Remove(rotated[0]); err != nil {
+			return err
+		}
+		rotated = rotated[1:]
+	}
+	return nil
+}
+
+// rotatedFiles returns the rotated audit files, oldest first
+func (s *FileSink) rotatedFiles() ([]string, error) {
+	entries, err := ioutil.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, e := range entries {
+		name := e.Name()
+		if strings.HasPrefix(name, rotatedPrefix) && strings.HasSuffix(name, fileSuffix) {
+			files = append(files, filepath.Join(s.dir, name))
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// files returns every audit file in chain order
+func (s *FileSink) files() ([]string, error) {
+	files, err := s.rotatedFiles()
+	if err != nil {
+		return nil, err
+	}
+	return append(files, filepath.Join(s.dir, activeFileName)), nil
+}
+
+func (s *FileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
+
+// Query scans the audit files and returns the matching records in chain order
+func (s *FileSink) Query(q Query) ([]*Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	files, err := s.files()
+	if err != nil {
+		return nil, err
+	}
+
+	var res []*Record
+	for _, name := range files {
+		err := readRecords(name, func(rec *Record) {
+			if q.Match(rec) {
+				res = append(res, rec)
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// Last returns the most recently written record, or nil for an empty trail
+func (s *FileSink) Last() (*Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	files, err := s.files()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := len(files) - 1; i >= 0; i-- {
+		var last *Record
+		err := readRecords(files[i], func(rec *Record) {
+			last = rec
+		})
+		if err != nil {
+			return nil, err
+		}
+		if last != nil {
+			return last, nil
+		}
+	}
+	return nil, nil
+}
+
+func readRecords(name string, fn func(rec *Record)) error {
+	f, err := os.Open(name)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		rec := &Record{}
+		if err := json.Unmarshal(line, rec); err != nil {
+			return fmt.Errorf("audit file %s: %v", filepath.Base(name), err)
+		}
+		fn(rec)
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,152 @@
+package audit
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"universalsdk/config"
+	"universalsdk/envelope"
+	"universalsdk/models"
+)
+
+// Logger appends hash-chained records of device checks to a Sink
+type Logger struct {
+	mu       sync.Mutex
+	sink     Sink
+	policy   *ValuePolicy
+	chainKey []byte
+	seq      uint64
+	lastHash string
+}
+
+// Option configures optional behaviour of the Logger
+type Option func(*Logger)
+
+// WithChainKey keys the hash chain of the records with an HMAC, so a record
+// can't be edited and the chain recomputed without the key
+func WithChainKey(key []byte) Option {
+	return func(l *Logger) {
+		l.chainKey = key
+	}
+}
+
+// NewLogger creates an audit Logger writing to sink. If the sink already
+// holds records the chain is continued from the last one.
+func NewLogger(sink Sink, policy *ValuePolicy, opts ...Option) (*Logger, error) {
+	l := &Logger{sink: sink, policy: policy}
+	for _, opt := range opts {
+		opt(l)
+	}
+
+	if tailer, ok := sink.(Tailer); ok {
+		last, err := tailer.Last()
+		if err != nil {
+			return nil, err
+		}
+		if last != nil {
+			l.seq = last.Sequence
+			l.lastHash = last.Hash
+		}
+	}
+	return l, nil
+}
+
+// NewLoggerFromConfig creates a Logger backed by the rotating file sink,
+// chained with the configured key. The encryptor, which may be nil, is
+// needed by the encrypt and token value modes.
+func NewLoggerFromConfig(cfg config.AuditConfig, encryptor *envelope.Encryptor) (*Logger, error) {
+	if cfg.ChainKey == "" {
+		return nil, fmt.Errorf("audit chain key is required, without it edited records can be chained again")
+	}
+	for family, mode := range cfg.ValuePolicy {
+		if (mode == ValueEncrypt || mode == ValueToken) && encryptor == nil {
+			return nil, fmt.Errorf("audit value policy %s of %s requires encryption keys", mode, family)
+		}
+	}
+
+	sink, err := NewFileSink(cfg.Dir, cfg.MaxBytes, cfg.MaxFiles)
+	if err != nil {
+		return nil, err
+	}
+	policy := &ValuePolicy{Modes: cfg.ValuePolicy, HashKey: []byte(cfg.HashKey), Encryptor: encryptor}
+	return NewLogger(sink, policy, WithChainKey([]byte(cfg.ChainKey)))
+}
+
+// Log completes the record with its sequence and chain hash and writes it
+func (l *Logger) Log(rec *Record) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	rec.Sequence = l.seq + 1
+	rec.PrevHash = l.lastHash
+	if rec.Time.IsZero() {
+		rec.Time = time.Now()
+	}
+	rec.Time = rec.Time.UTC()
+
+	hash, err := rec.computeHash(l.chainKey)
+	if err != nil {
+		return err
+	}
+	rec.Hash = hash
+
+	if err := l.sink.Write(rec); err != nil {
+		return err
+	}
+
+	l.seq = rec.Sequence
+	l.lastHash = rec.Hash
+	return nil
+}
+
+// Checks converts the request collection to audit check records,
+// applying the value policy to every KVP value.
+func (l *Logger) Checks(collection models.DeviceCheckDetailsObjectCollection) []CheckRecord {
+	checks := make([]CheckRecord, 0, len(collection))
+	for _, elem := range collection {
+		if elem == nil {
+			continue
+		}
+		check := CheckRecord{
+			CheckSessionKey: elem.CheckSessionKey,
+			CheckType:       elem.CheckType,
+			ActivityType:    elem.ActivityType,
+			JourneyID:       elem.JourneyID,
+		}
+		for _, kvp := range elem.ActivityData {
+			if kvp == nil {
+				continue
+			}
+			check.ActivityData = append(check.ActivityData, KVPRecord{
+				Key:   kvp.KvpKey,
+				Type:  string(kvp.KvpType),
+				Value: l.policy.Apply(kvp.KvpType, kvp.KvpValue),
+			})
+		}
+		checks = append(checks, check)
+	}
+	return checks
+}
+
+// Query returns the records selected by q, if the sink supports reading
+func (l *Logger) Query(q Query) ([]*Record, error) {
+	querier, ok := l.sink.(Querier)
+	if !ok {
+		return nil, fmt.Errorf("audit sink does not support queries")
+	}
+	return querier.Query(q)
+}
+
+// Verify reads back the whole trail and checks its hash chain
+func (l *Logger) Verify() error {
+	records, err := l.Query(Query{})
+	if err != nil {
+		return err
+	}
+	return VerifyChain(records, l.chainKey)
+}
+
+func (l *Logger) Close() error {
+	return l.sink.Close()
+}
@@ -0,0 +1,95 @@
+package audit
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"universalsdk/envelope"
+	"universalsdk/models"
+)
+
+// How a KVP value is written to the audit trail
+const (
+	ValueHash   = "hash"
+	ValueRedact = "redact"
+	ValuePlain  = "plain"
+
+	// Envelope encrypted, needs an Encryptor
+	ValueEncrypt = "encrypt"
+
+	// Deterministic token that can be searched for, needs an Encryptor
+	ValueToken = "token"
+)
+
+const redacted = "[REDACTED]"
+
+// ValuePolicy decides how KVP values are stored, per kvpType family.
+// The family is the part of the kvpType before the first dot, so "pii"
+// covers pii.name, pii.address etc. Unknown families are hashed.
+type ValuePolicy struct {
+	Modes     map[string]string
+	HashKey   []byte
+	Encryptor *envelope.Encryptor
+}
+
+// Apply returns the value as it should be stored for the given kvpType
+func (p *ValuePolicy) Apply(kvpType models.EnumKVPType, value string) string {
+	if value == "" {
+		return ""
+	}
+
+	family := string(kvpType)
+	if i := strings.Index(family, "."); i >= 0 {
+		family = family[:i]
+	}
+
+	mode := ValueHash
+	if p != nil {
+		if m, ok := p.Modes[family]; ok {
+			mode = m
+		}
+	}
+
+	switch mode {
+	case ValuePlain:
+		return value
+	case ValueRedact:
+		return redacted
+	case ValueEncrypt, ValueToken:
+		return p.protect(mode, kvpType, value)
+	default:
+		return "sha256:" + p.hash(value)
+	}
+}
+
+// protect encrypts or tokenises the value, which is redacted rather than
+// written in plain text when that fails
+func (p *ValuePolicy) protect(mode string, kvpType models.EnumKVPType, value string) string {
+	if p.Encryptor == nil {
+		return redacted
+	}
+
+	var protected string
+	var err error
+	if mode == ValueEncrypt {
+		protected, err = p.Encryptor.Encrypt(string(kvpType), value)
+	} else {
+		protected, err = p.Encryptor.Token(string(kvpType), value)
+	}
+	if err != nil {
+		return redacted
+	}
+	return protected
+}
+
+func (p *ValuePolicy) hash(value string) string {
+	if p != nil && len(p.HashKey) > 0 {
+		mac := hmac.New(sha256.New, p.HashKey)
+		mac.Write([]byte(value))
+		return hex.EncodeToString(mac.Sum(nil))
+	}
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
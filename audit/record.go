@@ -0,0 +1,113 @@
+package audit
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Outcome of an audited device check call
+const (
+	OutcomePass  = "pass"
+	OutcomeFail  = "fail"
+	OutcomeError = "error"
+)
+
+// Record is a single entry of the audit trail, one per /isgood call.
+// Records are hash-chained: Hash covers the whole record including
+// PrevHash, so altering or removing an entry breaks the chain. The hash is
+// keyed with the chain key, without which the chain can't be recomputed
+// after editing a record.
+type Record struct {
+	Sequence uint64    `json:"seq"`
+	Time     time.Time `json:"time"`
+
+	// Caller is the caller its API key belongs to when Authenticated,
+	// otherwise the identity the caller declared (X-Caller-Id). RemoteAddr
+	// is the host the request came from.
+	Caller        string `json:"caller,omitempty"`
+	Authenticated bool   `json:"authenticated,omitempty"`
+	RemoteAddr    string `json:"remoteAddr,omitempty"`
+
+	Checks    []CheckRecord `json:"checks,omitempty"`
+	Errors    []string      `json:"errors,omitempty"`
+	ErrorCode int64         `json:"errorCode,omitempty"`
+	Outcome   string        `json:"outcome"`
+	Latency   time.Duration `json:"latencyNs"`
+	PrevHash  string        `json:"prevHash"`
+	Hash      string        `json:"hash"`
+}
+
+// CheckRecord captures one element of the DeviceCheckDetailsObjectCollection
+type CheckRecord struct {
+	CheckSessionKey string      `json:"checkSessionKey,omitempty"`
+	CheckType       string      `json:"checkType,omitempty"`
+	ActivityType    string      `json:"activityType,omitempty"`
+	JourneyID       string      `json:"journeyId,omitempty"`
+	ActivityData    []KVPRecord `json:"activityData,omitempty"`
+}
+
+// KVPRecord captures a key value pair. Value is hashed or redacted
+// according to the ValuePolicy of the kvpType.
+type KVPRecord struct {
+	Key   string `json:"key"`
+	Type  string `json:"type,omitempty"`
+	Value string `json:"value,omitempty"`
+}
+
+// HasSessionKey reports whether any check of the record used the session key
+func (r *Record) HasSessionKey(sessionKey string) bool {
+	for _, c := range r.Checks {
+		if c.CheckSessionKey == sessionKey {
+			return true
+		}
+	}
+	return false
+}
+
+// computeHash returns the chain hash of the record, ignoring the current
+// Hash field: its HMAC-SHA256 with key, or its plain SHA-256 without key
+func (r *Record) computeHash(key []byte) (string, error) {
+	cp := *r
+	cp.Hash = ""
+	data, err := json.Marshal(&cp)
+	if err != nil {
+		return "", err
+	}
+	if len(key) == 0 {
+		sum := sha256.Sum256(data)
+		return hex.EncodeToString(sum[:]), nil
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write(data)
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// VerifyChain checks that records form an unbroken hash chain, in order,
+// keyed with the chain key the records were logged with. The first record
+// is trusted as the anchor of the chain.
+func VerifyChain(records []*Record, key []byte) error {
+	for i, rec := range records {
+		if i > 0 {
+			prev := records[i-1]
+			if rec.PrevHash != prev.Hash {
+				return fmt.Errorf("audit record %d does not chain to record %d", rec.Sequence, prev.Sequence)
+			}
+			if rec.Sequence != prev.Sequence+1 {
+				return fmt.Errorf("audit record %d follows record %d, sequence gap", rec.Sequence, prev.Sequence)
+			}
+		}
+
+		hash, err := rec.computeHash(key)
+		if err != nil {
+			return err
+		}
+		if !hmac.Equal([]byte(hash), []byte(rec.Hash)) {
+			return fmt.Errorf("audit record %d hash mismatch", rec.Sequence)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package audit
+
+import (
+	"sync"
+	"time"
+)
+
+// Sink persists audit records. Implementations must keep records in the
+// order they are written.
+type Sink interface {
+	Write(rec *Record) error
+	Close() error
+}
+
+// Querier is implemented by sinks that can read their records back
+type Querier interface {
+	Query(q Query) ([]*Record, error)
+}
+
+// Tailer is implemented by sinks that can return the last written record,
+// so a restarted Logger continues the existing chain.
+type Tailer interface {
+	Last() (*Record, error)
+}
+
+// Query selects audit records. Zero values are not used as a filter.
+type Query struct {
+	SessionKey string
+	From       time.Time
+	To         time.Time
+}
+
+// Match reports whether the record is selected by the query
+func (q Query) Match(rec *Record) bool {
+	if q.SessionKey != "" && !rec.HasSessionKey(q.SessionKey) {
+		return false
+	}
+	if !q.From.IsZero() && rec.Time.Before(q.From) {
+		return false
+	}
+	if !q.To.IsZero() && rec.Time.After(q.To) {
+		return false
+	}
+	return true
+}
+
+// MemorySink keeps audit records in process memory
+type MemorySink struct {
+	mu      sync.RWMutex
+	records []*Record
+}
+
+func NewMemorySink() *MemorySink {
+	return &MemorySink{}
+}
+
+func (s *MemorySink) Write(rec *Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cp := *rec
+	s.records = append(s.records, &cp)
+	return nil
+}
+
+func (s *MemorySink) Close() error {
+	return nil
+}
+
+func (s *MemorySink) Query(q Query) ([]*Record, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var res []*Record
+	for _, rec := range s.records {
+		if q.Match(rec) {
+			cp := *rec
+			res = append(res, &cp)
+		}
+	}
+	return res, nil
+}
+
+func (s *MemorySink) Last() (*Record, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if len(s.records) == 0 {
+		return nil, nil
+	}
+	cp := *s.records[len(s.records)-1]
+	return &cp, nil
+}
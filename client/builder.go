@@ -0,0 +1,57 @@
+package client
+
+import (
+	"strconv"
+
+	"universalsdk/models"
+)
+
+// ActivityDataBuilder builds typed KeyValuePairObjects, formatting values
+// the way the server parses them.
+//
+//	data := client.NewActivityData().
+//		String("ip.address", "1.23.45.123").
+//		Int("login.attempts", 3).
+//		Build()
+type ActivityDataBuilder struct {
+	kvps []*models.KeyValuePairObject
+}
+
+func NewActivityData() *ActivityDataBuilder {
+	return &ActivityDataBuilder{}
+}
+
+func (b *ActivityDataBuilder) add(key, value string, kvpType models.EnumKVPType) *ActivityDataBuilder {
+	b.kvps = append(b.kvps, &models.KeyValuePairObject{KvpKey: key, KvpValue: value, KvpType: kvpType})
+	return b
+}
+
+func (b *ActivityDataBuilder) String(key, value string) *ActivityDataBuilder {
+	return b.add(key, value, models.EnumKVPTypeGeneralString)
+}
+
+func (b *ActivityDataBuilder) Int(key string, value int64) *ActivityDataBuilder {
+	return b.add(key, strconv.FormatInt(value, 10), models.EnumKVPTypeGeneralInteger)
+}
+
+func (b *ActivityDataBuilder) Float(key string, value float64) *ActivityDataBuilder {
+	return b.add(key, strconv.FormatFloat(value, 'g', -1, 64), models.EnumKVPTypeGeneralFloat)
+}
+
+func (b *ActivityDataBuilder) Bool(key string, value bool) *ActivityDataBuilder {
+	return b.add(key, strconv.FormatBool(value), models.EnumKVPTypeGeneralBool)
+}
+
+// Typed adds a pre-formatted value of any EnumKVPType
+func (b *ActivityDataBuilder) Typed(key, value string, kvpType models.EnumKVPType) *ActivityDataBuilder {
+	return b.add(key, value, kvpType)
+}
+
+func (b *ActivityDataBuilder) Build() []*models.KeyValuePairObject {
+	return b.kvps
+}
+
+// NewCheck creates a DeviceCheckDetailsObject for the given check and activity type
+func NewCheck(checkType, activityType string, activityData []*models.KeyValuePairObject) *models.DeviceCheckDetailsObject {
+	return &models.DeviceCheckDetailsObject{CheckType: checkType, ActivityType: activityType, ActivityData: activityData}
+}
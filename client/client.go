@@ -0,0 +1,243 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"universalsdk/kvp"
+	"universalsdk/models"
+	"universalsdk/sessionkey"
+)
+
+// Client calls the /isgood device check API
+type Client struct {
+	baseURL    string
+	route      string
+	httpClient *http.Client
+	retries    int
+	backoff    time.Duration
+	callerID   string
+	apiKey     string
+	adminToken string
+	keyScope   kvp.KeyScope
+	keys       sessionkey.Generator
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithHTTPClient sets the http.Client used for requests
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithRoute overrides the device check route, "/isgood" by default
+func WithRoute(route string) Option {
+	return func(c *Client) {
+		c.route = route
+	}
+}
+
+// WithRetries sets how many times a failed call is retried and the
+// initial backoff, doubled after every attempt. Calls are not retried by
+// default: a retry sends the same session keys again, which the server
+// only answers with the first response when replay is enabled
+// (session.replayTTL), otherwise a check that reached the server on an
+// earlier attempt fails as a reused session key.
+func WithRetries(retries int, backoff time.Duration) Option {
+	return func(c *Client) {
+		c.retries = retries
+		c.backoff = backoff
+	}
+}
+
+// WithCallerID identifies the calling service in the server audit trail
+func WithCallerID(callerID string) Option {
+	return func(c *Client) {
+		c.callerID = callerID
+	}
+}
+
+// WithAPIKey sets the API key sent with device checks, required when the
+// server authenticates its callers (check.callers)
+func WithAPIKey(apiKey string) Option {
+	return func(c *Client) {
+		c.apiKey = apiKey
+	}
+}
+
+// WithAdminToken sets the admin token sent to the admin routes, such as the one of Journey
+func WithAdminToken(token string) Option {
+	return func(c *Client) {
+		c.adminToken = token
+	}
+}
+
+// WithKeyScope validates kvpKey uniqueness within the scope the server is configured with
+func WithKeyScope(scope kvp.KeyScope) Option {
+	return func(c *Client) {
+		c.keyScope = scope
+	}
+}
+
+// WithSessionKeys sets the generator of the session keys given to checks
+// without one, e.g. with a fake clock and seeded randomness in tests
+func WithSessionKeys(keys sessionkey.Generator) Option {
+	return func(c *Client) {
+		c.keys = keys
+	}
+}
+
+// New creates a Client for the server at baseURL, e.g. "http://localhost:8080"
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		route:      "/isgood",
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		retries:    0,
+		backoff:    200 * time.Millisecond,
+		keyScope:   kvp.DefaultKeyScope,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// DeviceCheck validates the collection and submits it to the server.
+//
+// Elements without a CheckSessionKey are given a generated one before the
+// first attempt, so every retry sends the same session keys. The key is set
+// on the element of the caller's collection, which is how the caller learns
+// it; nothing else of the collection is changed, and it must not be used
+// concurrently during the call. Transport errors and 5xx responses are
+// retried when enabled with WithRetries, error responses of the API are
+// returned as *APIError and client-side validation failures as *ValidationError.
+func (c *Client) DeviceCheck(ctx context.Context, collection models.DeviceCheckDetailsObjectCollection) (*models.DeviceCheckResponseObject, error) {
+	if errs := ValidateScope(collection, c.keyScope); len(errs) > 0 {
+		return nil, &ValidationError{Errors: errs}
+	}
+
+	for _, elem := range collection {
+		if elem != nil && elem.CheckSessionKey == "" {
+			sessionKey, err := c.keys.New()
+			if err != nil {
+				return nil, err
+			}
+			elem.CheckSessionKey = sessionKey
+		}
+	}
+
+	body, err := json.Marshal(collection)
+	if err != nil {
+		return nil, err
+	}
+
+	backoff := c.backoff
+	for attempt := 0; ; attempt++ {
+		resp, err := c.post(ctx, body)
+		if err == nil || attempt >= c.retries || !retryable(err) {
+			return resp, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+func (c *Client) post(ctx context.Context, body []byte) (*models.DeviceCheckResponseObject, error) {
+	puppy := &models.DeviceCheckResponseObject{}
+	if err := c.do(ctx, "POST", c.route, body, puppy); err != nil {
+		return nil, err
+	}
+	return puppy, nil
+}
+
+// Journey returns the history of the checks of a journey, an admin route
+// needing WithAdminToken
+func (c *Client) Journey(ctx context.Context, journeyID string) (*models.JourneyObject, error) {
+	journey := &models.JourneyObject{}
+	if err := c.do(ctx, "GET", "/admin/journeys/"+url.PathEscape(journeyID), nil, journey); err != nil {
+		return nil, err
+	}
+	return journey, nil
+}
+
+// do sends a request to path and decodes the JSON response into out
+func (c *Client) do(ctx context.Context, method, path string, body []byte, out interface{}) error {
+	var reqBody io.Reader
+	if body != nil {
+		reqBody = bytes.NewReader(body)
+	}
+	req, err := http.NewRequest(method, c.baseURL+path, reqBody)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.callerID != "" {
+		req.Header.Set("X-Caller-Id", c.callerID)
+	}
+	if c.apiKey != "" && !strings.HasPrefix(path, "/admin/") {
+		req.Header.Set("X-Api-Key", c.apiKey)
+	}
+	if c.adminToken != "" && strings.HasPrefix(path, "/admin/") {
+		req.Header.Set("Authorization", "Bearer "+c.adminToken)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		errorObj := models.ErrorObject{}
+		if err := json.Unmarshal(data, &errorObj); err != nil || errorObj.Message == "" {
+			errorObj.Message = http.StatusText(resp.StatusCode)
+		}
+		return &APIError{StatusCode: resp.StatusCode, Code: ErrorCode(errorObj.Code), Message: errorObj.Message}
+	}
+
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("usdk: invalid response: %v", err)
+	}
+	return nil
+}
+
+// retryable reports whether the call may succeed when sent again.
+// Cancellation of the context is handled by the retry loop.
+func retryable(err error) bool {
+	if apiErr, ok := err.(*APIError); ok {
+		return apiErr.StatusCode >= 500
+	}
+	return true
+}
+
+// NewSessionKey generates a random checkSessionKey: a version 7 UUID,
+// so keys sort by the time they were created
+func NewSessionKey() (string, error) {
+	return sessionkey.New()
+}
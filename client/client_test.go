@@ -0,0 +1,181 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/suite"
+	"universalsdk/controller"
+	"universalsdk/kvp"
+	"universalsdk/models"
+	"universalsdk/service"
+	"universalsdk/sessionkey"
+	"universalsdk/testutil"
+)
+
+type ClientSuite struct {
+	suite.Suite
+	server   *httptest.Server
+	failures int32
+}
+
+func TestClientSuite(t *testing.T) {
+	suite.Run(t, new(ClientSuite))
+}
+
+func (suite *ClientSuite) SetupTest() {
+	var sessionKeyMap sync.Map
+	journeys := service.NewJourneyStore(service.DefaultTransitions(), service.DefaultJourneyTTL)
+	usdkController := controller.NewUsdkController(service.NewUsdkService(&sessionKeyMap, service.WithJourneyStore(journeys)))
+	journeyController := controller.NewJourneyController(service.NewJourneyService(journeys))
+	adminController := controller.NewAdminController(service.NewSessionKeyService(&sessionKeyMap), "s3cret")
+
+	router := mux.NewRouter()
+	router.HandleFunc("/isgood", usdkController.DeviceCheck).Methods("POST")
+	router.HandleFunc("/admin/journeys/{journeyId}", adminController.Authenticate(journeyController.LookupJourney)).Methods("GET")
+
+	// Fails the configured number of calls before reaching the controller
+	suite.failures = 0
+	flaky := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&suite.failures, -1) >= 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		router.ServeHTTP(w, r)
+	})
+	suite.server = httptest.NewServer(flaky)
+}
+
+func (suite *ClientSuite) TearDownTest() {
+	suite.server.Close()
+}
+
+func (suite *ClientSuite) TestDeviceCheck() {
+	c := New(suite.server.URL)
+	data := NewActivityData().
+		String("ip.address", "1.23.45.123").
+		Int("login.attempts", 3).
+		Float("risk.score", 0.25).
+		Bool("vpn", false).
+		Build()
+	check := NewCheck(models.DeviceCheckDetailsObjectCheckTypeDEVICE, models.DeviceCheckDetailsObjectActivityTypeSIGNUP, data)
+
+	resp, err := c.DeviceCheck(context.Background(), models.DeviceCheckDetailsObjectCollection{check})
+	suite.Require().NoError(err)
+	suite.True(resp.Puppy)
+	_, ok := sessionkey.Time(check.CheckSessionKey)
+	suite.True(ok, "expected a generated UUIDv7 session key, got %q", check.CheckSessionKey)
+
+	// Reusing the session key is reported as a typed error
+	_, err = c.DeviceCheck(context.Background(), models.DeviceCheckDetailsObjectCollection{check})
+	suite.Require().Error(err)
+	suite.True(IsCode(err, CodeCheckFailed), "expected check failed error, got %v", err)
+}
+
+func (suite *ClientSuite) TestClientSideValidation() {
+	c := New(suite.server.URL)
+	data := NewActivityData().
+		Typed("ip.address", "1.23.45.123", models.EnumKVPTypeGeneralBool).
+		Typed("amount", "ten", models.EnumKVPTypeGeneralFloat).
+		Build()
+	check := NewCheck("DUMMY", models.DeviceCheckDetailsObjectActivityTypeLOGIN, data)
+
+	_, err := c.DeviceCheck(context.Background(), models.DeviceCheckDetailsObjectCollection{check})
+	suite.Require().Error(err)
+	validationErr, ok := err.(*ValidationError)
+	suite.Require().True(ok, "expected *ValidationError, got %T", err)
+	suite.Len(validationErr.Errors, 3)
+	suite.Empty(check.CheckSessionKey, "invalid request must not be sent")
+
+	// Null checks and activity data are reported, not sent
+	valid := NewCheck(models.DeviceCheckDetailsObjectCheckTypeDEVICE, models.DeviceCheckDetailsObjectActivityTypeLOGIN, []*models.KeyValuePairObject{nil})
+	errs := Validate(models.DeviceCheckDetailsObjectCollection{nil, valid})
+	suite.Require().Len(errs, 2)
+	suite.EqualError(errs[0], "0 in body is required")
+	suite.EqualError(errs[1], "1.activityData.0 in body is required")
+}
+
+func (suite *ClientSuite) TestValidateScope() {
+	data := NewActivityData().String("ip.address", "1.23.45.123").Build()
+	batch := models.DeviceCheckDetailsObjectCollection{
+		NewCheck(models.DeviceCheckDetailsObjectCheckTypeDEVICE, models.DeviceCheckDetailsObjectActivityTypeLOGIN, data),
+		NewCheck(models.DeviceCheckDetailsObjectCheckTypeDEVICE, models.DeviceCheckDetailsObjectActivityTypeLOGIN, data),
+	}
+
+	suite.Empty(Validate(batch))
+	errs := ValidateScope(batch, kvp.KeyScopeCollection)
+	suite.Require().Len(errs, 1)
+	suite.EqualError(errs[0], "KvpKey ip.address is not unique within the request")
+}
+
+func (suite *ClientSuite) TestJourney() {
+	c := New(suite.server.URL, WithAdminToken("s3cret"))
+	for _, activityType := range []string{"LOGIN", "PAYMENT"} {
+		check := NewCheck(models.DeviceCheckDetailsObjectCheckTypeDEVICE, activityType, NewActivityData().String("ip.address", "1.23.45.123").Build())
+		check.JourneyID = "journey-1"
+		_, err := c.DeviceCheck(context.Background(), models.DeviceCheckDetailsObjectCollection{check})
+		suite.Require().NoError(err)
+	}
+
+	journey, err := c.Journey(context.Background(), "journey-1")
+	suite.Require().NoError(err)
+	suite.Len(journey.Steps, 2)
+
+	_, err = c.Journey(context.Background(), "unknown")
+	suite.True(IsCode(err, CodeNotFound), "expected not found, got %v", err)
+
+	_, err = New(suite.server.URL).Journey(context.Background(), "journey-1")
+	suite.True(IsCode(err, CodeUnauthorized), "expected unauthorized, got %v", err)
+}
+
+func (suite *ClientSuite) TestServerSideError() {
+	c := New(suite.server.URL, WithRoute("/missing"), WithRetries(0, 0))
+	check := NewCheck(models.DeviceCheckDetailsObjectCheckTypeDEVICE, models.DeviceCheckDetailsObjectActivityTypeLOGIN, nil)
+
+	_, err := c.DeviceCheck(context.Background(), models.DeviceCheckDetailsObjectCollection{check})
+	apiErr, ok := err.(*APIError)
+	suite.Require().True(ok, "expected *APIError, got %T", err)
+	suite.Equal(http.StatusNotFound, apiErr.StatusCode)
+}
+
+func (suite *ClientSuite) TestRetry() {
+	atomic.StoreInt32(&suite.failures, 2)
+	c := New(suite.server.URL, WithRetries(2, time.Millisecond))
+	check := NewCheck(models.DeviceCheckDetailsObjectCheckTypeDEVICE, models.DeviceCheckDetailsObjectActivityTypeLOGIN, nil)
+
+	resp, err := c.DeviceCheck(context.Background(), models.DeviceCheckDetailsObjectCollection{check})
+	suite.Require().NoError(err)
+	suite.True(resp.Puppy)
+
+	// Not retried by default
+	atomic.StoreInt32(&suite.failures, 1)
+	check = NewCheck(models.DeviceCheckDetailsObjectCheckTypeDEVICE, models.DeviceCheckDetailsObjectActivityTypeLOGIN, nil)
+	_, err = New(suite.server.URL).DeviceCheck(context.Background(), models.DeviceCheckDetailsObjectCollection{check})
+	apiErr, ok := err.(*APIError)
+	suite.Require().True(ok, "expected *APIError, got %T", err)
+	suite.Equal(http.StatusServiceUnavailable, apiErr.StatusCode)
+
+	// Out of retries
+	atomic.StoreInt32(&suite.failures, 2)
+	c = New(suite.server.URL, WithRetries(1, time.Millisecond))
+	check = NewCheck(models.DeviceCheckDetailsObjectCheckTypeDEVICE, models.DeviceCheckDetailsObjectActivityTypeLOGIN, nil)
+	_, err = c.DeviceCheck(context.Background(), models.DeviceCheckDetailsObjectCollection{check})
+	suite.Error(err)
+}
+
+func (suite *ClientSuite) TestSessionKeys() {
+	fixtures := testutil.New(7)
+	expected := testutil.New(7).SessionKey()
+
+	c := New(suite.server.URL, WithSessionKeys(fixtures.SessionKeys()))
+	check := NewCheck(models.DeviceCheckDetailsObjectCheckTypeDEVICE, models.DeviceCheckDetailsObjectActivityTypeSIGNUP, NewActivityData().String("ip.address", "1.23.45.123").Build())
+	_, err := c.DeviceCheck(context.Background(), models.DeviceCheckDetailsObjectCollection{check})
+	suite.Require().NoError(err)
+	suite.Equal(expected, check.CheckSessionKey)
+}
@@ -0,0 +1,50 @@
+package client
+
+import (
+	"fmt"
+	"strings"
+
+	"universalsdk/models"
+)
+
+// ErrorCode mirrors the codes returned in models.ErrorObject
+type ErrorCode int64
+
+const (
+	CodeContentType    = ErrorCode(models.ErrorCodeContentType)
+	CodeInvalidRequest = ErrorCode(models.ErrorCodeInvalidRequest)
+	CodeCheckFailed    = ErrorCode(models.ErrorCodeCheckFailed)
+	CodeUnauthorized   = ErrorCode(models.ErrorCodeUnauthorized)
+	CodeNotFound       = ErrorCode(models.ErrorCodeNotFound)
+)
+
+// APIError is returned when the server answers with an ErrorObject
+type APIError struct {
+	StatusCode int
+	Code       ErrorCode
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("usdk: %d (code %d): %s", e.StatusCode, e.Code, e.Message)
+}
+
+// ValidationError is returned when a request fails client-side validation.
+// The request is not sent to the server.
+type ValidationError struct {
+	Errors []error
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		msgs[i] = err.Error()
+	}
+	return "usdk: invalid request: " + strings.Join(msgs, ", ")
+}
+
+// IsCode reports whether err is an APIError with the given code
+func IsCode(err error, code ErrorCode) bool {
+	apiErr, ok := err.(*APIError)
+	return ok && apiErr.Code == code
+}
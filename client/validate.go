@@ -0,0 +1,56 @@
+package client
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/go-openapi/errors"
+	"universalsdk/kvp"
+	"universalsdk/models"
+)
+
+// Validate checks the collection against the swagger schema and the
+// activity data rules of the service, returning every error found.
+// kvpKeys must be unique within the default key scope of the kvp.
+func Validate(collection models.DeviceCheckDetailsObjectCollection) []error {
+	return ValidateScope(collection, kvp.DefaultKeyScope)
+}
+
+// ValidateScope is Validate with kvpKeys required to be unique within scope
+func ValidateScope(collection models.DeviceCheckDetailsObjectCollection, scope kvp.KeyScope) []error {
+	var errs []error
+
+	if len(collection) == 0 {
+		return append(errs, fmt.Errorf("invalid or missing input"))
+	}
+
+	errs = append(errs, collection.ValidateNotNull()...)
+
+	keys := kvp.NewKeyTracker(scope)
+	for i, elem := range collection {
+		if elem == nil {
+			continue
+		}
+
+		if err := elem.Validate(nil); err != nil {
+			if ve, ok := err.(*errors.Validation); ok {
+				err = ve.ValidateName(strconv.Itoa(i))
+			}
+			errs = append(errs, err)
+		}
+
+		for _, pair := range elem.ActivityData {
+			if pair == nil {
+				continue
+			}
+			if err := keys.Add(elem, pair.KvpKey); err != nil {
+				errs = append(errs, err)
+			}
+
+			if err := kvp.ValidateDataType(pair.KvpValue, pair.KvpType); err != nil {
+				errs = append(errs, fmt.Errorf("KvpKey %s %s", pair.KvpKey, err.Error()))
+			}
+		}
+	}
+	return errs
+}
@@ -0,0 +1,110 @@
+// Command usdk-load sends a mix of valid, invalid, duplicate session key and
+// retried device checks to a server at a fixed rate, then reports the
+// throughput, latency percentiles, outcomes by ErrorObject code and, for
+// soak runs, the memory of the server process.
+//
+//	usdk-load -url http://localhost:8080 -rate 200 -duration 1m
+//	usdk-load -url http://localhost:8080 -rate 50 -duration 12h -pid $(pgrep universalsdk) -sample 1m
+//	usdk-load -url https://usdk.example.com -rate 50 -duration 12h -admin-token $TOKEN -sample 1m
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+	"universalsdk/loadgen"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	os.Exit(run(ctx, os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run returns 1 when any request isn't answered as its kind should be,
+// so a run can gate a pipeline
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("usdk-load", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	url := fs.String("url", "", "base URL of the server")
+	route := fs.String("route", "/isgood", "device check route")
+	rate := fs.Float64("rate", 100, "requests per second, 0 for as fast as -concurrency allows")
+	duration := fs.Duration("duration", 0, "length of the run, until interrupted when 0")
+	concurrency := fs.Int("concurrency", 16, "largest number of requests in flight")
+	mix := fs.String("mix", "90:7:3", "weights of valid:invalid:duplicate[:retry] collections, retries need replay enabled")
+	maxChecks := fs.Int("max-checks", 3, "largest number of checks of a collection")
+	seed := fs.Int64("seed", 1, "seed of the generated traffic")
+	timeout := fs.Duration("timeout", 0, "timeout of a request, 10s when 0")
+	callerID := fs.String("caller", "usdk-load", "X-Caller-Id of the requests")
+	apiKey := fs.String("api-key", "", "X-Api-Key of the requests, when the server authenticates its callers")
+	pid := fs.Int("pid", 0, "id of a local server process to sample the memory of")
+	adminToken := fs.String("admin-token", "", "admin token to sample the memory of the server from its /admin/vars, without -pid")
+	sample := fs.Duration("sample", 0, "interval of the memory samples, 10s when 0")
+	jsonReport := fs.Bool("json", false, "print the report as JSON")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *url == "" || fs.NArg() > 0 {
+		fmt.Fprintln(stderr, "usage: usdk-load -url <server> [flags]")
+		return 2
+	}
+
+	cfg := loadgen.Config{
+		URL:         *url,
+		Route:       *route,
+		Rate:        *rate,
+		Duration:    *duration,
+		Concurrency: *concurrency,
+		MaxChecks:   *maxChecks,
+		Seed:        *seed,
+		Timeout:     *timeout,
+		CallerID:    *callerID,
+		APIKey:      *apiKey,
+		SampleEvery: *sample,
+	}
+	var err error
+	if cfg.Mix, err = loadgen.ParseMix(*mix); err != nil {
+		fmt.Fprintf(stderr, "usdk-load: %v\n", err)
+		return 2
+	}
+	switch {
+	case *pid > 0:
+		cfg.Memory = loadgen.ProcessRSS(*pid)
+		if _, err := cfg.Memory(); err != nil {
+			fmt.Fprintf(stderr, "usdk-load: can't sample the memory of process %d: %v\n", *pid, err)
+			return 2
+		}
+	case *adminToken != "":
+		cfg.Memory = loadgen.ExpvarMemory(&http.Client{Timeout: 10 * time.Second}, strings.TrimRight(*url, "/")+"/admin/vars", *adminToken)
+		if _, err := cfg.Memory(); err != nil {
+			fmt.Fprintf(stderr, "usdk-load: can't sample the memory of the server: %v\n", err)
+			return 2
+		}
+	}
+
+	report, err := loadgen.Run(ctx, cfg)
+	if err != nil {
+		fmt.Fprintf(stderr, "usdk-load: %v\n", err)
+		return 1
+	}
+
+	if *jsonReport {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(report)
+	} else {
+		report.Print(stdout)
+	}
+
+	if report.Requests == 0 || report.Unexpected() > 0 {
+		return 1
+	}
+	return 0
+}
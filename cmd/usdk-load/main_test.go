@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"universalsdk/controller"
+	"universalsdk/service"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type UsdkLoadSuite struct {
+	suite.Suite
+}
+
+func TestUsdkLoadSuite(t *testing.T) {
+	suite.Run(t, new(UsdkLoadSuite))
+}
+
+func (suite *UsdkLoadSuite) TestRun() {
+	var sessionKeyMap sync.Map
+	usdkController := controller.NewUsdkController(service.NewUsdkService(&sessionKeyMap))
+	server := httptest.NewServer(controller.NewRouter(controller.Routes{Usdk: usdkController}))
+	defer server.Close()
+
+	var stdout, stderr bytes.Buffer
+	code := run(context.Background(), []string{"-url", server.URL, "-rate", "200", "-duration", "300ms"}, &stdout, &stderr)
+	suite.Equal(0, code, stderr.String())
+	suite.Contains(stdout.String(), "unexpected")
+
+	stdout.Reset()
+	code = run(context.Background(), []string{"-url", server.URL, "-rate", "200", "-duration", "300ms", "-json"}, &stdout, &stderr)
+	suite.Equal(0, code, stderr.String())
+	suite.Contains(stdout.String(), `"latencyMs"`)
+
+	// Without replay a retry is rejected as a reused session key
+	code = run(context.Background(), []string{"-url", server.URL, "-rate", "200", "-duration", "300ms", "-mix", "50:0:0:50"}, &stdout, &stderr)
+	suite.Equal(1, code)
+
+	// The route doesn't exist, so nothing is answered as expected
+	code = run(context.Background(), []string{"-url", server.URL, "-route", "/isbad", "-duration", "200ms"}, &stdout, &stderr)
+	suite.Equal(1, code)
+}
+
+func (suite *UsdkLoadSuite) TestAdminToken() {
+	var sessionKeyMap sync.Map
+	server := httptest.NewServer(controller.NewRouter(controller.Routes{
+		Usdk:  controller.NewUsdkController(service.NewUsdkService(&sessionKeyMap)),
+		Admin: controller.NewAdminController(service.NewSessionKeyService(&sessionKeyMap), "s3cret"),
+	}))
+	defer server.Close()
+
+	var stdout, stderr bytes.Buffer
+	code := run(context.Background(), []string{"-url", server.URL, "-duration", "300ms", "-admin-token", "s3cret", "-sample", "100ms"}, &stdout, &stderr)
+	suite.Equal(0, code, stderr.String())
+	suite.Contains(stdout.String(), "memory   start")
+
+	code = run(context.Background(), []string{"-url", server.URL, "-duration", "300ms", "-admin-token", "wrong"}, &stdout, &stderr)
+	suite.Equal(2, code)
+	suite.Contains(stderr.String(), "can't sample the memory of the server")
+}
+
+func (suite *UsdkLoadSuite) TestUsage() {
+	var stdout, stderr bytes.Buffer
+	suite.Equal(2, run(context.Background(), nil, &stdout, &stderr))
+	suite.Contains(stderr.String(), "usage: usdk-load")
+
+	stderr.Reset()
+	suite.Equal(2, run(context.Background(), []string{"-url", "http://localhost", "-mix", "1:2"}, &stdout, &stderr))
+	suite.Contains(stderr.String(), "valid:invalid:duplicate")
+}
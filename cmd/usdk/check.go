@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"time"
+	"universalsdk/client"
+	"universalsdk/kvp"
+	"universalsdk/models"
+)
+
+// runCheck submits a payload to a server and prints the response or ErrorObject
+func runCheck(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("check", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	url := fs.String("url", "http://localhost:8080", "base URL of the server")
+	route := fs.String("route", "/isgood", "device check route")
+	caller := fs.String("caller", "usdk-cli", "caller identity sent as X-Caller-Id")
+	apiKey := fs.String("api-key", "", "API key sent as X-Api-Key, when the server authenticates its callers")
+	timeout := fs.Duration("timeout", 10*time.Second, "request timeout")
+	retries := fs.Int("retries", 0, "number of retries on transport or server errors")
+	keyScope := fs.String("key-scope", string(kvp.DefaultKeyScope), "scope of kvpKey uniqueness: element, collection or session")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	scope, err := kvp.ParseKeyScope(*keyScope)
+	if err != nil {
+		fmt.Fprintf(stderr, "usdk: %v\n", err)
+		return 2
+	}
+
+	collection, err := readPayload(fs.Arg(0), stdin)
+	if err != nil {
+		fmt.Fprintf(stderr, "usdk: %v\n", err)
+		return 1
+	}
+
+	c := client.New(*url, client.WithRoute(*route), client.WithCallerID(*caller), client.WithAPIKey(*apiKey), client.WithRetries(*retries, 200*time.Millisecond), client.WithKeyScope(scope))
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	resp, err := c.DeviceCheck(ctx, collection)
+	switch e := err.(type) {
+	case nil:
+		printJSON(stdout, resp)
+		return 0
+	case *client.APIError:
+		fmt.Fprintf(stdout, "HTTP %d\n", e.StatusCode)
+		printJSON(stdout, models.ErrorObject{Code: int64(e.Code), Message: e.Message})
+		return 1
+	case *client.ValidationError:
+		for _, verr := range e.Errors {
+			fmt.Fprintln(stdout, verr)
+		}
+		fmt.Fprintf(stdout, "%d error(s), payload not sent\n", len(e.Errors))
+		return 1
+	default:
+		fmt.Fprintf(stderr, "usdk: %v\n", err)
+		return 1
+	}
+}
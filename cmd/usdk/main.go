@@ -0,0 +1,89 @@
+// Command usdk validates and submits device check payloads and runs the server.
+//
+//	usdk validate payload.json
+//	usdk check -url http://localhost:8080 payload.json
+//	usdk serve -addr :8080 -route /isgood
+//	usdk rotate-key keys.json
+//	usdk signing-key
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"universalsdk/models"
+)
+
+const usage = `usage: usdk <command> [flags] [file]
+
+commands:
+  validate    check a JSON payload offline and print every error
+  check       submit a JSON payload to a server and print the response
+  serve       start the server
+  rotate-key  add a new current key to an encryption key file
+  signing-key print a new response signing key
+
+Use "-" or omit the file to read the payload from stdin.
+Run "usdk <command> -h" for the flags of a command.
+`
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+
+	switch args[0] {
+	case "validate":
+		return runValidate(args[1:], stdin, stdout, stderr)
+	case "check":
+		return runCheck(args[1:], stdin, stdout, stderr)
+	case "serve":
+		return runServe(args[1:], stderr)
+	case "rotate-key":
+		return runRotateKey(args[1:], stdout, stderr)
+	case "signing-key":
+		return runSigningKey(args[1:], stdout, stderr)
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(stdout, usage)
+		return 0
+	default:
+		fmt.Fprintf(stderr, "usdk: unknown command %q\n\n%s", args[0], usage)
+		return 2
+	}
+}
+
+// readPayload decodes the collection from the named file, or stdin for "" and "-"
+func readPayload(name string, stdin io.Reader) (models.DeviceCheckDetailsObjectCollection, error) {
+	var data []byte
+	var err error
+	if name == "" || name == "-" {
+		data, err = ioutil.ReadAll(stdin)
+	} else {
+		data, err = ioutil.ReadFile(name)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var collection models.DeviceCheckDetailsObjectCollection
+	if err := json.Unmarshal(data, &collection); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %v", err)
+	}
+	return collection, nil
+}
+
+func printJSON(w io.Writer, v interface{}) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		fmt.Fprintln(w, err)
+		return
+	}
+	fmt.Fprintln(w, string(data))
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"universalsdk/config"
+	"universalsdk/controller"
+	"universalsdk/envelope"
+	"universalsdk/service"
+	"universalsdk/signing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/suite"
+)
+
+const validPayload = `[{"checkType":"DEVICE","activityType":"SIGNUP","checkSessionKey":"cli-1",
+	"activityData":[{"kvpKey":"ip.address","kvpValue":"1.23.45.123","kvpType":"general.string"}]}]`
+
+const invalidPayload = `[{"checkType":"DUMMY","activityType":"SIGNUP",
+	"activityData":[{"kvpKey":"ip.address","kvpValue":"abc","kvpType":"general.integer"},
+	{"kvpKey":"ip.address","kvpValue":"maybe","kvpType":"general.bool"}]}]`
+
+type UsdkCliSuite struct {
+	suite.Suite
+}
+
+func TestUsdkCliSuite(t *testing.T) {
+	suite.Run(t, new(UsdkCliSuite))
+}
+
+func (suite *UsdkCliSuite) TestValidate() {
+	code, stdout, _ := runCli([]string{"validate"}, validPayload)
+	suite.Equal(0, code)
+	suite.Contains(stdout, "OK: 1 check(s) valid")
+
+	// Every error is printed: check type, duplicate key and both data types
+	code, stdout, _ = runCli([]string{"validate", "-"}, invalidPayload)
+	suite.Equal(1, code)
+	suite.Contains(stdout, "checkType")
+	suite.Contains(stdout, "KvpKey ip.address is not unique")
+	suite.Contains(stdout, "4 error(s)")
+
+	code, _, stderr := runCli([]string{"validate"}, "{")
+	suite.Equal(1, code)
+	suite.Contains(stderr, "invalid JSON")
+}
+
+func (suite *UsdkCliSuite) TestCheck() {
+	var sessionKeyMap sync.Map
+	usdkController := controller.NewUsdkController(service.NewUsdkService(&sessionKeyMap))
+	router := mux.NewRouter()
+	router.HandleFunc("/isgood", usdkController.DeviceCheck).Methods("POST")
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	code, stdout, _ := runCli([]string{"check", "-url", server.URL}, validPayload)
+	suite.Equal(0, code)
+	suite.Contains(stdout, `"puppy": true`)
+
+	// The session key is now used, the ErrorObject is printed
+	code, stdout, _ = runCli([]string{"check", "-url", server.URL}, validPayload)
+	suite.Equal(1, code)
+	suite.Contains(stdout, "HTTP 400")
+	suite.Contains(stdout, `"code": 3`)
+
+	code, stdout, _ = runCli([]string{"check", "-url", server.URL}, invalidPayload)
+	suite.Equal(1, code)
+	suite.Contains(stdout, "payload not sent")
+}
+
+func (suite *UsdkCliSuite) TestRotateKey() {
+	keyFile := filepath.Join(suite.T().TempDir(), "keys.json")
+
+	code, stdout, _ := runCli([]string{"rotate-key", keyFile}, "")
+	suite.Equal(0, code)
+	suite.Contains(stdout, "current key is now")
+
+	provider, err := envelope.NewLocalKeyProvider(keyFile)
+	suite.Require().NoError(err)
+	first, _ := provider.CurrentKey()
+
+	code, _, _ = runCli([]string{"rotate-key", keyFile}, "")
+	suite.Equal(0, code)
+	suite.Require().NoError(provider.Reload())
+	current, _ := provider.CurrentKey()
+	keys, _ := provider.Keys()
+	suite.NotEqual(first.ID, current.ID)
+	suite.Len(keys, 2)
+
+	code, _, stderr := runCli([]string{"rotate-key"}, "")
+	suite.Equal(2, code)
+	suite.Contains(stderr, "usage: usdk rotate-key")
+}
+
+func (suite *UsdkCliSuite) TestSigningKey() {
+	code, stdout, _ := runCli([]string{"signing-key", "-id", "s1"}, "")
+	suite.Require().Equal(0, code)
+	suite.Contains(stdout, "- id: s1\n")
+
+	// The printed key is an entry of signing.keys
+	configFile := filepath.Join(suite.T().TempDir(), "config.yaml")
+	entry := strings.ReplaceAll(strings.TrimSpace(stdout), "\n", "\n    ")
+	suite.Require().NoError(os.WriteFile(configFile, []byte("signing:\n  current: s1\n  keys:\n    "+entry+"\n"), 0600))
+	cfg, err := config.Load(configFile)
+	suite.Require().NoError(err)
+	signer, err := signing.NewSignerFromConfig(cfg.Signing)
+	suite.Require().NoError(err)
+	suite.Equal("s1", signer.KeyID())
+
+	code, _, stderr := runCli([]string{"signing-key", "extra"}, "")
+	suite.Equal(2, code)
+	suite.Contains(stderr, "usage: usdk signing-key")
+}
+
+func (suite *UsdkCliSuite) TestUsage() {
+	code, _, stderr := runCli(nil, "")
+	suite.Equal(2, code)
+	suite.Contains(stderr, "usage: usdk")
+
+	code, _, stderr = runCli([]string{"unknown"}, "")
+	suite.Equal(2, code)
+	suite.Contains(stderr, "unknown command")
+}
+
+func runCli(args []string, stdin string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := run(args, strings.NewReader(stdin), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"universalsdk/envelope"
+)
+
+// runRotateKey adds a new current key to a local encryption key file,
+// creating the file when it does not exist yet
+func runRotateKey(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("rotate-key", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fmt.Fprintln(stderr, "usage: usdk rotate-key <keyfile>")
+		return 2
+	}
+
+	id, err := envelope.RotateKeyFile(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(stderr, "usdk: %v\n", err)
+		return 1
+	}
+
+	fmt.Fprintf(stdout, "current key is now %s, restart the servers to use it\n", id)
+	return 0
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"universalsdk/config"
+	"universalsdk/server"
+)
+
+// runServe starts the server, flags override the config file
+func runServe(args []string, stderr io.Writer) int {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	configPath := fs.String("config", "", "path to the config file")
+	addr := fs.String("addr", "", "REST listen address, e.g. :8080")
+	route := fs.String("route", "", "device check route, e.g. /isgood")
+	grpcAddr := fs.String("grpc-addr", "", "gRPC listen address, e.g. :9090")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "usdk: %v\n", err)
+		return 1
+	}
+	if *addr != "" {
+		cfg.Server.Addr = *addr
+	}
+	if *route != "" {
+		cfg.Server.Route = *route
+	}
+	if *grpcAddr != "" {
+		cfg.Grpc.Addr = *grpcAddr
+	}
+
+	if err := server.Run(cfg); err != nil {
+		fmt.Fprintf(stderr, "usdk: %v\n", err)
+		return 1
+	}
+	return 0
+}
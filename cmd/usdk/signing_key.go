@@ -0,0 +1,38 @@
+package main
+
+import (
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"io"
+	"time"
+	"universalsdk/signing"
+)
+
+// runSigningKey prints a new response signing key as an entry of signing.keys
+func runSigningKey(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("signing-key", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	id := fs.String("id", "", "key id, derived from the current time when empty")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 0 {
+		fmt.Fprintln(stderr, "usage: usdk signing-key [-id <id>]")
+		return 2
+	}
+	if *id == "" {
+		*id = "s" + time.Now().UTC().Format("20060102T150405Z")
+	}
+
+	key, err := signing.GenerateKey(*id)
+	if err != nil {
+		fmt.Fprintf(stderr, "usdk: %v\n", err)
+		return 1
+	}
+
+	fmt.Fprintf(stdout, "- id: %s\n  privateKey: %s\n  publicKey: %s\n", key.ID,
+		base64.StdEncoding.EncodeToString(key.PrivateKey.Seed()),
+		base64.StdEncoding.EncodeToString(key.PublicKey))
+	return 0
+}
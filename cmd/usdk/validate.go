@@ -0,0 +1,42 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"universalsdk/client"
+	"universalsdk/kvp"
+)
+
+// runValidate checks a payload with the same rules as the server, without calling it
+func runValidate(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("validate", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	keyScope := fs.String("key-scope", string(kvp.DefaultKeyScope), "scope of kvpKey uniqueness: element, collection or session")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	scope, err := kvp.ParseKeyScope(*keyScope)
+	if err != nil {
+		fmt.Fprintf(stderr, "usdk: %v\n", err)
+		return 2
+	}
+
+	collection, err := readPayload(fs.Arg(0), stdin)
+	if err != nil {
+		fmt.Fprintf(stderr, "usdk: %v\n", err)
+		return 1
+	}
+
+	errs := client.ValidateScope(collection, scope)
+	for _, err := range errs {
+		fmt.Fprintln(stdout, err)
+	}
+	if len(errs) > 0 {
+		fmt.Fprintf(stdout, "%d error(s)\n", len(errs))
+		return 1
+	}
+
+	fmt.Fprintf(stdout, "OK: %d check(s) valid\n", len(collection))
+	return 0
+}
@@ -0,0 +1,258 @@
+// Package codec decodes device check requests and encodes responses in the
+// media type negotiated with the Content-Type and Accept headers.
+package codec
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"sort"
+	"strconv"
+	"strings"
+	"universalsdk/models"
+	"universalsdk/proto/usdkpb"
+
+	"github.com/fxamacker/cbor/v2"
+	"google.golang.org/protobuf/proto"
+)
+
+// Media types of the built-in codecs
+const (
+	JSON     = "application/json"
+	CBOR     = "application/cbor"
+	Protobuf = "application/x-protobuf"
+	Form     = "application/x-www-form-urlencoded"
+)
+
+// DecodeFunc decodes a request body into the collection
+type DecodeFunc func(r io.Reader, collection *models.DeviceCheckDetailsObjectCollection) error
+
+// EncodeFunc encodes a response, a DeviceCheckResponseObject or an ErrorObject
+type EncodeFunc func(w io.Writer, v interface{}) error
+
+// UnsupportedError is returned for a media type without codec
+type UnsupportedError struct {
+	Header    string
+	MediaType string
+	Supported []string
+}
+
+func (e *UnsupportedError) Error() string {
+	if e.MediaType == "" {
+		return fmt.Sprintf("%s is required, use one of %s", e.Header, strings.Join(e.Supported, ", "))
+	}
+	return fmt.Sprintf("%s %s is not supported, use one of %s", e.Header, e.MediaType, strings.Join(e.Supported, ", "))
+}
+
+type decoder struct {
+	mediaType string
+	decode    DecodeFunc
+}
+
+type encoder struct {
+	mediaType string
+	encode    EncodeFunc
+}
+
+// Registry holds the decoders and encoders by media type.
+// The first one registered is the default.
+type Registry struct {
+	decoders []decoder
+	encoders []encoder
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Default returns a registry decoding JSON, CBOR, protobuf and forms,
+// and encoding JSON, CBOR and protobuf
+func Default() *Registry {
+	reg := NewRegistry()
+	reg.RegisterDecoder(JSON, DecodeJSON)
+	reg.RegisterDecoder(CBOR, DecodeCBOR)
+	reg.RegisterDecoder(Protobuf, DecodeProtobuf)
+	reg.RegisterDecoder(Form, DecodeForm)
+	reg.RegisterEncoder(JSON, EncodeJSON)
+	reg.RegisterEncoder(CBOR, EncodeCBOR)
+	reg.RegisterEncoder(Protobuf, EncodeProtobuf)
+	return reg
+}
+
+// RegisterDecoder adds or replaces the decoder of a media type
+func (reg *Registry) RegisterDecoder(mediaType string, decode DecodeFunc) {
+	for i := range reg.decoders {
+		if reg.decoders[i].mediaType == mediaType {
+			reg.decoders[i].decode = decode
+			return
+		}
+	}
+	reg.decoders = append(reg.decoders, decoder{mediaType: mediaType, decode: decode})
+}
+
+// RegisterEncoder adds or replaces the encoder of a media type
+func (reg *Registry) RegisterEncoder(mediaType string, encode EncodeFunc) {
+	for i := range reg.encoders {
+		if reg.encoders[i].mediaType == mediaType {
+			reg.encoders[i].encode = encode
+			return
+		}
+	}
+	reg.encoders = append(reg.encoders, encoder{mediaType: mediaType, encode: encode})
+}
+
+// DecodeTypes returns the media types that can be decoded, default first
+func (reg *Registry) DecodeTypes() []string {
+	types := make([]string, len(reg.decoders))
+	for i, d := range reg.decoders {
+		types[i] = d.mediaType
+	}
+	return types
+}
+
+// EncodeTypes returns the media types that can be encoded, default first
+func (reg *Registry) EncodeTypes() []string {
+	types := make([]string, len(reg.encoders))
+	for i, e := range reg.encoders {
+		types[i] = e.mediaType
+	}
+	return types
+}
+
+// Decoder returns the decoder of a Content-Type header. A request without
+// header is rejected rather than decoded with the default decoder.
+func (reg *Registry) Decoder(contentType string) (DecodeFunc, error) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err == nil {
+		for _, d := range reg.decoders {
+			if d.mediaType == mediaType {
+				return d.decode, nil
+			}
+		}
+	}
+	return nil, &UnsupportedError{Header: "Content-Type", MediaType: contentType, Supported: reg.DecodeTypes()}
+}
+
+// Encoder returns the preferred encoder of an Accept header and its media type.
+// Without header the default encoder is used.
+func (reg *Registry) Encoder(accept string) (string, EncodeFunc, error) {
+	if len(reg.encoders) == 0 {
+		return "", nil, &UnsupportedError{Header: "Accept", MediaType: accept}
+	}
+	if strings.TrimSpace(accept) == "" {
+		return reg.encoders[0].mediaType, reg.encoders[0].encode, nil
+	}
+
+	for _, mediaRange := range parseAccept(accept) {
+		for _, e := range reg.encoders {
+			if matchRange(mediaRange, e.mediaType) {
+				return e.mediaType, e.encode, nil
+			}
+		}
+	}
+	return "", nil, &UnsupportedError{Header: "Accept", MediaType: accept, Supported: reg.EncodeTypes()}
+}
+
+// parseAccept returns the accepted media ranges, most preferred first
+func parseAccept(accept string) []string {
+	type weighted struct {
+		mediaRange string
+		q          float64
+	}
+	var ranges []weighted
+	for _, part := range strings.Split(accept, ",") {
+		mediaRange, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		if q > 0 {
+			ranges = append(ranges, weighted{mediaRange, q})
+		}
+	}
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].q > ranges[j].q })
+
+	mediaRanges := make([]string, len(ranges))
+	for i, r := range ranges {
+		mediaRanges[i] = r.mediaRange
+	}
+	return mediaRanges
+}
+
+func matchRange(mediaRange, mediaType string) bool {
+	if mediaRange == "*/*" || mediaRange == mediaType {
+		return true
+	}
+	if strings.HasSuffix(mediaRange, "/*") {
+		return strings.HasPrefix(mediaType, strings.TrimSuffix(mediaRange, "*"))
+	}
+	return false
+}
+
+func DecodeJSON(r io.Reader, collection *models.DeviceCheckDetailsObjectCollection) error {
+	return json.NewDecoder(r).Decode(collection)
+}
+
+func EncodeJSON(w io.Writer, v interface{}) error {
+	return json.NewEncoder(w).Encode(v)
+}
+
+// The swagger models are binary marshalers of their JSON, CBOR maps of
+// their fields are used instead
+var (
+	cborDecMode, _ = cbor.DecOptions{BinaryUnmarshaler: cbor.BinaryUnmarshalerNone}.DecMode()
+	cborEncMode, _ = cbor.EncOptions{BinaryMarshaler: cbor.BinaryMarshalerNone}.EncMode()
+)
+
+// DecodeCBOR decodes CBOR using the JSON field names of the models
+func DecodeCBOR(r io.Reader, collection *models.DeviceCheckDetailsObjectCollection) error {
+	return cborDecMode.NewDecoder(r).Decode(collection)
+}
+
+func EncodeCBOR(w io.Writer, v interface{}) error {
+	return cborEncMode.NewEncoder(w).Encode(v)
+}
+
+// DecodeProtobuf decodes a usdk.v1.DeviceCheckRequest message
+func DecodeProtobuf(r io.Reader, collection *models.DeviceCheckDetailsObjectCollection) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	req := &usdkpb.DeviceCheckRequest{}
+	if err := proto.Unmarshal(data, req); err != nil {
+		return err
+	}
+	*collection = req.ToCollection()
+	return nil
+}
+
+// EncodeProtobuf encodes a DeviceCheckResponseObject or ErrorObject as its usdk.v1 message
+func EncodeProtobuf(w io.Writer, v interface{}) error {
+	var msg proto.Message
+	switch v := v.(type) {
+	case *models.DeviceCheckResponseObject:
+		msg = usdkpb.FromPuppy(v)
+	case models.ErrorObject:
+		msg = usdkpb.FromErrorObject(v)
+	case *models.ErrorObject:
+		msg = usdkpb.FromErrorObject(*v)
+	case proto.Message:
+		msg = v
+	default:
+		return fmt.Errorf("%T has no protobuf message", v)
+	}
+
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
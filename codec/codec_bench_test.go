@@ -0,0 +1,47 @@
+package codec
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"universalsdk/models"
+	"universalsdk/testutil"
+)
+
+func BenchmarkDecode(b *testing.B) {
+	for _, mediaType := range []string{JSON, CBOR} {
+		decode, _ := Default().Decoder(mediaType)
+		_, encode, _ := Default().Encoder(mediaType)
+
+		for _, size := range testutil.BatchSizes {
+			var buf bytes.Buffer
+			if err := encode(&buf, testutil.Batch(size.Checks, size.KVPs)); err != nil {
+				b.Fatal(err)
+			}
+			body := buf.Bytes()
+
+			b.Run(mediaType+"/"+size.Name, func(b *testing.B) {
+				b.ReportAllocs()
+				b.SetBytes(int64(len(body)))
+				r := bytes.NewReader(body)
+				for i := 0; i < b.N; i++ {
+					r.Reset(body)
+					var collection models.DeviceCheckDetailsObjectCollection
+					if err := decode(r, &collection); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkEncodeJSON(b *testing.B) {
+	resp := &models.DeviceCheckResponseObject{PuppyObject: models.PuppyObject{Puppy: true}}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := EncodeJSON(io.Discard, resp); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package codec
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"universalsdk/models"
+)
+
+// FuzzDecodeJSON checks that any decoded collection encodes back to JSON
+// that decodes to the same collection
+func FuzzDecodeJSON(f *testing.F) {
+	f.Add([]byte(`[{"checkType":"DEVICE","activityType":"SIGNUP","checkSessionKey":"1","activityData":[{"kvpKey":"a","kvpValue":"1","kvpType":"general.integer"}]}]`))
+	f.Add([]byte(`[null,{}]`))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var collection models.DeviceCheckDetailsObjectCollection
+		if err := DecodeJSON(bytes.NewReader(data), &collection); err != nil {
+			return
+		}
+
+		var buf bytes.Buffer
+		if err := EncodeJSON(&buf, collection); err != nil {
+			t.Fatalf("encoding %q: %v", data, err)
+		}
+		var again models.DeviceCheckDetailsObjectCollection
+		if err := DecodeJSON(&buf, &again); err != nil {
+			t.Fatalf("decoding encoded %q: %v", buf.String(), err)
+		}
+		if !reflect.DeepEqual(collection, again) {
+			t.Fatalf("%q decodes to %#v, encoded again to %#v", data, collection, again)
+		}
+	})
+}
@@ -0,0 +1,94 @@
+package codec
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"universalsdk/models"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type CodecSuite struct {
+	suite.Suite
+}
+
+func TestCodecSuite(t *testing.T) {
+	suite.Run(t, new(CodecSuite))
+}
+
+func (suite *CodecSuite) TestRoundTrip() {
+	collection := mockCollection()
+	for _, mediaType := range []string{JSON, CBOR} {
+		decode, err := Default().Decoder(mediaType)
+		suite.Require().NoError(err)
+		_, encode, err := Default().Encoder(mediaType)
+		suite.Require().NoError(err)
+
+		var buf bytes.Buffer
+		suite.Require().NoError(encode(&buf, collection))
+		var decoded models.DeviceCheckDetailsObjectCollection
+		suite.NoError(decode(&buf, &decoded), mediaType)
+		suite.Equal(collection, decoded, mediaType)
+	}
+
+	var decoded models.DeviceCheckDetailsObjectCollection
+	form := EncodeForm(collection).Encode()
+	suite.NoError(DecodeForm(strings.NewReader(form), &decoded))
+	suite.Equal(collection, decoded)
+}
+
+func (suite *CodecSuite) TestFormErrors() {
+	tests := map[string]string{
+		"checks[1].checkType=DEVICE":                               "form field checks[0] missing",
+		"checks[0].activityData[1].kvpKey=ip.address":              "form field checks[0].activityData[0] missing",
+		"checks[0].checkType=DEVICE&checkType=DEVICE":              "form field checkType invalid",
+		"checks[0].checkType=DEVICE&checks[0].checkType=BIOMETRIC": "form field checks[0].checkType should have a single value",
+	}
+	for form, expected := range tests {
+		var decoded models.DeviceCheckDetailsObjectCollection
+		suite.EqualError(DecodeForm(strings.NewReader(form), &decoded), expected, form)
+	}
+}
+
+func (suite *CodecSuite) TestNegotiation() {
+	reg := Default()
+
+	_, err := reg.Decoder("")
+	suite.EqualError(err, "Content-Type is required, use one of "+
+		"application/json, application/cbor, application/x-protobuf, application/x-www-form-urlencoded")
+	_, err = reg.Decoder("application/json; charset=utf-8")
+	suite.NoError(err)
+	_, err = reg.Decoder("text/plain")
+	suite.EqualError(err, "Content-Type text/plain is not supported, use one of "+
+		"application/json, application/cbor, application/x-protobuf, application/x-www-form-urlencoded")
+
+	tests := map[string]string{
+		"":                                      JSON,
+		"*/*":                                   JSON,
+		"application/*;q=0.9, application/cbor": CBOR,
+		"application/json;q=0.1, application/x-protobuf;q=0.2": Protobuf,
+		"text/html, application/cbor;q=0.5":                    CBOR,
+	}
+	for accept, expected := range tests {
+		mediaType, _, err := reg.Encoder(accept)
+		suite.NoError(err, accept)
+		suite.Equal(expected, mediaType, accept)
+	}
+
+	// Forms are only decoded, q=0 excludes a type
+	_, _, err = reg.Encoder("application/x-www-form-urlencoded, application/json;q=0")
+	suite.Error(err)
+}
+
+func mockCollection() models.DeviceCheckDetailsObjectCollection {
+	return models.DeviceCheckDetailsObjectCollection{
+		{CheckType: "DEVICE", ActivityType: "SIGNUP", CheckSessionKey: "codec-1", ActivityData: []*models.KeyValuePairObject{
+			{KvpKey: "ip.address", KvpValue: "1.23.45.123", KvpType: models.EnumKVPTypeGeneralString},
+			{KvpKey: "login.attempts", KvpValue: "3", KvpType: models.EnumKVPTypeGeneralInteger},
+		}},
+		{CheckType: "BIOMETRIC", ActivityType: "LOGIN", CheckSessionKey: "codec-2", JourneyID: "j-1", ActivityData: []*models.KeyValuePairObject{
+			{KvpKey: "note", KvpValue: "a&b=c", KvpType: models.EnumKVPTypeGeneralString},
+		}},
+	}
+}
@@ -0,0 +1,136 @@
+package codec
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"strconv"
+	"universalsdk/models"
+)
+
+var (
+	formCheckField = regexp.MustCompile(`^checks\[(\d+)\]\.(checkType|activityType|checkSessionKey|journeyId)$`)
+	formKvpField   = regexp.MustCompile(`^checks\[(\d+)\]\.activityData\[(\d+)\]\.(kvpKey|kvpValue|kvpType)$`)
+)
+
+// DecodeForm decodes an urlencoded form with a field per value, indexed from 0:
+//
+//	checks[0].checkType=DEVICE&checks[0].activityData[0].kvpKey=ip.address&...
+func DecodeForm(r io.Reader, collection *models.DeviceCheckDetailsObjectCollection) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	values, err := url.ParseQuery(string(data))
+	if err != nil {
+		return err
+	}
+
+	checks := make(map[int]*models.DeviceCheckDetailsObject)
+	kvps := make(map[int]map[int]*models.KeyValuePairObject)
+	check := func(i int) *models.DeviceCheckDetailsObject {
+		if checks[i] == nil {
+			checks[i] = &models.DeviceCheckDetailsObject{}
+		}
+		return checks[i]
+	}
+
+	for field, vs := range values {
+		if len(vs) != 1 {
+			return fmt.Errorf("form field %s should have a single value", field)
+		}
+		value := vs[0]
+
+		if m := formCheckField.FindStringSubmatch(field); m != nil {
+			i, _ := strconv.Atoi(m[1])
+			elem := check(i)
+			switch m[2] {
+			case "checkType":
+				elem.CheckType = value
+			case "activityType":
+				elem.ActivityType = value
+			case "checkSessionKey":
+				elem.CheckSessionKey = value
+			case "journeyId":
+				elem.JourneyID = value
+			}
+			continue
+		}
+
+		m := formKvpField.FindStringSubmatch(field)
+		if m == nil {
+			return fmt.Errorf("form field %s invalid", field)
+		}
+		i, _ := strconv.Atoi(m[1])
+		j, _ := strconv.Atoi(m[2])
+		check(i)
+		if kvps[i] == nil {
+			kvps[i] = make(map[int]*models.KeyValuePairObject)
+		}
+		kvp := kvps[i][j]
+		if kvp == nil {
+			kvp = &models.KeyValuePairObject{}
+			kvps[i][j] = kvp
+		}
+		switch m[3] {
+		case "kvpKey":
+			kvp.KvpKey = value
+		case "kvpValue":
+			kvp.KvpValue = value
+		case "kvpType":
+			kvp.KvpType = models.EnumKVPType(value)
+		}
+	}
+
+	// Indexes must run from 0 without gaps, so a form can't size the collection
+	result := make(models.DeviceCheckDetailsObjectCollection, len(checks))
+	for i := range result {
+		elem, ok := checks[i]
+		if !ok {
+			return fmt.Errorf("form field checks[%d] missing", i)
+		}
+		elem.ActivityData = make([]*models.KeyValuePairObject, len(kvps[i]))
+		for j := range elem.ActivityData {
+			kvp, ok := kvps[i][j]
+			if !ok {
+				return fmt.Errorf("form field checks[%d].activityData[%d] missing", i, j)
+			}
+			elem.ActivityData[j] = kvp
+		}
+		result[i] = elem
+	}
+	*collection = result
+	return nil
+}
+
+// EncodeForm returns the form fields of a collection, as read by DecodeForm
+func EncodeForm(collection models.DeviceCheckDetailsObjectCollection) url.Values {
+	values := url.Values{}
+	for i, elem := range collection {
+		if elem == nil {
+			continue
+		}
+		prefix := fmt.Sprintf("checks[%d].", i)
+		setIf(values, prefix+"checkType", elem.CheckType)
+		setIf(values, prefix+"activityType", elem.ActivityType)
+		setIf(values, prefix+"checkSessionKey", elem.CheckSessionKey)
+		setIf(values, prefix+"journeyId", elem.JourneyID)
+		for j, kvp := range elem.ActivityData {
+			if kvp == nil {
+				continue
+			}
+			kvpPrefix := fmt.Sprintf("%sactivityData[%d].", prefix, j)
+			values.Set(kvpPrefix+"kvpKey", kvp.KvpKey)
+			values.Set(kvpPrefix+"kvpValue", kvp.KvpValue)
+			values.Set(kvpPrefix+"kvpType", string(kvp.KvpType))
+		}
+	}
+	return values
+}
+
+func setIf(values url.Values, field, value string) {
+	if value != "" {
+		values.Set(field, value)
+	}
+}
@@ -0,0 +1,161 @@
+// Package compress decodes gzip and deflate request bodies, bounding their
+// decompressed size, and compresses responses for clients accepting it.
+package compress
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Content codings
+const (
+	Gzip     = "gzip"
+	Deflate  = "deflate"
+	Identity = "identity"
+)
+
+// ErrTooLarge is returned when a compressed body expands beyond the limit
+var ErrTooLarge = errors.New("decompressed body too large")
+
+// UnsupportedError is returned for a Content-Encoding that can't be decoded
+type UnsupportedError struct {
+	Encoding string
+}
+
+func (e *UnsupportedError) Error() string {
+	return fmt.Sprintf("Content-Encoding %s is not supported, use one of gzip, deflate, identity", e.Encoding)
+}
+
+// Config of the request and response compression
+type Config struct {
+	// Compress responses of at least MinSize bytes
+	Enabled bool
+	MinSize int
+
+	// Largest decompressed request body, guarding against decompression bombs.
+	// 0 uses the limit of DefaultConfig.
+	MaxDecompressedBytes int64
+}
+
+// DefaultConfig compresses responses of 1KiB and more, and decompresses
+// request bodies up to 10MiB
+var DefaultConfig = Config{Enabled: true, MinSize: 1024, MaxDecompressedBytes: 10 << 20}
+
+// RequestBody returns the request body decoded according to its Content-Encoding
+func RequestBody(r *http.Request, maxBytes int64) (io.Reader, error) {
+	if maxBytes <= 0 {
+		maxBytes = DefaultConfig.MaxDecompressedBytes
+	}
+	encoding := strings.ToLower(strings.TrimSpace(r.Header.Get("Content-Encoding")))
+	switch encoding {
+	case "", Identity:
+		return r.Body, nil
+	case Gzip, "x-gzip":
+		zr, err := gzip.NewReader(r.Body)
+		if err != nil {
+			return nil, fmt.Errorf("invalid gzip body: %v", err)
+		}
+		return &limitedReader{r: zr, n: maxBytes}, nil
+	case Deflate:
+		zr, err := zlib.NewReader(r.Body)
+		if err != nil {
+			return nil, fmt.Errorf("invalid deflate body: %v", err)
+		}
+		return &limitedReader{r: zr, n: maxBytes}, nil
+	default:
+		return nil, &UnsupportedError{Encoding: encoding}
+	}
+}
+
+// limitedReader fails with ErrTooLarge, rather than io.EOF, past n bytes
+// so a truncated body is never mistaken for a complete one
+type limitedReader struct {
+	r io.Reader
+	n int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.n <= 0 {
+		// a body of exactly the limit is fine
+		var b [1]byte
+		if n, _ := l.r.Read(b[:]); n > 0 {
+			return 0, ErrTooLarge
+		}
+		return 0, io.EOF
+	}
+	if int64(len(p)) > l.n {
+		p = p[:l.n]
+	}
+	n, err := l.r.Read(p)
+	l.n -= int64(n)
+	return n, err
+}
+
+// Negotiate returns the preferred coding of an Accept-Encoding header,
+// or Identity when neither gzip nor deflate is accepted
+func Negotiate(acceptEncoding string) string {
+	best, bestQ := Identity, 0.0
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		coding, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		if coding == "*" {
+			coding = Gzip
+		}
+		if (coding == Gzip || coding == Deflate) && q > bestQ {
+			best, bestQ = coding, q
+		}
+	}
+	return best
+}
+
+// Write writes the response body, compressed when the request accepts it
+// and the body is at least cfg.MinSize bytes
+func Write(w http.ResponseWriter, r *http.Request, cfg Config, status int, body []byte) error {
+	w.Header().Add("Vary", "Accept-Encoding")
+
+	encoding := Identity
+	if cfg.Enabled && len(body) >= cfg.MinSize {
+		encoding = Negotiate(r.Header.Get("Accept-Encoding"))
+	}
+	if encoding == Identity {
+		w.WriteHeader(status)
+		_, err := w.Write(body)
+		return err
+	}
+
+	var buf bytes.Buffer
+	var zw io.WriteCloser
+	if encoding == Gzip {
+		zw = gzip.NewWriter(&buf)
+	} else {
+		zw = zlib.NewWriter(&buf)
+	}
+	if _, err := zw.Write(body); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Encoding", encoding)
+	w.Header().Del("Content-Length")
+	w.WriteHeader(status)
+	_, err := w.Write(buf.Bytes())
+	return err
+}
@@ -0,0 +1,121 @@
+package compress
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type CompressSuite struct {
+	suite.Suite
+}
+
+func TestCompressSuite(t *testing.T) {
+	suite.Run(t, new(CompressSuite))
+}
+
+func (suite *CompressSuite) TestRequestBody() {
+	payload := strings.Repeat("a", 100)
+	tests := map[string][]byte{
+		"":        []byte(payload),
+		"gzip":    gzipped([]byte(payload)),
+		"deflate": deflated([]byte(payload)),
+	}
+	for encoding, body := range tests {
+		req := httptest.NewRequest("POST", "/isgood", bytes.NewReader(body))
+		req.Header.Set("Content-Encoding", encoding)
+
+		r, err := RequestBody(req, 100)
+		suite.Require().NoError(err, encoding)
+		decoded, err := io.ReadAll(r)
+		suite.NoError(err, encoding)
+		suite.Equal(payload, string(decoded), encoding)
+	}
+
+	req := httptest.NewRequest("POST", "/isgood", strings.NewReader(payload))
+	req.Header.Set("Content-Encoding", "br")
+	_, err := RequestBody(req, 100)
+	suite.IsType(&UnsupportedError{}, err)
+
+	req = httptest.NewRequest("POST", "/isgood", strings.NewReader(payload))
+	req.Header.Set("Content-Encoding", "gzip")
+	_, err = RequestBody(req, 100)
+	suite.Error(err)
+}
+
+func (suite *CompressSuite) TestDecompressionBomb() {
+	bomb := gzipped(make([]byte, 10<<20))
+	req := httptest.NewRequest("POST", "/isgood", bytes.NewReader(bomb))
+	req.Header.Set("Content-Encoding", "gzip")
+
+	r, err := RequestBody(req, 1<<20)
+	suite.Require().NoError(err)
+	n, err := io.Copy(io.Discard, r)
+	suite.Equal(ErrTooLarge, err)
+	suite.Equal(int64(1<<20), n)
+}
+
+func (suite *CompressSuite) TestNegotiate() {
+	tests := map[string]string{
+		"":                        Identity,
+		"br":                      Identity,
+		"gzip":                    Gzip,
+		"deflate, gzip;q=0.5":     Deflate,
+		"gzip;q=0, deflate;q=0.1": Deflate,
+		"*":                       Gzip,
+		"gzip;q=0, deflate;q=0":   Identity,
+	}
+	for accept, expected := range tests {
+		suite.Equal(expected, Negotiate(accept), accept)
+	}
+}
+
+func (suite *CompressSuite) TestWrite() {
+	cfg := Config{Enabled: true, MinSize: 10}
+	body := []byte(strings.Repeat("b", 100))
+
+	req := httptest.NewRequest("POST", "/isgood", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	suite.NoError(Write(w, req, cfg, http.StatusOK, body))
+	suite.Equal(Gzip, w.Header().Get("Content-Encoding"))
+	zr, err := gzip.NewReader(w.Body)
+	suite.Require().NoError(err)
+	decoded, _ := io.ReadAll(zr)
+	suite.Equal(body, decoded)
+
+	// Below the threshold or not accepted the body is written as is
+	w = httptest.NewRecorder()
+	suite.NoError(Write(w, req, cfg, http.StatusOK, body[:5]))
+	suite.Empty(w.Header().Get("Content-Encoding"))
+	suite.Equal(body[:5], w.Body.Bytes())
+
+	req.Header.Del("Accept-Encoding")
+	w = httptest.NewRecorder()
+	suite.NoError(Write(w, req, cfg, http.StatusOK, body))
+	suite.Empty(w.Header().Get("Content-Encoding"))
+	suite.Equal(body, w.Body.Bytes())
+}
+
+func gzipped(data []byte) []byte {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write(data)
+	zw.Close()
+	return buf.Bytes()
+}
+
+func deflated(data []byte) []byte {
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	zw.Write(data)
+	zw.Close()
+	return buf.Bytes()
+}
@@ -0,0 +1,347 @@
+package config
+
+import (
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// Config holds the runtime configuration of the universalsdk server.
+// Values are read from an optional config file and can be overridden
+// with environment variables prefixed with USDK_ (e.g. USDK_SERVER_ADDR).
+type Config struct {
+	Server     ServerConfig     `mapstructure:"server"`
+	Grpc       GrpcConfig       `mapstructure:"grpc"`
+	Session    SessionConfig    `mapstructure:"session"`
+	Check      CheckConfig      `mapstructure:"check"`
+	Audit      AuditConfig      `mapstructure:"audit"`
+	Admin      AdminConfig      `mapstructure:"admin"`
+	Journey    JourneyConfig    `mapstructure:"journey"`
+	Velocity   VelocityConfig   `mapstructure:"velocity"`
+	Lists      ListsConfig      `mapstructure:"lists"`
+	Encryption EncryptionConfig `mapstructure:"encryption"`
+	Signing    SigningConfig    `mapstructure:"signing"`
+}
+
+// ServerConfig holds the REST listener settings
+type ServerConfig struct {
+	Addr        string            `mapstructure:"addr"`
+	Route       string            `mapstructure:"route"`
+	Compression CompressionConfig `mapstructure:"compression"`
+	Middleware  MiddlewareConfig  `mapstructure:"middleware"`
+}
+
+// MiddlewareConfig holds the middlewares wrapping every REST request
+type MiddlewareConfig struct {
+	// Names of the middlewares, outermost first: requestId, recover,
+	// accessLog, timeout and bodyLimit. Unlisted ones are left out.
+	Order []string `mapstructure:"order"`
+
+	// Header carrying the request ID, X-Request-Id by default
+	RequestIDHeader string `mapstructure:"requestIdHeader"`
+
+	// How long a request may take, 0 disables the timeout
+	Timeout time.Duration `mapstructure:"timeout"`
+
+	// Largest request body as sent, 0 disables the limit
+	MaxBodyBytes int64 `mapstructure:"maxBodyBytes"`
+}
+
+// CompressionConfig holds the gzip/deflate settings of request and response bodies
+type CompressionConfig struct {
+	// Compress responses of at least MinSize bytes when the client accepts it
+	Enabled bool `mapstructure:"enabled"`
+	MinSize int  `mapstructure:"minSize"`
+
+	// Largest decompressed request body, guarding against decompression bombs
+	MaxDecompressedBytes int64 `mapstructure:"maxDecompressedBytes"`
+}
+
+// GrpcConfig holds the gRPC listener settings
+type GrpcConfig struct {
+	// Listen address of the gRPC DeviceCheckService, disabled when empty
+	Addr string `mapstructure:"addr"`
+}
+
+// SessionConfig holds the settings of the checkSessionKey store
+type SessionConfig struct {
+	// How long an identical retry gets the original response back instead
+	// of a duplicate session key error, 0 disables replay
+	ReplayTTL time.Duration `mapstructure:"replayTTL"`
+}
+
+// CheckConfig holds the settings of the device check itself
+type CheckConfig struct {
+	// Return the normalised activity data of every check in the response
+	EchoNormalised bool `mapstructure:"echoNormalised"`
+
+	// Part of a request within which kvpKeys must be unique: "element"
+	// (each check), "collection" (the whole request) or "session" (the
+	// checks sharing a checkSessionKey)
+	KeyScope string `mapstructure:"keyScope"`
+
+	Semantic SemanticConfig `mapstructure:"semantic"`
+
+	// Required and forbidden kvpKeys per activityType
+	Schemas []SchemaConfig `mapstructure:"schemas"`
+
+	// Registered vendor specific activityTypes, any activityType starting
+	// with an underscore is accepted when empty
+	VendorActivityTypes []VendorActivityTypesConfig `mapstructure:"vendorActivityTypes"`
+
+	// Callers authenticated by their API key. When set every device check
+	// must carry one, and only these callers can be given list entries.
+	Callers []CallerConfig `mapstructure:"callers"`
+}
+
+// CallerConfig is a caller of the device checks and its API key
+type CallerConfig struct {
+	ID     string `mapstructure:"id"`
+	APIKey string `mapstructure:"apiKey"`
+}
+
+// VendorActivityTypesConfig lists the activityTypes of a vendor for a checkType, or every checkType when empty
+type VendorActivityTypesConfig struct {
+	Vendor        string   `mapstructure:"vendor"`
+	CheckType     string   `mapstructure:"checkType"`
+	ActivityTypes []string `mapstructure:"activityTypes"`
+}
+
+// SchemaConfig declares the kvpKeys of an activityType, optionally limited to one checkType
+type SchemaConfig struct {
+	ActivityType string              `mapstructure:"activityType"`
+	CheckType    string              `mapstructure:"checkType"`
+	Required     []RequiredKeyConfig `mapstructure:"required"`
+	Forbidden    []string            `mapstructure:"forbidden"`
+}
+
+// RequiredKeyConfig is a kvpKey an activity must contain and the kvpType of its value
+type RequiredKeyConfig struct {
+	Key  string `mapstructure:"key"`
+	Type string `mapstructure:"type"`
+}
+
+// SemanticConfig holds the settings of the semantic validation of well-known kvpKeys
+type SemanticConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+
+	// Keys added to or overriding the built-in catalogue
+	Keys []SemanticKeyConfig `mapstructure:"keys"`
+}
+
+// SemanticKeyConfig assigns a semantic validator (e.g. "ipv4", "country")
+// to a kvpKey, an empty validator removes the kvpKey from the catalogue
+type SemanticKeyConfig struct {
+	Key       string `mapstructure:"key"`
+	Validator string `mapstructure:"validator"`
+}
+
+// JourneyConfig holds the settings of the journeys grouping checks
+type JourneyConfig struct {
+	// Replace the default transitions of the listed activityTypes
+	Transitions []TransitionConfig `mapstructure:"transitions"`
+
+	// How long a journey is kept after its last check, 0 keeps journeys forever
+	TTL time.Duration `mapstructure:"ttl"`
+}
+
+// TransitionConfig lists the activityTypes the previous check of a journey
+// may have for a check of ActivityType, "START" allowing it to start a journey
+type TransitionConfig struct {
+	ActivityType string   `mapstructure:"activityType"`
+	After        []string `mapstructure:"after"`
+}
+
+// VelocityConfig holds the velocity rules and where their counters are kept
+type VelocityConfig struct {
+	// Counter store, "memory" or "redis" to share the counters between servers
+	Backend string      `mapstructure:"backend"`
+	Redis   RedisConfig `mapstructure:"redis"`
+
+	// No velocity checks are made when empty
+	Rules []VelocityRuleConfig `mapstructure:"rules"`
+}
+
+// RedisConfig holds the connection settings of the shared velocity store
+type RedisConfig struct {
+	Addr     string `mapstructure:"addr"`
+	Password string `mapstructure:"password"`
+	DB       int    `mapstructure:"db"`
+
+	// Prefix of every counter key
+	Prefix string `mapstructure:"prefix"`
+}
+
+// VelocityRuleConfig counts the checks of an activityType, or every
+// activityType when empty, sharing the values of Keys within Window, and
+// rejects or flags them once the count exceeds Limit
+type VelocityRuleConfig struct {
+	Name         string        `mapstructure:"name"`
+	ActivityType string        `mapstructure:"activityType"`
+	Keys         []string      `mapstructure:"keys"`
+	Window       time.Duration `mapstructure:"window"`
+	Limit        int64         `mapstructure:"limit"`
+
+	// "reject" or "flag"
+	Action string `mapstructure:"action"`
+
+	// What happens to a check when the store fails: "open" passes it,
+	// the default, and "closed" fails it
+	OnError string `mapstructure:"onError"`
+}
+
+// ListsConfig holds the settings of the allow and deny lists
+type ListsConfig struct {
+	// kvpKeys list entries can match
+	Keys []string `mapstructure:"keys"`
+
+	// File of list entries, as exported by the admin API, imported at start
+	File string `mapstructure:"file"`
+}
+
+// EncryptionConfig holds the keys used to encrypt or tokenise sensitive
+// activity data before it is written out of process memory
+type EncryptionConfig struct {
+	// Key provider, only "local" is built in
+	Provider string `mapstructure:"provider"`
+
+	// JSON key file of the local key provider, encryption is disabled when empty
+	KeyFile string `mapstructure:"keyFile"`
+}
+
+// SigningConfig holds the keys device check responses are signed with
+type SigningConfig struct {
+	// Id of the key responses are signed with, signing is disabled when empty
+	Current string `mapstructure:"current"`
+
+	// Keys published at /.well-known/jwks.json, the current one among them
+	Keys []SigningKeyConfig `mapstructure:"keys"`
+}
+
+// SigningKeyConfig is an Ed25519 key, as printed by usdk signing-key.
+// A key without a private key is only published, e.g. the previous key
+// until the responses it signed expire.
+type SigningKeyConfig struct {
+	ID string `mapstructure:"id"`
+
+	// Base64 encoded 32 byte seed of the private key
+	PrivateKey string `mapstructure:"privateKey"`
+
+	// Base64 encoded public key, derived from the private key when empty
+	PublicKey string `mapstructure:"publicKey"`
+}
+
+// AdminConfig holds the settings of the operator admin routes
+type AdminConfig struct {
+	// Bearer token required by the admin routes, which are disabled when empty
+	Token string `mapstructure:"token"`
+}
+
+// AuditConfig holds the settings of the device check audit trail
+type AuditConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+
+	// Directory the rotating JSONL audit files are written to
+	Dir string `mapstructure:"dir"`
+
+	// Size in bytes after which the active audit file is rotated
+	MaxBytes int64 `mapstructure:"maxBytes"`
+
+	// Number of rotated audit files to keep, 0 keeps all of them
+	MaxFiles int `mapstructure:"maxFiles"`
+
+	// Secret used to key the hash of KVP values, plain SHA-256 when empty
+	HashKey string `mapstructure:"hashKey"`
+
+	// Secret used to key the hash chain of the records, required
+	ChainKey string `mapstructure:"chainKey"`
+
+	// How KVP values are stored per kvpType family (the part before the
+	// first dot, e.g. "pii" for pii.name): "hash", "redact", "plain",
+	// "encrypt" or "token", the last two need encryption.keyFile
+	ValuePolicy map[string]string `mapstructure:"valuePolicy"`
+}
+
+// Load reads the configuration from path, if given, on top of the defaults
+// and environment variables.
+func Load(path string) (*Config, error) {
+	v := viper.New()
+	setDefaults(v)
+
+	v.SetEnvPrefix("USDK")
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AutomaticEnv()
+
+	if path != "" {
+		v.SetConfigFile(path)
+		if err := v.ReadInConfig(); err != nil {
+			return nil, err
+		}
+	}
+
+	cfg := &Config{}
+	if err := v.Unmarshal(cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Default returns the configuration used when no config file is supplied
+func Default() *Config {
+	cfg, err := Load("")
+	if err != nil {
+		panic(err)
+	}
+	return cfg
+}
+
+func setDefaults(v *viper.Viper) {
+	v.SetDefault("server.addr", ":8080")
+	v.SetDefault("server.route", "/isgood")
+	v.SetDefault("server.middleware.order", []string{"requestId", "accessLog", "recover", "timeout", "bodyLimit"})
+	v.SetDefault("server.middleware.requestIdHeader", "X-Request-Id")
+	v.SetDefault("server.middleware.timeout", "30s")
+	v.SetDefault("server.middleware.maxBodyBytes", 4<<20)
+	v.SetDefault("server.compression.enabled", true)
+	v.SetDefault("server.compression.minSize", 1024)
+	v.SetDefault("server.compression.maxDecompressedBytes", 10<<20)
+
+	v.SetDefault("grpc.addr", "")
+
+	v.SetDefault("session.replayTTL", 0)
+
+	v.SetDefault("check.echoNormalised", false)
+	v.SetDefault("check.keyScope", "element")
+	v.SetDefault("check.semantic.enabled", false)
+
+	v.SetDefault("journey.ttl", "24h")
+
+	v.SetDefault("admin.token", "")
+
+	v.SetDefault("encryption.provider", "local")
+	v.SetDefault("encryption.keyFile", "")
+
+	v.SetDefault("signing.current", "")
+
+	v.SetDefault("lists.keys", []string{"ip.address", "mac.address", "device.fingerprint", "account.id", "email.address"})
+	v.SetDefault("lists.file", "")
+
+	v.SetDefault("velocity.backend", "memory")
+	v.SetDefault("velocity.redis.addr", "localhost:6379")
+	v.SetDefault("velocity.redis.db", 0)
+	v.SetDefault("velocity.redis.prefix", "usdk:velocity:")
+
+	v.SetDefault("audit.enabled", false)
+	v.SetDefault("audit.dir", "audit")
+	v.SetDefault("audit.maxBytes", 10*1024*1024)
+	v.SetDefault("audit.maxFiles", 0)
+	v.SetDefault("audit.hashKey", "")
+	v.SetDefault("audit.chainKey", "")
+	v.SetDefault("audit.valuePolicy", map[string]string{
+		"general": "hash",
+		"id":      "redact",
+		"pii":     "redact",
+		"raw":     "hash",
+	})
+}
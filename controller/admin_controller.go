@@ -0,0 +1,83 @@
+package controller
+
+import (
+	"crypto/subtle"
+	"log"
+	"net/http"
+	"strings"
+	"universalsdk/models"
+	"universalsdk/service"
+	"universalsdk/util"
+
+	"github.com/gorilla/mux"
+)
+
+// AdminController exposes operator functions, all of them protected by
+// the configured admin token sent as "Authorization: Bearer <token>".
+type AdminController struct {
+	sessionKeyService service.SessionKeyService
+	adminToken        string
+}
+
+func NewAdminController(sessionKeyService service.SessionKeyService, adminToken string) *AdminController {
+	return &AdminController{sessionKeyService: sessionKeyService, adminToken: adminToken}
+}
+
+// Authenticate wraps an admin handler with the admin token check.
+// If no admin token is configured every request is rejected.
+func (x AdminController) Authenticate(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || x.adminToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(x.adminToken)) != 1 {
+			log.Printf(" ## Unauthorized admin request %s %s ##", r.Method, r.URL.Path)
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			errorObj := models.ErrorObject{Code: models.ErrorCodeUnauthorized, Message: "missing or invalid admin token"}
+			util.RespondWithStatus(w, http.StatusUnauthorized, errorObj)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// LookupSessionKey reports whether a session key is reserved and since when
+func (x AdminController) LookupSessionKey(w http.ResponseWriter, r *http.Request) {
+	sessionKey := mux.Vars(r)["sessionKey"]
+
+	sessionKeyObj, ok := x.sessionKeyService.LookupSessionKey(sessionKey)
+	if !ok {
+		respondSessionKeyNotFound(w, sessionKey)
+		return
+	}
+	util.RespondWithObject(w, sessionKeyObj)
+}
+
+// DeleteSessionKey releases a session key so it can be used again
+func (x AdminController) DeleteSessionKey(w http.ResponseWriter, r *http.Request) {
+	sessionKey := mux.Vars(r)["sessionKey"]
+
+	if !x.sessionKeyService.DeleteSessionKey(sessionKey) {
+		respondSessionKeyNotFound(w, sessionKey)
+		return
+	}
+	log.Printf(" ## Session key %s released ##", sessionKey)
+	util.RespondWithStatus(w, http.StatusNoContent, nil)
+}
+
+// ListSessionKeys lists the session keys matching the "prefix" query parameter.
+// With "countOnly=true" only the number of matching keys is returned.
+func (x AdminController) ListSessionKeys(w http.ResponseWriter, r *http.Request) {
+	prefix := r.URL.Query().Get("prefix")
+
+	if r.URL.Query().Get("countOnly") == "true" {
+		util.RespondWithObject(w, models.SessionKeyListObject{Count: x.sessionKeyService.CountSessionKeys(prefix)})
+		return
+	}
+
+	sessionKeys := x.sessionKeyService.ListSessionKeys(prefix)
+	util.RespondWithObject(w, models.SessionKeyListObject{Count: int64(len(sessionKeys)), SessionKeys: sessionKeys})
+}
+
+func respondSessionKeyNotFound(w http.ResponseWriter, sessionKey string) {
+	errorObj := models.ErrorObject{Code: models.ErrorCodeNotFound, Message: "checkSessionKey " + sessionKey + " is not reserved"}
+	util.RespondWithStatus(w, http.StatusNotFound, errorObj)
+}
@@ -0,0 +1,116 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"universalsdk/models"
+	"universalsdk/service"
+
+	"github.com/stretchr/testify/suite"
+)
+
+const mockAdminToken = "s3cret"
+
+type AdminControllerSuite struct {
+	suite.Suite
+	router http.Handler
+}
+
+func TestAdminControllerSuite(t *testing.T) {
+	suite.Run(t, new(AdminControllerSuite))
+}
+
+func (suite *AdminControllerSuite) SetupTest() {
+	var sessionKeyMap sync.Map
+	usdkController := NewUsdkController(service.NewUsdkService(&sessionKeyMap))
+	adminController := NewAdminController(service.NewSessionKeyService(&sessionKeyMap), mockAdminToken)
+
+	suite.router = NewRouter(Routes{Usdk: usdkController, Admin: adminController})
+}
+
+func (suite *AdminControllerSuite) TestUnauthorized() {
+	// The token alone, without the Bearer scheme, is rejected too
+	for _, authorization := range []string{"", "Bearer wrong", mockAdminToken, "Basic " + mockAdminToken} {
+		req, _ := http.NewRequest("GET", "/admin/sessionkeys", nil)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		response := suite.serve(req)
+		checkResponseCode(suite.T(), http.StatusUnauthorized, response.Code)
+		if challenge := response.Header().Get("WWW-Authenticate"); challenge != "Bearer" {
+			suite.T().Errorf("Expected a Bearer challenge for %q. Got '%s'", authorization, challenge)
+		}
+	}
+}
+
+func (suite *AdminControllerSuite) TestLookupAndDelete() {
+	mockRequest := mockRequest()
+	sessionKey := mockRequest[0].CheckSessionKey
+	suite.deviceCheck(mockRequest, http.StatusOK)
+
+	// Reserved key can be looked up
+	response := suite.serve(suite.adminRequest("GET", "/admin/sessionkeys/"+sessionKey))
+	checkResponseCode(suite.T(), http.StatusOK, response.Code)
+	var sessionKeyObj models.SessionKeyObject
+	json.Unmarshal(response.Body.Bytes(), &sessionKeyObj)
+	if sessionKeyObj.CheckSessionKey != sessionKey || sessionKeyObj.ReservedAt.String() == "" {
+		suite.T().Errorf("Expected the reserved session key. Got '%#v'", sessionKeyObj)
+	}
+
+	// Reuse is rejected until the key is deleted
+	suite.deviceCheck(mockRequest, http.StatusBadRequest)
+
+	response = suite.serve(suite.adminRequest("DELETE", "/admin/sessionkeys/"+sessionKey))
+	checkResponseCode(suite.T(), http.StatusNoContent, response.Code)
+
+	response = suite.serve(suite.adminRequest("GET", "/admin/sessionkeys/"+sessionKey))
+	checkResponseCode(suite.T(), http.StatusNotFound, response.Code)
+
+	suite.deviceCheck(mockRequest, http.StatusOK)
+}
+
+func (suite *AdminControllerSuite) TestListAndCount() {
+	for _, key := range []string{"incident-1", "incident-2", "other-1"} {
+		mockRequest := mockRequest()
+		mockRequest[0].CheckSessionKey = key
+		suite.deviceCheck(mockRequest, http.StatusOK)
+	}
+
+	response := suite.serve(suite.adminRequest("GET", "/admin/sessionkeys?prefix=incident-"))
+	checkResponseCode(suite.T(), http.StatusOK, response.Code)
+	var list models.SessionKeyListObject
+	json.Unmarshal(response.Body.Bytes(), &list)
+	if list.Count != 2 || len(list.SessionKeys) != 2 {
+		suite.T().Errorf("Expected 2 session keys. Got '%#v'", list)
+	}
+
+	response = suite.serve(suite.adminRequest("GET", "/admin/sessionkeys?countOnly=true"))
+	list = models.SessionKeyListObject{}
+	json.Unmarshal(response.Body.Bytes(), &list)
+	if list.Count != 3 || len(list.SessionKeys) != 0 {
+		suite.T().Errorf("Expected a count of 3 without keys. Got '%#v'", list)
+	}
+}
+
+func (suite *AdminControllerSuite) adminRequest(method, url string) *http.Request {
+	req, _ := http.NewRequest(method, url, nil)
+	req.Header.Set("Authorization", "Bearer "+mockAdminToken)
+	return req
+}
+
+func (suite *AdminControllerSuite) deviceCheck(mockRequest models.DeviceCheckDetailsObjectCollection, expected int) {
+	jsonAccount, _ := json.Marshal(mockRequest)
+	req, _ := http.NewRequest("POST", "/isgood", bytes.NewBuffer(jsonAccount))
+	req.Header.Set("Content-Type", "application/json")
+	checkResponseCode(suite.T(), expected, suite.serve(req).Code)
+}
+
+func (suite *AdminControllerSuite) serve(req *http.Request) *httptest.ResponseRecorder {
+	response := httptest.NewRecorder()
+	suite.router.ServeHTTP(response, req)
+	return response
+}
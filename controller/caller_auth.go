@@ -0,0 +1,83 @@
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"net/http"
+	"universalsdk/util"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// APIKeyHeader carries the API key of the caller of a device check, the
+// x-api-key metadata over gRPC
+const APIKeyHeader = "X-Api-Key"
+
+// callerKey is the API key of a known caller, hashed so comparing keys
+// takes the same time whatever their length
+type callerKey struct {
+	id   string
+	hash [sha256.Size]byte
+}
+
+// WithCallerKeys requires every device check to carry the API key of one of
+// the callers, keyed by caller id. The caller of a check is then the one
+// its API key belongs to rather than the X-Caller-Id it declares, and only
+// such authenticated callers are matched by caller scoped list entries.
+func WithCallerKeys(keys map[string]string) Option {
+	return func(x *UsdkController) {
+		x.callerKeys = make([]callerKey, 0, len(keys))
+		for id, key := range keys {
+			x.callerKeys = append(x.callerKeys, callerKey{id: id, hash: sha256.Sum256([]byte(key))})
+		}
+	}
+}
+
+// authenticate returns the id of the caller the API key belongs to
+func (x UsdkController) authenticate(apiKey string) (string, bool) {
+	if apiKey == "" {
+		return "", false
+	}
+	hash := sha256.Sum256([]byte(apiKey))
+	id, ok := "", false
+	// every key is compared, so the time taken doesn't tell which one matched
+	for _, key := range x.callerKeys {
+		if subtle.ConstantTimeCompare(hash[:], key.hash[:]) == 1 {
+			id, ok = key.id, true
+		}
+	}
+	return id, ok
+}
+
+// identify returns the caller of a device check. Without caller keys the
+// caller is the identity it declared and is not authenticated; with caller
+// keys ok is false unless the request carries a valid API key.
+func (x UsdkController) identify(declared, apiKey, remoteAddr string) (callerIdentity, bool) {
+	caller := callerIdentity{ID: declared, RemoteAddr: remoteAddr}
+	if len(x.callerKeys) == 0 {
+		return caller, true
+	}
+	id, ok := x.authenticate(apiKey)
+	if !ok {
+		return caller, false
+	}
+	caller.ID, caller.Authenticated = id, true
+	return caller, true
+}
+
+// httpCaller identifies the caller of a REST device check
+func (x UsdkController) httpCaller(r *http.Request) (callerIdentity, bool) {
+	return x.identify(util.CallerIdentity(r), r.Header.Get(APIKeyHeader), util.RemoteHost(r))
+}
+
+// grpcCaller identifies the caller of a gRPC device check
+func (x UsdkController) grpcCaller(ctx context.Context) (callerIdentity, bool) {
+	var apiKey string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if keys := md.Get("x-api-key"); len(keys) > 0 {
+			apiKey = keys[0]
+		}
+	}
+	return x.identify(grpcCallerIdentity(ctx), apiKey, grpcRemoteHost(ctx))
+}
@@ -0,0 +1,201 @@
+package controller
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/json"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"universalsdk/config"
+	"universalsdk/lists"
+	"universalsdk/middleware"
+	"universalsdk/models"
+	"universalsdk/service"
+	"universalsdk/signing"
+	"universalsdk/testutil"
+
+	"github.com/stretchr/testify/suite"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files of the contract tests")
+
+// contractDir holds a <name>.json request fixture per case, next to the
+// <name>.golden.json response the API answers it with
+const contractDir = "testdata/contract"
+
+// contractRequest is a request of a fixture. Body is sent as is, BodyText
+// when the body is not JSON.
+type contractRequest struct {
+	Method   string            `json:"method"`
+	Path     string            `json:"path"`
+	Headers  map[string]string `json:"headers,omitempty"`
+	Body     json.RawMessage   `json:"body,omitempty"`
+	BodyText string            `json:"bodyText,omitempty"`
+}
+
+// contractCase is the request of a fixture, sent after the requests it depends on
+type contractCase struct {
+	Description string             `json:"description"`
+	Before      []*contractRequest `json:"before,omitempty"`
+	Request     *contractRequest   `json:"request"`
+}
+
+// contractResponse is the content of a golden file. Body is the JSON body
+// with sorted keys, BodyText any other body.
+type contractResponse struct {
+	Status   int               `json:"status"`
+	Headers  map[string]string `json:"headers,omitempty"`
+	Body     interface{}       `json:"body,omitempty"`
+	BodyText string            `json:"bodyText,omitempty"`
+}
+
+type ContractSuite struct {
+	suite.Suite
+}
+
+// TestContractSuite replays the fixtures of testdata/contract against the
+// full router and compares the responses with their golden files, which
+// `go test ./controller -run TestContractSuite -update` rewrites.
+func TestContractSuite(t *testing.T) {
+	suite.Run(t, new(ContractSuite))
+}
+
+func (suite *ContractSuite) TestContracts() {
+	files, err := filepath.Glob(filepath.Join(contractDir, "*.json"))
+	suite.Require().NoError(err)
+
+	cases := 0
+	for _, file := range files {
+		if strings.HasSuffix(file, ".golden.json") {
+			continue
+		}
+		cases++
+		name := strings.TrimSuffix(filepath.Base(file), ".json")
+		suite.Run(name, func() {
+			suite.checkContract(file, filepath.Join(contractDir, name+".golden.json"))
+		})
+	}
+	suite.NotZero(cases, "no contract fixtures in %s", contractDir)
+}
+
+// TestErrorCodes pins the codes of ErrorObject, clients depend on them
+func (suite *ContractSuite) TestErrorCodes() {
+	suite.Equal(map[string]int64{
+		"ContentType":    1,
+		"InvalidRequest": 2,
+		"CheckFailed":    3,
+		"Unauthorized":   4,
+		"NotFound":       5,
+		"Internal":       6,
+		"Timeout":        7,
+	}, map[string]int64{
+		"ContentType":    models.ErrorCodeContentType,
+		"InvalidRequest": models.ErrorCodeInvalidRequest,
+		"CheckFailed":    models.ErrorCodeCheckFailed,
+		"Unauthorized":   models.ErrorCodeUnauthorized,
+		"NotFound":       models.ErrorCodeNotFound,
+		"Internal":       models.ErrorCodeInternal,
+		"Timeout":        models.ErrorCodeTimeout,
+	})
+}
+
+func (suite *ContractSuite) checkContract(fixture, golden string) {
+	data, err := os.ReadFile(fixture)
+	suite.Require().NoError(err)
+	var c contractCase
+	suite.Require().NoError(json.Unmarshal(data, &c), fixture)
+	suite.Require().NotNil(c.Request, "%s has no request", fixture)
+
+	router := contractRouter(suite.T())
+	for i, before := range c.Before {
+		response := serveContract(router, before)
+		suite.Require().Less(response.Code, 300, "request %d before the case failed: %s", i, response.Body)
+	}
+	actual, err := json.MarshalIndent(recordContract(serveContract(router, c.Request)), "", "  ")
+	suite.Require().NoError(err)
+	actual = append(actual, '\n')
+
+	if *update {
+		suite.Require().NoError(os.WriteFile(golden, actual, 0644))
+		return
+	}
+	expected, err := os.ReadFile(golden)
+	suite.Require().NoError(err, "run go test ./controller -run TestContractSuite -update to create it")
+	suite.Equal(string(expected), string(actual), "response differs from %s", golden)
+}
+
+// contractSigningKey signs the responses, Ed25519 signatures are
+// deterministic so they are part of the golden files
+var contractSigningKey = signing.Key{ID: "contract", PrivateKey: ed25519.NewKeyFromSeed(bytes.Repeat([]byte{1}, ed25519.SeedSize))}
+
+// contractRouter serves every controller with the default middlewares.
+// Time is frozen at testutil.Epoch so the responses don't change between runs.
+func contractRouter(t *testing.T) http.Handler {
+	var sessionKeyMap sync.Map
+	clock := testutil.NewClock(testutil.Epoch)
+	journeys := service.NewJourneyStore(service.DefaultTransitions(), service.DefaultJourneyTTL, service.WithJourneyClock(clock.Now))
+	listStore := lists.NewStore([]string{"ip.address", "device.fingerprint"}, lists.WithClock(clock.Now))
+	usdkService := service.NewUsdkService(&sessionKeyMap, service.WithClock(clock.Now), service.WithJourneyStore(journeys), service.WithLists(listStore))
+
+	signer, err := signing.NewSigner(contractSigningKey.ID, []signing.Key{contractSigningKey}, signing.WithClock(clock.Now))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	middlewares, err := middleware.FromConfig(config.Default().Server.Middleware)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewRouter(Routes{
+		Usdk:    NewUsdkController(usdkService, WithSigner(signer)),
+		Signing: NewSigningController(signer),
+		Journey: NewJourneyController(service.NewJourneyService(journeys)),
+		Admin:   NewAdminController(service.NewSessionKeyService(&sessionKeyMap), mockAdminToken),
+		Lists:   NewListController(listStore),
+	}, middlewares...)
+}
+
+// serveContract sends the request with a fixed request id, the id is
+// otherwise random
+func serveContract(router http.Handler, r *contractRequest) *httptest.ResponseRecorder {
+	body := []byte(r.BodyText)
+	if len(r.Body) > 0 {
+		body = r.Body
+	}
+	req := httptest.NewRequest(r.Method, r.Path, bytes.NewReader(body))
+	req.Header.Set("X-Request-Id", "contract-test")
+	for name, value := range r.Headers {
+		req.Header.Set(name, value)
+	}
+
+	response := httptest.NewRecorder()
+	router.ServeHTTP(response, req)
+	return response
+}
+
+func recordContract(response *httptest.ResponseRecorder) contractResponse {
+	recorded := contractResponse{Status: response.Code, Headers: make(map[string]string)}
+	names := make([]string, 0, len(response.Header()))
+	for name := range response.Header() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		recorded.Headers[name] = strings.Join(response.Header().Values(name), ", ")
+	}
+
+	var body interface{}
+	if err := json.Unmarshal(response.Body.Bytes(), &body); err == nil {
+		recorded.Body = body
+	} else {
+		recorded.BodyText = response.Body.String()
+	}
+	return recorded
+}
@@ -0,0 +1,32 @@
+package controller
+
+import (
+	"net/http"
+	"universalsdk/models"
+	"universalsdk/service"
+	"universalsdk/util"
+
+	"github.com/gorilla/mux"
+)
+
+// JourneyController returns the history of the checks of a journey
+type JourneyController struct {
+	journeyService service.JourneyService
+}
+
+func NewJourneyController(journeyService service.JourneyService) *JourneyController {
+	return &JourneyController{journeyService: journeyService}
+}
+
+// LookupJourney returns the successful checks of the journey, oldest first
+func (x JourneyController) LookupJourney(w http.ResponseWriter, r *http.Request) {
+	journeyID := mux.Vars(r)["journeyId"]
+
+	journeyObj, ok := x.journeyService.LookupJourney(journeyID)
+	if !ok {
+		errorObj := models.ErrorObject{Code: models.ErrorCodeNotFound, Message: "journeyId " + journeyID + " is not known"}
+		util.RespondWithStatus(w, http.StatusNotFound, errorObj)
+		return
+	}
+	util.RespondWithObject(w, journeyObj)
+}
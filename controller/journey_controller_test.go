@@ -0,0 +1,95 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+	"universalsdk/models"
+	"universalsdk/service"
+	"universalsdk/testutil"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type JourneyControllerSuite struct {
+	suite.Suite
+	router http.Handler
+	clock  *testutil.Clock
+}
+
+func TestJourneyControllerSuite(t *testing.T) {
+	suite.Run(t, new(JourneyControllerSuite))
+}
+
+func (suite *JourneyControllerSuite) SetupTest() {
+	var sessionKeyMap sync.Map
+	suite.clock = testutil.NewClock(testutil.Epoch)
+	journeys := service.NewJourneyStore(service.DefaultTransitions(), service.DefaultJourneyTTL, service.WithJourneyClock(suite.clock.Now))
+	usdkController := NewUsdkController(service.NewUsdkService(&sessionKeyMap, service.WithClock(suite.clock.Now), service.WithJourneyStore(journeys)))
+	journeyController := NewJourneyController(service.NewJourneyService(journeys))
+
+	adminController := NewAdminController(service.NewSessionKeyService(&sessionKeyMap), mockAdminToken)
+
+	suite.router = NewRouter(Routes{Usdk: usdkController, Journey: journeyController, Admin: adminController})
+}
+
+func (suite *JourneyControllerSuite) TestJourney() {
+	// A confirmation can't start a journey
+	suite.deviceCheck("journey-1", models.DeviceCheckDetailsObjectActivityTypeCONFIRMATION, http.StatusBadRequest)
+
+	for _, activityType := range []string{"LOGIN", "PAYMENT", "CONFIRMATION"} {
+		suite.deviceCheck("journey-1", activityType, http.StatusOK)
+	}
+
+	// Journeys are only served to the admin
+	req, _ := http.NewRequest("GET", "/admin/journeys/journey-1", nil)
+	checkResponseCode(suite.T(), http.StatusUnauthorized, suite.serve(req).Code)
+
+	req.Header.Set("Authorization", "Bearer "+mockAdminToken)
+	response := suite.serve(req)
+	checkResponseCode(suite.T(), http.StatusOK, response.Code)
+
+	var journey models.JourneyObject
+	json.Unmarshal(response.Body.Bytes(), &journey)
+	suite.Equal("journey-1", journey.JourneyID)
+	suite.Require().Len(journey.Steps, 3)
+	suite.Equal("LOGIN", journey.Steps[0].ActivityType)
+	suite.Equal("CONFIRMATION", journey.Steps[2].ActivityType)
+	suite.NotEqual(journey.Steps[0].CheckSessionKey, journey.Steps[1].CheckSessionKey)
+
+	req, _ = http.NewRequest("GET", "/admin/journeys/unknown", nil)
+	req.Header.Set("Authorization", "Bearer "+mockAdminToken)
+	checkResponseCode(suite.T(), http.StatusNotFound, suite.serve(req).Code)
+}
+
+func (suite *JourneyControllerSuite) TestExpiredJourney() {
+	suite.deviceCheck("journey-1", models.DeviceCheckDetailsObjectActivityTypeLOGIN, http.StatusOK)
+
+	req, _ := http.NewRequest("GET", "/admin/journeys/journey-1", nil)
+	req.Header.Set("Authorization", "Bearer "+mockAdminToken)
+	checkResponseCode(suite.T(), http.StatusOK, suite.serve(req).Code)
+
+	// No check swept the journey, it still expired
+	suite.clock.Advance(service.DefaultJourneyTTL + time.Second)
+	checkResponseCode(suite.T(), http.StatusNotFound, suite.serve(req).Code)
+}
+
+func (suite *JourneyControllerSuite) deviceCheck(journeyID, activityType string, expected int) {
+	mockRequest := mockRequest()
+	mockRequest[0].JourneyID = journeyID
+	mockRequest[0].ActivityType = activityType
+	jsonAccount, _ := json.Marshal(mockRequest)
+	req, _ := http.NewRequest("POST", "/isgood", bytes.NewBuffer(jsonAccount))
+	req.Header.Set("Content-Type", "application/json")
+	checkResponseCode(suite.T(), expected, suite.serve(req).Code)
+}
+
+func (suite *JourneyControllerSuite) serve(req *http.Request) *httptest.ResponseRecorder {
+	response := httptest.NewRecorder()
+	suite.router.ServeHTTP(response, req)
+	return response
+}
@@ -0,0 +1,133 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+	"universalsdk/lists"
+	"universalsdk/models"
+	"universalsdk/util"
+
+	"github.com/gorilla/mux"
+)
+
+// ListController manages the allow and deny list entries. Its handlers are
+// meant to be wrapped with AdminController.Authenticate.
+type ListController struct {
+	store *lists.Store
+}
+
+func NewListController(store *lists.Store) *ListController {
+	return &ListController{store: store}
+}
+
+// ListEntries lists the entries that have not expired, oldest first
+func (x ListController) ListEntries(w http.ResponseWriter, r *http.Request) {
+	util.RespondWithObject(w, x.store.List())
+}
+
+// AddEntry adds the entry of the request body and returns it with its id
+func (x ListController) AddEntry(w http.ResponseWriter, r *http.Request) {
+	entry, ok := decodeListEntry(w, r)
+	if !ok {
+		return
+	}
+
+	added, err := x.store.Add(entry)
+	if err != nil {
+		respondInvalidListEntry(w, err)
+		return
+	}
+	log.Printf(" ## %s list entry %s added for %s ##", added.List, added.ID, added.KvpKey)
+	util.RespondWithStatus(w, http.StatusCreated, added)
+}
+
+// LookupEntry returns an entry that has not expired
+func (x ListController) LookupEntry(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	entry, ok := x.store.Get(id)
+	if !ok {
+		respondListEntryNotFound(w, id)
+		return
+	}
+	util.RespondWithObject(w, entry)
+}
+
+// UpdateEntry replaces an entry with the request body
+func (x ListController) UpdateEntry(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	entry, ok := decodeListEntry(w, r)
+	if !ok {
+		return
+	}
+
+	updated, err := x.store.Update(id, entry)
+	if err == lists.ErrNotFound {
+		respondListEntryNotFound(w, id)
+		return
+	}
+	if err != nil {
+		respondInvalidListEntry(w, err)
+		return
+	}
+	log.Printf(" ## List entry %s updated ##", id)
+	util.RespondWithObject(w, updated)
+}
+
+// DeleteEntry removes an entry
+func (x ListController) DeleteEntry(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	if !x.store.Delete(id) {
+		respondListEntryNotFound(w, id)
+		return
+	}
+	log.Printf(" ## List entry %s deleted ##", id)
+	util.RespondWithStatus(w, http.StatusNoContent, nil)
+}
+
+// Export downloads the entries as a JSON file that Import accepts
+func (x ListController) Export(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=lists-%s.json", time.Now().UTC().Format("20060102T150405Z")))
+	if err := x.store.Export(w); err != nil {
+		log.Printf(" ## List export failure %s ##", err)
+	}
+}
+
+// Import adds the entries of an exported file, replacing all the existing
+// entries with "replace=true"
+func (x ListController) Import(w http.ResponseWriter, r *http.Request) {
+	replace, _ := strconv.ParseBool(r.URL.Query().Get("replace"))
+
+	count, err := x.store.Import(r.Body, replace)
+	if err != nil {
+		respondInvalidListEntry(w, err)
+		return
+	}
+	log.Printf(" ## %d list entries imported, replace %t ##", count, replace)
+	util.RespondWithObject(w, map[string]int{"imported": count})
+}
+
+func decodeListEntry(w http.ResponseWriter, r *http.Request) (*models.ListEntryObject, bool) {
+	entry := &models.ListEntryObject{}
+	if err := json.NewDecoder(r.Body).Decode(entry); err != nil {
+		respondInvalidListEntry(w, err)
+		return nil, false
+	}
+	return entry, true
+}
+
+func respondInvalidListEntry(w http.ResponseWriter, err error) {
+	errorObj := models.ErrorObject{Code: models.ErrorCodeInvalidRequest, Message: err.Error()}
+	util.RespondWithErrorObject(w, errorObj)
+}
+
+func respondListEntryNotFound(w http.ResponseWriter, id string) {
+	errorObj := models.ErrorObject{Code: models.ErrorCodeNotFound, Message: "list entry " + id + " does not exist"}
+	util.RespondWithStatus(w, http.StatusNotFound, errorObj)
+}
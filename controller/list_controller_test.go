@@ -0,0 +1,163 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"universalsdk/lists"
+	"universalsdk/models"
+	"universalsdk/service"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type ListControllerSuite struct {
+	suite.Suite
+	router http.Handler
+}
+
+func TestListControllerSuite(t *testing.T) {
+	suite.Run(t, new(ListControllerSuite))
+}
+
+func (suite *ListControllerSuite) SetupTest() {
+	var sessionKeyMap sync.Map
+	store := lists.NewStore([]string{"ip.address"}, lists.WithCallers([]string{"qa", "other"}))
+	usdkController := NewUsdkController(service.NewUsdkService(&sessionKeyMap, service.WithLists(store)),
+		WithCallerKeys(map[string]string{"qa": "qa-key", "other": "other-key"}))
+	adminController := NewAdminController(service.NewSessionKeyService(&sessionKeyMap), mockAdminToken)
+	listController := NewListController(store)
+
+	suite.router = NewRouter(Routes{Usdk: usdkController, Admin: adminController, Lists: listController})
+}
+
+func (suite *ListControllerSuite) TestDenyAndAllow() {
+	entry := models.ListEntryObject{List: "deny", KvpKey: "ip.address", Match: "cidr", Value: "1.23.0.0/16", Reason: "botnet"}
+	response := suite.serve(suite.adminRequest("POST", "/admin/lists", entry))
+	checkResponseCode(suite.T(), http.StatusCreated, response.Code)
+	var added models.ListEntryObject
+	json.Unmarshal(response.Body.Bytes(), &added)
+
+	// The mock request comes from 1.23.45.123
+	resp := suite.deviceCheck("other-key", "")
+	suite.False(resp.Puppy)
+	suite.Require().Len(resp.ListMatches, 1)
+	suite.Equal(added.ID, resp.ListMatches[0].EntryID)
+	suite.Equal("botnet", resp.ListMatches[0].Reason)
+
+	// Turned into an allow entry for the test caller only
+	added.List = "allow"
+	added.Caller = "qa"
+	response = suite.serve(suite.adminRequest("PUT", "/admin/lists/"+added.ID, added))
+	checkResponseCode(suite.T(), http.StatusOK, response.Code)
+
+	resp = suite.deviceCheck("qa-key", "")
+	suite.True(resp.Puppy)
+	suite.Require().Len(resp.ListMatches, 1)
+	suite.Equal("allow", resp.ListMatches[0].List)
+
+	resp = suite.deviceCheck("other-key", "")
+	suite.True(resp.Puppy)
+	suite.Empty(resp.ListMatches)
+
+	// Declaring to be the test caller doesn't make a caller it
+	resp = suite.deviceCheck("other-key", "qa")
+	suite.Empty(resp.ListMatches)
+
+	response = suite.serve(suite.adminRequest("DELETE", "/admin/lists/"+added.ID, nil))
+	checkResponseCode(suite.T(), http.StatusNoContent, response.Code)
+	response = suite.serve(suite.adminRequest("GET", "/admin/lists/"+added.ID, nil))
+	checkResponseCode(suite.T(), http.StatusNotFound, response.Code)
+}
+
+func (suite *ListControllerSuite) TestInvalidEntry() {
+	entry := models.ListEntryObject{List: "deny", KvpKey: "user.agent", Match: "exact", Value: "curl"}
+	response := suite.serve(suite.adminRequest("POST", "/admin/lists", entry))
+	checkResponseCode(suite.T(), http.StatusBadRequest, response.Code)
+
+	req, _ := http.NewRequest("GET", "/admin/lists", nil)
+	checkResponseCode(suite.T(), http.StatusUnauthorized, suite.serve(req).Code)
+
+	// Only authenticated callers can be listed
+	entry = models.ListEntryObject{List: "allow", KvpKey: "ip.address", Match: "exact", Value: "1.1.1.1", Caller: "unknown"}
+	response = suite.serve(suite.adminRequest("POST", "/admin/lists", entry))
+	checkResponseCode(suite.T(), http.StatusBadRequest, response.Code)
+}
+
+func (suite *ListControllerSuite) TestUnauthenticatedCaller() {
+	for _, apiKey := range []string{"", "wrong-key"} {
+		jsonAccount, _ := json.Marshal(mockRequest())
+		req, _ := http.NewRequest("POST", "/isgood", bytes.NewBuffer(jsonAccount))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Caller-Id", "qa")
+		if apiKey != "" {
+			req.Header.Set(APIKeyHeader, apiKey)
+		}
+		response := suite.serve(req)
+		checkResponseCode(suite.T(), http.StatusUnauthorized, response.Code)
+		suite.Equal("ApiKey", response.Header().Get("WWW-Authenticate"))
+
+		var errorObj models.ErrorObject
+		json.Unmarshal(response.Body.Bytes(), &errorObj)
+		suite.Equal(models.ErrorCodeUnauthorized, errorObj.Code)
+	}
+}
+
+func (suite *ListControllerSuite) TestExportImport() {
+	for _, value := range []string{"1.1.1.1", "2.2.2.2"} {
+		entry := models.ListEntryObject{List: "deny", KvpKey: "ip.address", Match: "exact", Value: value}
+		checkResponseCode(suite.T(), http.StatusCreated, suite.serve(suite.adminRequest("POST", "/admin/lists", entry)).Code)
+	}
+
+	response := suite.serve(suite.adminRequest("GET", "/admin/lists/export", nil))
+	checkResponseCode(suite.T(), http.StatusOK, response.Code)
+	suite.Contains(response.Header().Get("Content-Disposition"), "attachment")
+	exported := response.Body.Bytes()
+
+	suite.SetupTest()
+	req, _ := http.NewRequest("POST", "/admin/lists/import?replace=true", bytes.NewReader(exported))
+	req.Header.Set("Authorization", "Bearer "+mockAdminToken)
+	response = suite.serve(req)
+	checkResponseCode(suite.T(), http.StatusOK, response.Code)
+	suite.JSONEq(`{"imported":2}`, response.Body.String())
+
+	response = suite.serve(suite.adminRequest("GET", "/admin/lists", nil))
+	var entries []*models.ListEntryObject
+	json.Unmarshal(response.Body.Bytes(), &entries)
+	suite.Len(entries, 2)
+}
+
+func (suite *ListControllerSuite) adminRequest(method, url string, body interface{}) *http.Request {
+	var buf bytes.Buffer
+	if body != nil {
+		json.NewEncoder(&buf).Encode(body)
+	}
+	req, _ := http.NewRequest(method, url, &buf)
+	req.Header.Set("Authorization", "Bearer "+mockAdminToken)
+	return req
+}
+
+func (suite *ListControllerSuite) deviceCheck(apiKey, caller string) models.DeviceCheckResponseObject {
+	jsonAccount, _ := json.Marshal(mockRequest())
+	req, _ := http.NewRequest("POST", "/isgood", bytes.NewBuffer(jsonAccount))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(APIKeyHeader, apiKey)
+	if caller != "" {
+		req.Header.Set("X-Caller-Id", caller)
+	}
+	response := suite.serve(req)
+	checkResponseCode(suite.T(), http.StatusOK, response.Code)
+
+	var resp models.DeviceCheckResponseObject
+	json.Unmarshal(response.Body.Bytes(), &resp)
+	return resp
+}
+
+func (suite *ListControllerSuite) serve(req *http.Request) *httptest.ResponseRecorder {
+	response := httptest.NewRecorder()
+	suite.router.ServeHTTP(response, req)
+	return response
+}
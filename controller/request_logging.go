@@ -0,0 +1,37 @@
+package controller
+
+import (
+	"context"
+	"time"
+	"universalsdk/middleware"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// callerIdentity identifies the caller of a device check for the audit trail
+type callerIdentity struct {
+	// ID is the caller its API key belongs to when Authenticated, otherwise
+	// the identity the caller declared, or its host without one
+	ID            string
+	Authenticated bool
+
+	// RemoteAddr is the host the request came from
+	RemoteAddr string
+}
+
+// LoggingInterceptor logs every unary gRPC call in the format of the REST
+// access log, with the request ID of the x-request-id metadata
+func LoggingInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	var requestID string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if ids := md.Get("x-request-id"); len(ids) > 0 {
+			requestID = ids[0]
+		}
+	}
+	middleware.LogRequest("grpc", info.FullMethod, grpcCallerIdentity(ctx), status.Code(err).String(), requestID, time.Since(start))
+	return resp, err
+}
@@ -0,0 +1,83 @@
+package controller
+
+import (
+	"expvar"
+	"net/http"
+	"universalsdk/middleware"
+	"universalsdk/models"
+	"universalsdk/util"
+
+	"github.com/gorilla/mux"
+)
+
+// DefaultDeviceCheckRoute is the route of the UsdkController without configured route
+const DefaultDeviceCheckRoute = "/isgood"
+
+// Routes are the controllers served by NewRouter, nil ones are left out.
+// The journey and list routes are only served with an AdminController
+// authenticating them.
+type Routes struct {
+	DeviceCheckRoute string
+	Usdk             *UsdkController
+	Journey          *JourneyController
+	Admin            *AdminController
+	Lists            *ListController
+	Signing          *SigningController
+}
+
+// NewRouter routes the REST API to the controllers, wrapped by the
+// middlewares, the first one being the outermost. Unknown routes and
+// methods are answered with an ErrorObject like every other error.
+func NewRouter(routes Routes, middlewares ...middleware.Middleware) http.Handler {
+	router := mux.NewRouter()
+	router.NotFoundHandler = http.HandlerFunc(notFound)
+	router.MethodNotAllowedHandler = http.HandlerFunc(methodNotAllowed)
+
+	if routes.Usdk != nil {
+		route := routes.DeviceCheckRoute
+		if route == "" {
+			route = DefaultDeviceCheckRoute
+		}
+		router.HandleFunc(route, routes.Usdk.DeviceCheck).Methods("POST")
+	}
+
+	if routes.Signing != nil {
+		router.HandleFunc("/.well-known/jwks.json", routes.Signing.JWKS).Methods("GET")
+	}
+
+	if routes.Admin != nil {
+		adminController := routes.Admin
+		admin := router.PathPrefix("/admin").Subrouter()
+		admin.HandleFunc("/sessionkeys", adminController.Authenticate(adminController.ListSessionKeys)).Methods("GET")
+		admin.HandleFunc("/sessionkeys/{sessionKey}", adminController.Authenticate(adminController.LookupSessionKey)).Methods("GET")
+		admin.HandleFunc("/sessionkeys/{sessionKey}", adminController.Authenticate(adminController.DeleteSessionKey)).Methods("DELETE")
+		// the expvar variables, such as the memstats a soak test samples
+		admin.HandleFunc("/vars", adminController.Authenticate(expvar.Handler().ServeHTTP)).Methods("GET")
+
+		if journeyController := routes.Journey; journeyController != nil {
+			admin.HandleFunc("/journeys/{journeyId}", adminController.Authenticate(journeyController.LookupJourney)).Methods("GET")
+		}
+
+		if listController := routes.Lists; listController != nil {
+			admin.HandleFunc("/lists", adminController.Authenticate(listController.ListEntries)).Methods("GET")
+			admin.HandleFunc("/lists", adminController.Authenticate(listController.AddEntry)).Methods("POST")
+			admin.HandleFunc("/lists/export", adminController.Authenticate(listController.Export)).Methods("GET")
+			admin.HandleFunc("/lists/import", adminController.Authenticate(listController.Import)).Methods("POST")
+			admin.HandleFunc("/lists/{id}", adminController.Authenticate(listController.LookupEntry)).Methods("GET")
+			admin.HandleFunc("/lists/{id}", adminController.Authenticate(listController.UpdateEntry)).Methods("PUT")
+			admin.HandleFunc("/lists/{id}", adminController.Authenticate(listController.DeleteEntry)).Methods("DELETE")
+		}
+	}
+
+	return middleware.Chain(router, middlewares...)
+}
+
+func notFound(w http.ResponseWriter, r *http.Request) {
+	errorObj := models.ErrorObject{Code: models.ErrorCodeNotFound, Message: "no route " + r.URL.Path}
+	util.RespondWithStatus(w, http.StatusNotFound, errorObj)
+}
+
+func methodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	errorObj := models.ErrorObject{Code: models.ErrorCodeInvalidRequest, Message: "method " + r.Method + " is not allowed on " + r.URL.Path}
+	util.RespondWithStatus(w, http.StatusMethodNotAllowed, errorObj)
+}
@@ -0,0 +1,23 @@
+package controller
+
+import (
+	"net/http"
+	"universalsdk/signing"
+	"universalsdk/util"
+)
+
+// SigningController publishes the public keys responses are signed with
+type SigningController struct {
+	signer *signing.Signer
+}
+
+func NewSigningController(signer *signing.Signer) *SigningController {
+	return &SigningController{signer: signer}
+}
+
+// JWKS returns the public keys as a JSON Web Key Set, current key first.
+// Verifiers may cache it for a few minutes, they fetch it again for a key they don't know.
+func (x SigningController) JWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	util.RespondWithObject(w, x.signer.JWKS())
+}
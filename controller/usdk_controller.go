@@ -1,76 +1,318 @@
 package controller
 
 import (
-	"encoding/json"
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
+	"strings"
+	"sync"
+	"time"
+	"universalsdk/audit"
+	"universalsdk/codec"
+	"universalsdk/compress"
 	"universalsdk/models"
 	"universalsdk/service"
+	"universalsdk/signing"
 	"universalsdk/util"
 )
 
 type UsdkController struct {
 	usdkService service.UsdkService
+	auditLogger *audit.Logger
+	codecs      *codec.Registry
+	compression compress.Config
+	signer      *signing.Signer
+	callerKeys  []callerKey
 }
 
-func NewUsdkController(service service.UsdkService) *UsdkController {
-	return &UsdkController{usdkService: service}
+// Option configures optional collaborators of the UsdkController
+type Option func(*UsdkController)
+
+// WithAuditLogger records every device check in the audit trail
+func WithAuditLogger(auditLogger *audit.Logger) Option {
+	return func(x *UsdkController) {
+		x.auditLogger = auditLogger
+	}
+}
+
+// WithCodecs replaces the request decoders and response encoders, by default
+// JSON, CBOR, protobuf and forms
+func WithCodecs(codecs *codec.Registry) Option {
+	return func(x *UsdkController) {
+		x.codecs = codecs
+	}
+}
+
+// WithCompression replaces the compress.DefaultConfig of request and response bodies
+func WithCompression(cfg compress.Config) Option {
+	return func(x *UsdkController) {
+		x.compression = cfg
+	}
+}
+
+// WithSigner signs successful responses in the signing.Header, so the
+// backend a verdict is passed to can verify it
+func WithSigner(signer *signing.Signer) Option {
+	return func(x *UsdkController) {
+		x.signer = signer
+	}
+}
+
+func NewUsdkController(service service.UsdkService, opts ...Option) *UsdkController {
+	x := &UsdkController{usdkService: service, codecs: codec.Default(), compression: compress.DefaultConfig}
+	for _, opt := range opts {
+		opt(x)
+	}
+	return x
 }
 
 // Controller handler function to receive request and parse to json.
 // After conversion it will pass request to service layer for further processing
 func (x UsdkController) DeviceCheck(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	caller, authenticated := x.httpCaller(r)
+
+	// Response encoding, errors fall back to JSON when no accepted type can be encoded
+	respond := func(status int, v interface{}) { util.RespondWithStatus(w, status, v) }
+	mediaType, encode, acceptErr := x.codecs.Encoder(r.Header.Get("Accept"))
+	if acceptErr == nil {
+		respond = func(status int, v interface{}) { x.respondWithEncoder(w, r, status, mediaType, encode, v, nil) }
+	}
+
+	if !authenticated {
+		log.Print(" ## Unauthenticated caller ##")
+		errorObj := models.ErrorObject{Code: models.ErrorCodeUnauthorized, Message: "missing or invalid " + APIKeyHeader}
+		x.audit(caller, start, nil, nil, &errorObj)
+		w.Header().Set("WWW-Authenticate", "ApiKey")
+		respond(http.StatusUnauthorized, errorObj)
+		return
+	}
 
 	// Content Type Validation
-	if !util.HasContentType(r, "application/json") {
+	decode, err := x.codecs.Decoder(r.Header.Get("Content-Type"))
+	if err != nil {
 		log.Print(" ## Invalid Content Type ##")
-		errorObj := models.ErrorObject{Code: 1, Message: "Content-type should be application/json"}
-		util.RespondWithErrorObject(w, errorObj)
+		errorObj := models.ErrorObject{Code: models.ErrorCodeContentType, Message: err.Error()}
+		x.audit(caller, start, nil, nil, &errorObj)
+		respond(http.StatusUnsupportedMediaType, errorObj)
+		return
+	}
+	if acceptErr != nil {
+		log.Print(" ## Invalid Accept ##")
+		errorObj := models.ErrorObject{Code: models.ErrorCodeContentType, Message: acceptErr.Error()}
+		x.audit(caller, start, nil, nil, &errorObj)
+		respond(http.StatusNotAcceptable, errorObj)
 		return
 	}
 
-	deviceCheckReq, err := parseAndValidateRequest(r)
+	body, err := compress.RequestBody(r, x.compression.MaxDecompressedBytes)
 	if err != nil {
 		log.Print(err)
-		errorObj := models.ErrorObject{Code: 2, Message: err.Error()}
-		util.RespondWithErrorObject(w, errorObj)
+		status, errorObj := http.StatusBadRequest, models.ErrorObject{Code: models.ErrorCodeInvalidRequest, Message: err.Error()}
+		if _, ok := err.(*compress.UnsupportedError); ok {
+			status, errorObj.Code = http.StatusUnsupportedMediaType, models.ErrorCodeContentType
+		}
+		x.audit(caller, start, nil, nil, &errorObj)
+		respond(status, errorObj)
+		return
+	}
+
+	deviceCheckReq, err := parseAndValidateRequest(body, decode)
+	var maxBytesErr *http.MaxBytesError
+	if errors.Is(err, compress.ErrTooLarge) || errors.As(err, &maxBytesErr) {
+		log.Print(err)
+		errorObj := models.ErrorObject{Code: models.ErrorCodeInvalidRequest, Message: err.Error()}
+		x.audit(caller, start, nil, nil, &errorObj)
+		respond(http.StatusRequestEntityTooLarge, errorObj)
+		return
+	}
+	if err != nil {
+		log.Print(err)
+		errorObj := models.ErrorObject{Code: models.ErrorCodeInvalidRequest, Message: err.Error()}
+		x.audit(caller, start, deviceCheckReq, nil, &errorObj)
+		respond(http.StatusBadRequest, errorObj)
+		return
+	}
+
+	serviceResp, errorObj := x.deviceCheck(r.Context(), caller, start, deviceCheckReq)
+	if errorObj != nil {
+		respond(http.StatusBadRequest, *errorObj)
+		return
+	}
+	x.respondWithEncoder(w, r, http.StatusOK, mediaType, encode, serviceResp, x.claims(deviceCheckReq, serviceResp))
+}
+
+// responseBuffers are reused to encode the responses
+var responseBuffers = sync.Pool{New: func() interface{} { return new(bytes.Buffer) }}
+
+const maxPooledResponse = 64 << 10
+
+// respondWithEncoder writes the response in the negotiated media type,
+// compressed when large enough and accepted by the client. With claims the
+// encoded body is signed, before compression.
+func (x UsdkController) respondWithEncoder(w http.ResponseWriter, r *http.Request, status int, mediaType string, encode codec.EncodeFunc, v interface{}, claims *signing.Claims) {
+	buf := responseBuffers.Get().(*bytes.Buffer)
+	buf.Reset()
+	defer func() {
+		// large responses, e.g. echoing normalised activity data, are not kept
+		if buf.Cap() <= maxPooledResponse {
+			responseBuffers.Put(buf)
+		}
+	}()
+
+	if err := encode(buf, v); err != nil {
+		log.Printf(" ## Response encoding failure %s ##", err)
+		util.RespondWithStatus(w, http.StatusInternalServerError, models.ErrorObject{Code: models.ErrorCodeInternal, Message: "response encoding failure"})
 		return
 	}
+	if claims != nil {
+		signature, err := x.signer.Sign(buf.Bytes(), *claims)
+		if err != nil {
+			log.Printf(" ## Response signing failure %s ##", err)
+			util.RespondWithStatus(w, http.StatusInternalServerError, models.ErrorObject{Code: models.ErrorCodeInternal, Message: "response signing failure"})
+			return
+		}
+		w.Header().Set(signing.Header, signature)
+	}
+
+	w.Header().Set("Content-Type", mediaType)
+	w.Header().Add("Vary", "Accept")
+	if err := compress.Write(w, r, x.compression, status, buf.Bytes()); err != nil {
+		log.Printf(" ## Response write failure %s ##", err)
+	}
+}
+
+// claims are signed with the response, nil without a signer
+func (x UsdkController) claims(deviceCheckReq *models.DeviceCheckDetailsObjectCollection, resp *models.DeviceCheckResponseObject) *signing.Claims {
+	if x.signer == nil {
+		return nil
+	}
+	claims := &signing.Claims{SessionKeys: make([]string, 0, len(*deviceCheckReq)), Puppy: resp.Puppy}
+	for _, elem := range *deviceCheckReq {
+		claims.SessionKeys = append(claims.SessionKeys, elem.CheckSessionKey)
+	}
+	return claims
+}
+
+// deviceCheck is the transport independent part of a device check.
+// It passes a validated request to the service layer and audits the outcome.
+func (x UsdkController) deviceCheck(ctx context.Context, caller callerIdentity, start time.Time, deviceCheckReq *models.DeviceCheckDetailsObjectCollection) (*models.DeviceCheckResponseObject, *models.ErrorObject) {
 
-	log.Printf(" Request %#v: ", deviceCheckReq)
+	log.Printf(" Request %s: ", requestSummary(deviceCheckReq))
+
+	// Only an authenticated caller is matched by caller scoped list entries
+	if caller.Authenticated {
+		ctx = service.WithCaller(ctx, caller.ID)
+	}
 
 	// Calling Service to process the request
-	serviceResp, err := x.usdkService.DeviceCheck(*deviceCheckReq)
+	serviceResp, err := x.usdkService.DeviceCheckContext(ctx, *deviceCheckReq)
 
 	if err != nil {
 		log.Print(err)
-		errorObj := models.ErrorObject{Code: 3, Message: err.Error()}
-		util.RespondWithErrorObject(w, errorObj)
+		errorObj := &models.ErrorObject{Code: models.ErrorCodeCheckFailed, Message: err.Error()}
+		x.audit(caller, start, deviceCheckReq, nil, errorObj)
+		return nil, errorObj
+	}
+	x.audit(caller, start, deviceCheckReq, serviceResp, nil)
+	return serviceResp, nil
+}
+
+// audit writes the outcome of a device check to the audit trail, if enabled.
+// Failing to audit is logged but does not fail the request.
+func (x UsdkController) audit(caller callerIdentity, start time.Time, req *models.DeviceCheckDetailsObjectCollection, resp *models.DeviceCheckResponseObject, errorObj *models.ErrorObject) {
+	if x.auditLogger == nil {
 		return
 	}
-	util.RespondWithObject(w, serviceResp)
 
+	rec := &audit.Record{
+		Time:          start,
+		Caller:        caller.ID,
+		Authenticated: caller.Authenticated,
+		RemoteAddr:    caller.RemoteAddr,
+		Latency:       time.Since(start),
+	}
+	if req != nil {
+		rec.Checks = x.auditLogger.Checks(*req)
+	}
+
+	switch {
+	case errorObj != nil:
+		rec.Outcome = audit.OutcomeError
+		rec.ErrorCode = errorObj.Code
+		rec.Errors = []string{errorObj.Message}
+	case resp != nil && resp.Puppy:
+		rec.Outcome = audit.OutcomePass
+	default:
+		rec.Outcome = audit.OutcomeFail
+	}
+
+	if err := x.auditLogger.Log(rec); err != nil {
+		log.Printf(" ## Audit failure %s ##", err)
+	}
 }
 
-func parseAndValidateRequest(r *http.Request) (*models.DeviceCheckDetailsObjectCollection, error) {
+// requestSummary describes a request for the log by its session keys and
+// kvpKeys only, activity data values never leave process memory in plain text
+func requestSummary(deviceCheckReq *models.DeviceCheckDetailsObjectCollection) string {
+	var b strings.Builder
+	for _, elem := range *deviceCheckReq {
+		if elem == nil {
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteString("; ")
+		}
+		b.WriteString(elem.CheckSessionKey)
+		b.WriteByte(' ')
+		b.WriteString(elem.CheckType)
+		b.WriteByte('/')
+		b.WriteString(elem.ActivityType)
+		b.WriteString(" [")
+		first := true
+		for _, kvp := range elem.ActivityData {
+			if kvp == nil {
+				continue
+			}
+			if !first {
+				b.WriteByte(',')
+			}
+			b.WriteString(kvp.KvpKey)
+			first = false
+		}
+		b.WriteByte(']')
+	}
+	return b.String()
+}
 
-	// Parse request to json
+func parseAndValidateRequest(body io.Reader, decode codec.DecodeFunc) (*models.DeviceCheckDetailsObjectCollection, error) {
+
+	// Parse request in its content type
 	deviceCheckReq := &models.DeviceCheckDetailsObjectCollection{}
-	err := json.NewDecoder(r.Body).Decode(deviceCheckReq)
+	err := decode(body, deviceCheckReq)
 	if err != nil {
 		return nil, err
 	}
 
+	// The parsed request is still returned on failure so it can be audited
+	return deviceCheckReq, validateRequest(deviceCheckReq)
+}
+
+func validateRequest(deviceCheckReq *models.DeviceCheckDetailsObjectCollection) error {
+
 	if deviceCheckReq == nil || len(*deviceCheckReq) <= 0 {
-		return nil, fmt.Errorf("invalid or missing input")
+		return fmt.Errorf("invalid or missing input")
 	}
 
-	// Validate Request according to Swagger Schema
-	err = deviceCheckReq.Validate(nil)
-	if err != nil {
-		return nil, err
+	// Null checks and activity data are skipped by the Swagger Schema validation
+	if errs := deviceCheckReq.ValidateNotNull(); len(errs) > 0 {
+		return errs[0]
 	}
 
-	return deviceCheckReq, nil
+	// Validate Request according to Swagger Schema
+	return deviceCheckReq.ValidateFast(nil)
 }
@@ -0,0 +1,102 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"universalsdk/codec"
+	"universalsdk/models"
+	"universalsdk/service"
+	"universalsdk/testutil"
+)
+
+// discardWriter is a ResponseWriter reused across iterations, unlike
+// httptest.ResponseRecorder it keeps no body
+type discardWriter struct {
+	header http.Header
+	status int
+}
+
+func (w *discardWriter) Header() http.Header         { return w.header }
+func (w *discardWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (w *discardWriter) WriteHeader(status int)      { w.status = status }
+
+func BenchmarkValidateRequest(b *testing.B) {
+	for _, size := range testutil.BatchSizes {
+		collection := testutil.Batch(size.Checks, size.KVPs)
+
+		b.Run(size.Name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if err := validateRequest(&collection); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkParseAndValidateRequest(b *testing.B) {
+	for _, size := range testutil.BatchSizes {
+		body, _ := json.Marshal(testutil.Batch(size.Checks, size.KVPs))
+
+		b.Run(size.Name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(body)))
+			r := bytes.NewReader(body)
+			for i := 0; i < b.N; i++ {
+				r.Reset(body)
+				if _, err := parseAndValidateRequest(r, codec.DecodeJSON); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkDeviceCheckHandler measures a valid JSON request through the
+// router, from decoding the body to writing the response. The session keys
+// are released after every request so each iteration reserves them again.
+func BenchmarkDeviceCheckHandler(b *testing.B) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	for _, size := range testutil.BatchSizes {
+		collection := testutil.Batch(size.Checks, size.KVPs)
+		body, _ := json.Marshal(collection)
+
+		b.Run(size.Name, func(b *testing.B) {
+			var sessionKeyMap sync.Map
+			router := NewRouter(Routes{Usdk: NewUsdkController(service.NewUsdkService(&sessionKeyMap))})
+			r := bytes.NewReader(body)
+			req := httptest.NewRequest("POST", "/isgood", r)
+			req.Header.Set("Content-Type", "application/json")
+			w := &discardWriter{header: make(http.Header)}
+
+			b.ReportAllocs()
+			b.SetBytes(int64(len(body)))
+			for i := 0; i < b.N; i++ {
+				r.Reset(body)
+				req.Body = io.NopCloser(r)
+				clear(w.header)
+				router.ServeHTTP(w, req)
+				if w.status != http.StatusOK {
+					b.Fatalf("status %d", w.status)
+				}
+				release(&sessionKeyMap, collection)
+			}
+		})
+	}
+}
+
+func release(sessionKeyMap *sync.Map, collection models.DeviceCheckDetailsObjectCollection) {
+	for _, elem := range collection {
+		sessionKeyMap.Delete(elem.CheckSessionKey)
+	}
+}
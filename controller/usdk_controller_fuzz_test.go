@@ -0,0 +1,78 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"universalsdk/codec"
+	"universalsdk/models"
+	"universalsdk/service"
+)
+
+var fuzzSeeds = []string{
+	`[{"checkType":"DEVICE","activityType":"SIGNUP","checkSessionKey":"fuzz-1","activityData":[{"kvpKey":"ip.address","kvpValue":"1.23.45.123","kvpType":"general.string"}]}]`,
+	`[{"checkType":"COMBO","activityType":"_LOGIN_3","checkSessionKey":"fuzz-2","activityData":[{"kvpKey":"n","kvpValue":"007","kvpType":"general.integer"},{"kvpKey":"f","kvpValue":"1e3","kvpType":"general.float"}]}]`,
+	`[null]`,
+	`[{"activityData":[null]}]`,
+	`[{}]`,
+	`[]`,
+	`null`,
+	`{}`,
+	`[{"checkType":"DEVICE","activityType":"SIGNUP","activityData":[{"kvpKey":"a","kvpValue":"NaN","kvpType":"general.float"}]}]`,
+}
+
+// FuzzParseAndValidateRequest checks that any body is either rejected or
+// yields a collection every later layer can handle
+func FuzzParseAndValidateRequest(f *testing.F) {
+	for _, seed := range fuzzSeeds {
+		f.Add([]byte(seed))
+	}
+	f.Fuzz(func(t *testing.T, body []byte) {
+		collection, err := parseAndValidateRequest(bytes.NewReader(body), codec.DecodeJSON)
+		if err != nil {
+			return
+		}
+		if len(*collection) == 0 {
+			t.Fatalf("empty collection %q passed validation", body)
+		}
+		if errs := collection.ValidateNotNull(); len(errs) > 0 {
+			t.Fatalf("collection %q with null elements passed validation: %v", body, errs)
+		}
+	})
+}
+
+// FuzzDeviceCheck sends any body through the router, which must answer
+// with a DeviceCheckResponseObject or a 4xx ErrorObject and never panic
+func FuzzDeviceCheck(f *testing.F) {
+	for _, seed := range fuzzSeeds {
+		f.Add([]byte(seed))
+	}
+
+	var sessionKeyMap sync.Map
+	handler := NewRouter(Routes{Usdk: NewUsdkController(service.NewUsdkService(&sessionKeyMap, service.WithEchoNormalised(true)))})
+
+	f.Fuzz(func(t *testing.T, body []byte) {
+		req, _ := http.NewRequest("POST", "/isgood", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, req)
+
+		switch response.Code {
+		case http.StatusOK:
+			var puppy models.DeviceCheckResponseObject
+			if err := json.Unmarshal(response.Body.Bytes(), &puppy); err != nil {
+				t.Fatalf("invalid response %q: %v", response.Body.String(), err)
+			}
+		case http.StatusBadRequest:
+			var errorObj models.ErrorObject
+			if err := json.Unmarshal(response.Body.Bytes(), &errorObj); err != nil || errorObj.Code == 0 {
+				t.Fatalf("invalid error response %q: %v", response.Body.String(), err)
+			}
+		default:
+			t.Fatalf("unexpected status %d for %q", response.Code, body)
+		}
+	})
+}
@@ -2,17 +2,26 @@ package controller
 
 import (
 	"bytes"
+	"compress/gzip"
+	"context"
 	"encoding/json"
+	"github.com/fxamacker/cbor/v2"
 	"github.com/stretchr/testify/suite"
+	"google.golang.org/protobuf/proto"
 	"log"
 	"net/http"
 	"net/http/httptest"
-	"strconv"
+	"strings"
 	"sync"
 	"testing"
+	"universalsdk/audit"
+	"universalsdk/codec"
+	"universalsdk/compress"
 	"universalsdk/models"
+	"universalsdk/proto/usdkpb"
 	"universalsdk/service"
-	"universalsdk/util"
+	"universalsdk/signing"
+	"universalsdk/testutil"
 )
 
 type UsdkControllerSuite struct {
@@ -33,9 +42,10 @@ func (suite *UsdkControllerSuite) TestEmptyRequest() {
 	usdkController := createUsdkController()
 
 	req, _ := http.NewRequest("POST", "/isgood", bytes.NewBuffer(jsonAccount))
+	req.Header.Set("Content-Type", "application/json")
 
 	response := httptest.NewRecorder()
-	handler := http.HandlerFunc(usdkController.DeviceCheck)
+	handler := NewRouter(Routes{Usdk: usdkController})
 	handler.ServeHTTP(response, req)
 
 	checkResponseCode(suite.T(), http.StatusBadRequest, response.Code)
@@ -60,17 +70,16 @@ func (suite *UsdkControllerSuite) TestInvalidContentType() {
 
 	req.Header.Set("Content-Type", "application/xml")
 	response := httptest.NewRecorder()
-	handler := http.HandlerFunc(usdkController.DeviceCheck)
+	handler := NewRouter(Routes{Usdk: usdkController})
 	handler.ServeHTTP(response, req)
 
-	checkResponseCode(suite.T(), http.StatusBadRequest, response.Code)
+	checkResponseCode(suite.T(), http.StatusUnsupportedMediaType, response.Code)
 
 	var m map[string]interface{}
 	json.Unmarshal(response.Body.Bytes(), &m)
 
-	if m["message"] == "" {
-		suite.T().Errorf("Expected the detail of error. Got '%s'", m["status"])
-	}
+	// The alternatives are listed
+	suite.Contains(m["message"], "application/cbor")
 
 	suite.T().Log(m["message"])
 }
@@ -84,7 +93,7 @@ func (suite *UsdkControllerSuite) TestInvalidRequest() {
 	req, _ := http.NewRequest("POST", "/isgood", bytes.NewBuffer(jsonAccount))
 	req.Header.Set("Content-Type", "application/json")
 	response := httptest.NewRecorder()
-	handler := http.HandlerFunc(usdkController.DeviceCheck)
+	handler := NewRouter(Routes{Usdk: usdkController})
 	handler.ServeHTTP(response, req)
 
 	checkResponseCode(suite.T(), http.StatusBadRequest, response.Code)
@@ -108,7 +117,7 @@ func (suite *UsdkControllerSuite) TestSameSessionKey() {
 	req, _ := http.NewRequest("POST", "/isgood", bytes.NewBuffer(jsonAccount))
 	req.Header.Set("Content-Type", "application/json")
 	response := httptest.NewRecorder()
-	handler := http.HandlerFunc(usdkController.DeviceCheck)
+	handler := NewRouter(Routes{Usdk: usdkController})
 	handler.ServeHTTP(response, req)
 
 	checkResponseCode(suite.T(), http.StatusBadRequest, response.Code)
@@ -131,7 +140,7 @@ func (suite *UsdkControllerSuite) TestDuplicateActivityKey() {
 	req, _ := http.NewRequest("POST", "/isgood", bytes.NewBuffer(jsonAccount))
 	req.Header.Set("Content-Type", "application/json")
 	response := httptest.NewRecorder()
-	handler := http.HandlerFunc(usdkController.DeviceCheck)
+	handler := NewRouter(Routes{Usdk: usdkController})
 	handler.ServeHTTP(response, req)
 
 	checkResponseCode(suite.T(), http.StatusBadRequest, response.Code)
@@ -154,7 +163,7 @@ func (suite *UsdkControllerSuite) TestActivityKeyWithInvalidDataType() {
 	req, _ := http.NewRequest("POST", "/isgood", bytes.NewBuffer(jsonAccount))
 	req.Header.Set("Content-Type", "application/json")
 	response := httptest.NewRecorder()
-	handler := http.HandlerFunc(usdkController.DeviceCheck)
+	handler := NewRouter(Routes{Usdk: usdkController})
 	handler.ServeHTTP(response, req)
 
 	checkResponseCode(suite.T(), http.StatusBadRequest, response.Code)
@@ -177,7 +186,7 @@ func (suite *UsdkControllerSuite) TestVendorActivityType() {
 	req, _ := http.NewRequest("POST", "/isgood", bytes.NewBuffer(jsonAccount))
 	req.Header.Set("Content-Type", "application/json")
 	response := httptest.NewRecorder()
-	handler := http.HandlerFunc(usdkController.DeviceCheck)
+	handler := NewRouter(Routes{Usdk: usdkController})
 	handler.ServeHTTP(response, req)
 
 	checkResponseCode(suite.T(), http.StatusOK, response.Code)
@@ -200,7 +209,7 @@ func (suite *UsdkControllerSuite) TestInvalidActivityType() {
 	req, _ := http.NewRequest("POST", "/isgood", bytes.NewBuffer(jsonAccount))
 	req.Header.Set("Content-Type", "application/json")
 	response := httptest.NewRecorder()
-	handler := http.HandlerFunc(usdkController.DeviceCheck)
+	handler := NewRouter(Routes{Usdk: usdkController})
 	handler.ServeHTTP(response, req)
 
 	checkResponseCode(suite.T(), http.StatusBadRequest, response.Code)
@@ -223,7 +232,7 @@ func (suite *UsdkControllerSuite) TestInvalidCheckType() {
 	req, _ := http.NewRequest("POST", "/isgood", bytes.NewBuffer(jsonAccount))
 	req.Header.Set("Content-Type", "application/json")
 	response := httptest.NewRecorder()
-	handler := http.HandlerFunc(usdkController.DeviceCheck)
+	handler := NewRouter(Routes{Usdk: usdkController})
 	handler.ServeHTTP(response, req)
 
 	checkResponseCode(suite.T(), http.StatusBadRequest, response.Code)
@@ -247,7 +256,7 @@ func (suite *UsdkControllerSuite) TestSuccessRequest() {
 	req.Header.Set("Content-Type", "application/json")
 
 	response := httptest.NewRecorder()
-	handler := http.HandlerFunc(usdkController.DeviceCheck)
+	handler := NewRouter(Routes{Usdk: usdkController})
 	handler.ServeHTTP(response, req)
 
 	checkResponseCode(suite.T(), http.StatusOK, response.Code)
@@ -260,6 +269,266 @@ func (suite *UsdkControllerSuite) TestSuccessRequest() {
 	}
 }
 
+func (suite *UsdkControllerSuite) TestNullElements() {
+	handler := NewRouter(Routes{Usdk: createUsdkController()})
+
+	tests := map[string]string{
+		`[null]`: "0 in body is required",
+		`[{"checkType":"DEVICE","activityType":"SIGNUP","checkSessionKey":"null-1","activityData":[null]}]`: "0.activityData.0 in body is required",
+		`[{"checkType":"DEVICE","activityType":"SIGNUP","checkSessionKey":"null-2","activityData":[` +
+			`{"kvpKey":"ip.address","kvpValue":"1.23.45.123","kvpType":"general.string"},null]}]`: "0.activityData.1 in body is required",
+	}
+	for body, message := range tests {
+		req, _ := http.NewRequest("POST", "/isgood", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, req)
+
+		suite.Equal(http.StatusBadRequest, response.Code, body)
+		var errorObj models.ErrorObject
+		json.Unmarshal(response.Body.Bytes(), &errorObj)
+		suite.Equal(models.ErrorCodeInvalidRequest, errorObj.Code, body)
+		suite.Equal(message, errorObj.Message, body)
+	}
+}
+
+func (suite *UsdkControllerSuite) TestValidateFast() {
+	invalid := []func(check *models.DeviceCheckDetailsObject){
+		func(check *models.DeviceCheckDetailsObject) { check.CheckType = "DUMMY" },
+		func(check *models.DeviceCheckDetailsObject) { check.ActivityType = "DUMMY" },
+		func(check *models.DeviceCheckDetailsObject) { check.ActivityData[1].KvpType = "general.dummy" },
+	}
+	for i, mutate := range invalid {
+		collection := testutil.Batch(3, 4)
+		mutate(collection[2])
+		expected := collection.Validate(nil)
+		suite.Error(expected, "case %d", i)
+		suite.Equal(expected, collection.ValidateFast(nil), "case %d", i)
+	}
+
+	// Vendor activity types and missing enums are valid
+	collection := testutil.Collection(testutil.NewCheck("fast-1").ActivityType("_LOGIN_3").KVP("ip.address", testutil.MockIP, ""), testutil.NewCheck("fast-2").CheckType(""))
+	suite.NoError(collection.Validate(nil))
+	suite.NoError(collection.ValidateFast(nil))
+
+	// A valid request is validated without allocating
+	collection = testutil.Batch(10, 10)
+	allocs := testing.AllocsPerRun(100, func() {
+		if err := validateRequest(&collection); err != nil {
+			suite.Fail(err.Error())
+		}
+	})
+	suite.Zero(allocs)
+}
+
+func (suite *UsdkControllerSuite) TestContentNegotiation() {
+	handler := NewRouter(Routes{Usdk: createUsdkController()})
+
+	var cborBody bytes.Buffer
+	codec.EncodeCBOR(&cborBody, mockRequest())
+	protoBody, _ := proto.Marshal(usdkpb.FromCollection(mockRequest()))
+	tests := []struct {
+		contentType string
+		body        []byte
+	}{
+		{codec.CBOR, cborBody.Bytes()},
+		{codec.Protobuf, protoBody},
+		{codec.Form, []byte(codec.EncodeForm(mockRequest()).Encode())},
+	}
+	for _, test := range tests {
+		req, _ := http.NewRequest("POST", "/isgood", bytes.NewBuffer(test.body))
+		req.Header.Set("Content-Type", test.contentType)
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, req)
+
+		suite.Equal(http.StatusOK, response.Code, test.contentType)
+		suite.Equal(codec.JSON, response.Header().Get("Content-Type"))
+		suite.JSONEq(`{"puppy":true}`, response.Body.String(), test.contentType)
+	}
+
+	// The response is encoded in the preferred accepted type
+	req, _ := http.NewRequest("POST", "/isgood", bytes.NewBuffer(mustJSON(mockRequest())))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json;q=0.5, application/x-protobuf")
+	response := httptest.NewRecorder()
+	handler.ServeHTTP(response, req)
+	suite.Equal(http.StatusOK, response.Code)
+	suite.Equal(codec.Protobuf, response.Header().Get("Content-Type"))
+	puppy := &usdkpb.PuppyObject{}
+	suite.NoError(proto.Unmarshal(response.Body.Bytes(), puppy))
+	suite.True(puppy.GetPuppy())
+
+	req, _ = http.NewRequest("POST", "/isgood", bytes.NewBuffer(mustJSON(mockRequest())))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/cbor")
+	response = httptest.NewRecorder()
+	handler.ServeHTTP(response, req)
+	var m map[string]interface{}
+	suite.NoError(cbor.Unmarshal(response.Body.Bytes(), &m))
+	suite.Equal(true, m["puppy"])
+
+	req, _ = http.NewRequest("POST", "/isgood", bytes.NewBuffer(mustJSON(mockRequest())))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "text/html")
+	response = httptest.NewRecorder()
+	handler.ServeHTTP(response, req)
+	suite.Equal(http.StatusNotAcceptable, response.Code)
+	suite.Contains(response.Body.String(), "application/x-protobuf")
+}
+
+func (suite *UsdkControllerSuite) TestCompression() {
+	var sessionKeyMap sync.Map
+	usdkService := service.NewUsdkService(&sessionKeyMap, service.WithEchoNormalised(true))
+	handler := NewRouter(Routes{Usdk: NewUsdkController(usdkService, WithCompression(compress.Config{Enabled: true, MinSize: 10, MaxDecompressedBytes: 1 << 16}))})
+
+	var body bytes.Buffer
+	zw := gzip.NewWriter(&body)
+	zw.Write(mustJSON(mockRequest()))
+	zw.Close()
+
+	req, _ := http.NewRequest("POST", "/isgood", &body)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Encoding", "gzip")
+	req.Header.Set("Accept-Encoding", "gzip")
+	response := httptest.NewRecorder()
+	handler.ServeHTTP(response, req)
+
+	suite.Equal(http.StatusOK, response.Code)
+	suite.Equal("gzip", response.Header().Get("Content-Encoding"))
+	zr, err := gzip.NewReader(response.Body)
+	suite.Require().NoError(err)
+	var m map[string]interface{}
+	suite.NoError(json.NewDecoder(zr).Decode(&m))
+	suite.Equal(true, m["puppy"])
+
+	// A body expanding beyond the limit is rejected
+	body.Reset()
+	zw = gzip.NewWriter(&body)
+	zw.Write([]byte(`[{"checkType":"` + strings.Repeat("A", 1<<17) + `"}]`))
+	zw.Close()
+	req, _ = http.NewRequest("POST", "/isgood", &body)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Encoding", "gzip")
+	response = httptest.NewRecorder()
+	handler.ServeHTTP(response, req)
+	suite.Equal(http.StatusRequestEntityTooLarge, response.Code)
+
+	req, _ = http.NewRequest("POST", "/isgood", bytes.NewBuffer(mustJSON(mockRequest())))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Encoding", "br")
+	response = httptest.NewRecorder()
+	handler.ServeHTTP(response, req)
+	suite.Equal(http.StatusUnsupportedMediaType, response.Code)
+}
+
+func (suite *UsdkControllerSuite) TestSignedResponse() {
+	key, _ := signing.GenerateKey("s1")
+	signer, err := signing.NewSigner("s1", []signing.Key{key})
+	suite.Require().NoError(err)
+	verifier := signing.NewVerifier([]signing.Key{key})
+
+	var sessionKeyMap sync.Map
+	usdkController := NewUsdkController(service.NewUsdkService(&sessionKeyMap), WithSigner(signer),
+		WithCompression(compress.Config{Enabled: true, MinSize: 1, MaxDecompressedBytes: 1 << 16}))
+	handler := NewRouter(Routes{Usdk: usdkController, Signing: NewSigningController(signer)})
+
+	// The encoded body is signed whatever the media type, before compression
+	for _, accept := range []string{codec.JSON, codec.CBOR} {
+		mockRequest := mockRequest()
+		req, _ := http.NewRequest("POST", "/isgood", bytes.NewBuffer(mustJSON(mockRequest)))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", accept)
+		req.Header.Set("Accept-Encoding", "gzip")
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, req)
+		suite.Require().Equal(http.StatusOK, response.Code)
+		suite.Equal("gzip", response.Header().Get("Content-Encoding"))
+
+		zr, err := gzip.NewReader(response.Body)
+		suite.Require().NoError(err)
+		var body bytes.Buffer
+		body.ReadFrom(zr)
+		claims, err := verifier.Verify(context.Background(), body.Bytes(), response.Header().Get(signing.Header))
+		suite.Require().NoError(err, accept)
+		suite.True(claims.Puppy)
+		suite.Equal([]string{mockRequest[0].CheckSessionKey}, claims.SessionKeys)
+
+		// A failed check is not a verdict, it is left unsigned
+		req, _ = http.NewRequest("POST", "/isgood", bytes.NewBuffer(mustJSON(mockRequest)))
+		req.Header.Set("Content-Type", "application/json")
+		response = httptest.NewRecorder()
+		handler.ServeHTTP(response, req)
+		suite.Equal(http.StatusBadRequest, response.Code)
+		suite.Empty(response.Header().Get(signing.Header))
+	}
+
+	req, _ := http.NewRequest("GET", "/.well-known/jwks.json", nil)
+	response := httptest.NewRecorder()
+	handler.ServeHTTP(response, req)
+	suite.Equal(http.StatusOK, response.Code)
+	keys, err := signing.ParseJWKS(response.Body.Bytes())
+	suite.NoError(err)
+	suite.Equal([]signing.Key{{ID: "s1", PublicKey: key.PublicKey}}, keys)
+
+	// Unsigned without a signer
+	req, _ = http.NewRequest("POST", "/isgood", bytes.NewBuffer(mustJSON(mockRequest())))
+	req.Header.Set("Content-Type", "application/json")
+	response = httptest.NewRecorder()
+	NewRouter(Routes{Usdk: createUsdkController()}).ServeHTTP(response, req)
+	suite.Equal(http.StatusOK, response.Code)
+	suite.Empty(response.Header().Get(signing.Header))
+}
+
+func (suite *UsdkControllerSuite) TestAuditTrail() {
+
+	auditLogger, _ := audit.NewLogger(audit.NewMemorySink(), nil)
+	var sessionKeyMap sync.Map
+	usdkController := NewUsdkController(service.NewUsdkService(&sessionKeyMap), WithAuditLogger(auditLogger))
+	handler := NewRouter(Routes{Usdk: usdkController})
+
+	mockRequest := mockRequest()
+	sessionKey := mockRequest[0].CheckSessionKey
+	jsonAccount, _ := json.Marshal(mockRequest)
+
+	// Success followed by a duplicate session key failure
+	for i := 0; i < 2; i++ {
+		req, _ := http.NewRequest("POST", "/isgood", bytes.NewBuffer(jsonAccount))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Caller-Id", "test-caller")
+		req.RemoteAddr = "10.1.2.3:40000"
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	records, err := auditLogger.Query(audit.Query{SessionKey: sessionKey})
+	if err != nil {
+		suite.T().Fatalf("audit query failure %s", err)
+	}
+	if len(records) != 2 {
+		suite.T().Fatalf("Expected 2 audit records. Got %d", len(records))
+	}
+	if records[0].Outcome != audit.OutcomePass || records[1].Outcome != audit.OutcomeError {
+		suite.T().Errorf("Expected outcomes pass, error. Got %s, %s", records[0].Outcome, records[1].Outcome)
+	}
+	if records[1].ErrorCode != 3 || len(records[1].Errors) == 0 {
+		suite.T().Errorf("Expected the service error to be audited. Got %#v", records[1])
+	}
+	if records[0].Caller != "test-caller" {
+		suite.T().Errorf("Expected caller 'test-caller'. Got '%s'", records[0].Caller)
+	}
+	if records[0].RemoteAddr != "10.1.2.3" {
+		suite.T().Errorf("Expected remote address '10.1.2.3'. Got '%s'", records[0].RemoteAddr)
+	}
+	if records[0].Checks[0].ActivityData[0].Value == "1.23.45.123" {
+		suite.T().Errorf("Expected KVP value to be hashed in the audit trail")
+	}
+	if err := auditLogger.Verify(); err != nil {
+		suite.T().Errorf("audit chain failure %s", err)
+	}
+}
+
+// fixtures make the session keys of the mock requests, the same on every run
+var fixtures = testutil.New(1)
+
 func mockInvalidRequest() models.DeviceCheckDetailsObjectCollection {
 	deviceCheckDetail1 := &models.DeviceCheckDetailsObject{CheckType: "DEVICE", ActivityType: "SIGNUP", CheckSessionKey: "123654789"}
 	deviceCheckDetail2 := &models.DeviceCheckDetailsObject{CheckType: "DUMMY", ActivityType: "DUMMY"}
@@ -268,88 +537,45 @@ func mockInvalidRequest() models.DeviceCheckDetailsObjectCollection {
 }
 
 func mockSessionKeyRequest() models.DeviceCheckDetailsObjectCollection {
-	rand := strconv.Itoa(util.GenerateRandomInRange(1000000, 20000000))
-	keyValuePairObject := &models.KeyValuePairObject{KvpKey: "ip.address", KvpValue: "1.23.45.123", KvpType: models.EnumKVPType("general.string")}
-	keyValArray := make([]*models.KeyValuePairObject, 0)
-	keyValArray = append(keyValArray, keyValuePairObject)
-
-	deviceCheckDetail1 := &models.DeviceCheckDetailsObject{CheckType: "DEVICE", ActivityType: "SIGNUP", CheckSessionKey: rand, ActivityData: keyValArray}
-	deviceCheckDetail2 := &models.DeviceCheckDetailsObject{CheckType: "DEVICE", ActivityType: "SIGNUP", CheckSessionKey: rand}
-	mockDeviceCheckCollection := &models.DeviceCheckDetailsObjectCollection{deviceCheckDetail1, deviceCheckDetail2}
-	return *mockDeviceCheckCollection
+	key := fixtures.SessionKey()
+	return testutil.Collection(testutil.NewCheck(key).IP(), testutil.NewCheck(key))
 }
 
 func mockDuplicateActivityKeyRequest() models.DeviceCheckDetailsObjectCollection {
-	rand := strconv.Itoa(util.GenerateRandomInRange(1000000, 20000000))
-	rand2 := strconv.Itoa(util.GenerateRandomInRange(3000000, 40000000))
-	keyValuePairObject := &models.KeyValuePairObject{KvpKey: "ip.address", KvpValue: "1.23.45.123", KvpType: models.EnumKVPType("general.string")}
-	keyValArray := make([]*models.KeyValuePairObject, 0)
-	keyValArray = append(keyValArray, keyValuePairObject)
-
-	deviceCheckDetail1 := &models.DeviceCheckDetailsObject{CheckType: "DEVICE", ActivityType: "SIGNUP", CheckSessionKey: rand, ActivityData: keyValArray}
-	deviceCheckDetail2 := &models.DeviceCheckDetailsObject{CheckType: "DEVICE", ActivityType: "SIGNUP", CheckSessionKey: rand2, ActivityData: keyValArray}
-	mockDeviceCheckCollection := &models.DeviceCheckDetailsObjectCollection{deviceCheckDetail1, deviceCheckDetail2}
-	return *mockDeviceCheckCollection
+	// kvpKeys are unique per check by default, so the duplicate has to be within one check
+	return testutil.Collection(fixtures.Check().IP().IP(), fixtures.Check().IP())
 }
 
 func mockActivityKeyWithInvalidDataTypeRequest() models.DeviceCheckDetailsObjectCollection {
-	rand := strconv.Itoa(util.GenerateRandomInRange(1000000, 20000000))
-	rand2 := strconv.Itoa(util.GenerateRandomInRange(3000000, 40000000))
-
-	keyValuePairObject := &models.KeyValuePairObject{KvpKey: "ip.address", KvpValue: "1.23.45.123", KvpType: models.EnumKVPType("general.bool")}
-	keyValuePairObject2 := &models.KeyValuePairObject{KvpKey: "ip.address", KvpValue: "asdfh", KvpType: models.EnumKVPType("general.float")}
-	keyValArray := make([]*models.KeyValuePairObject, 0)
-	keyValArray = append(keyValArray, keyValuePairObject)
-	keyValArray = append(keyValArray, keyValuePairObject2)
-
-	deviceCheckDetail1 := &models.DeviceCheckDetailsObject{CheckType: "DEVICE", ActivityType: "SIGNUP", CheckSessionKey: rand, ActivityData: keyValArray}
-	deviceCheckDetail2 := &models.DeviceCheckDetailsObject{CheckType: "DEVICE", ActivityType: "SIGNUP", CheckSessionKey: rand2, ActivityData: keyValArray}
-	mockDeviceCheckCollection := &models.DeviceCheckDetailsObjectCollection{deviceCheckDetail1, deviceCheckDetail2}
-	return *mockDeviceCheckCollection
+	key, key2 := fixtures.SessionKey(), fixtures.SessionKey()
+	kvps := func(b *testutil.CheckBuilder) *testutil.CheckBuilder {
+		return b.KVP("ip.address", testutil.MockIP, "general.bool").KVP("ip.address", "asdfh", "general.float")
+	}
+	return testutil.Collection(kvps(testutil.NewCheck(key)), kvps(testutil.NewCheck(key2)))
 }
 
 func mockVendorActivityTypeRequest() models.DeviceCheckDetailsObjectCollection {
-	rand := strconv.Itoa(util.GenerateRandomInRange(1000000, 20000000))
-	keyValuePairObject := &models.KeyValuePairObject{KvpKey: "ip.address", KvpValue: "1.23.45.123", KvpType: models.EnumKVPType("general.string")}
-	keyValArray := make([]*models.KeyValuePairObject, 0)
-	keyValArray = append(keyValArray, keyValuePairObject)
-
-	deviceCheckDetail1 := &models.DeviceCheckDetailsObject{CheckType: "DEVICE", ActivityType: "_SIGNUP", CheckSessionKey: rand, ActivityData: keyValArray}
-	mockDeviceCheckCollection := &models.DeviceCheckDetailsObjectCollection{deviceCheckDetail1}
-	return *mockDeviceCheckCollection
+	return testutil.Collection(fixtures.Check().ActivityType("_SIGNUP").IP())
 }
 
 func mockInvalidActivityTypeRequest() models.DeviceCheckDetailsObjectCollection {
-	rand := strconv.Itoa(util.GenerateRandomInRange(1000000, 20000000))
-	keyValuePairObject := &models.KeyValuePairObject{KvpKey: "ip.address", KvpValue: "1.23.45.123", KvpType: models.EnumKVPType("general.string")}
-	keyValArray := make([]*models.KeyValuePairObject, 0)
-	keyValArray = append(keyValArray, keyValuePairObject)
-
-	deviceCheckDetail1 := &models.DeviceCheckDetailsObject{CheckType: "DEVICE", ActivityType: "DUMMY", CheckSessionKey: rand, ActivityData: keyValArray}
-	mockDeviceCheckCollection := &models.DeviceCheckDetailsObjectCollection{deviceCheckDetail1}
-	return *mockDeviceCheckCollection
+	return testutil.Collection(fixtures.Check().ActivityType("DUMMY").IP())
 }
 
 func mockInvalidCheckTypeRequest() models.DeviceCheckDetailsObjectCollection {
-	rand := strconv.Itoa(util.GenerateRandomInRange(1000000, 20000000))
-	keyValuePairObject := &models.KeyValuePairObject{KvpKey: "ip.address", KvpValue: "1.23.45.123", KvpType: models.EnumKVPType("general.string")}
-	keyValArray := make([]*models.KeyValuePairObject, 0)
-	keyValArray = append(keyValArray, keyValuePairObject)
-
-	deviceCheckDetail1 := &models.DeviceCheckDetailsObject{CheckType: "DUMMY", ActivityType: "SIGNUP", CheckSessionKey: rand, ActivityData: keyValArray}
-	mockDeviceCheckCollection := &models.DeviceCheckDetailsObjectCollection{deviceCheckDetail1}
-	return *mockDeviceCheckCollection
+	return testutil.Collection(fixtures.Check().CheckType("DUMMY").IP())
 }
 
 func mockRequest() models.DeviceCheckDetailsObjectCollection {
-	rand := strconv.Itoa(util.GenerateRandomInRange(1000000, 20000000))
-	keyValuePairObject := &models.KeyValuePairObject{KvpKey: "ip.address", KvpValue: "1.23.45.123", KvpType: models.EnumKVPType("general.string")}
-	keyValArray := make([]*models.KeyValuePairObject, 0)
-	keyValArray = append(keyValArray, keyValuePairObject)
+	return testutil.Collection(fixtures.Check().IP())
+}
 
-	deviceCheckDetail1 := &models.DeviceCheckDetailsObject{CheckType: "DEVICE", ActivityType: "SIGNUP", CheckSessionKey: rand, ActivityData: keyValArray}
-	mockDeviceCheckCollection := &models.DeviceCheckDetailsObjectCollection{deviceCheckDetail1}
-	return *mockDeviceCheckCollection
+func mustJSON(v interface{}) []byte {
+	data, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return data
 }
 
 func createUsdkController() *UsdkController {
@@ -0,0 +1,134 @@
+package controller
+
+import (
+	"context"
+	"log"
+	"net"
+	"runtime/debug"
+	"time"
+	"universalsdk/models"
+	"universalsdk/proto/usdkpb"
+	"universalsdk/service"
+	"universalsdk/signing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// UsdkGrpcController adapts the UsdkService to the gRPC DeviceCheckService.
+// It shares validation, auditing and error codes with the REST controller.
+type UsdkGrpcController struct {
+	usdkpb.UnimplementedDeviceCheckServiceServer
+	UsdkController
+}
+
+func NewUsdkGrpcController(service service.UsdkService, opts ...Option) *UsdkGrpcController {
+	return &UsdkGrpcController{UsdkController: *NewUsdkController(service, opts...)}
+}
+
+// DeviceCheck is the gRPC equivalent of the /isgood REST handler
+func (x *UsdkGrpcController) DeviceCheck(ctx context.Context, req *usdkpb.DeviceCheckRequest) (*usdkpb.PuppyObject, error) {
+	start := time.Now()
+	caller, authenticated := x.grpcCaller(ctx)
+	if !authenticated {
+		errorObj := models.ErrorObject{Code: models.ErrorCodeUnauthorized, Message: "missing or invalid x-api-key"}
+		x.audit(caller, start, nil, nil, &errorObj)
+		return nil, errorStatus(errorObj)
+	}
+
+	deviceCheckReq := req.ToCollection()
+	if err := validateRequest(&deviceCheckReq); err != nil {
+		log.Print(err)
+		errorObj := models.ErrorObject{Code: models.ErrorCodeInvalidRequest, Message: err.Error()}
+		x.audit(caller, start, &deviceCheckReq, nil, &errorObj)
+		return nil, errorStatus(errorObj)
+	}
+
+	serviceResp, errorObj := x.deviceCheck(ctx, caller, start, &deviceCheckReq)
+	if errorObj != nil {
+		return nil, errorStatus(*errorObj)
+	}
+	resp := usdkpb.FromPuppy(serviceResp)
+	if claims := x.claims(&deviceCheckReq, serviceResp); claims != nil {
+		if err := x.signTrailer(ctx, resp, *claims); err != nil {
+			log.Printf(" ## Response signing failure %s ##", err)
+			return nil, errorStatus(models.ErrorObject{Code: models.ErrorCodeInternal, Message: "response signing failure"})
+		}
+	}
+	return resp, nil
+}
+
+// signTrailer signs the response in the signing.Trailer, with the same
+// signer and claims as a REST response
+func (x *UsdkGrpcController) signTrailer(ctx context.Context, resp *usdkpb.PuppyObject, claims signing.Claims) error {
+	body, err := usdkpb.SignedBytes(resp)
+	if err != nil {
+		return err
+	}
+	signature, err := x.signer.Sign(body, claims)
+	if err != nil {
+		return err
+	}
+	return grpc.SetTrailer(ctx, metadata.Pairs(signing.Trailer, signature))
+}
+
+// RecoveryInterceptor turns a panic of a unary gRPC call into an Internal
+// status carrying an ErrorObject, logging the stack
+func RecoveryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			log.Printf(" ## Panic serving %s: %v\n%s", info.FullMethod, p, debug.Stack())
+			resp, err = nil, errorStatus(models.ErrorObject{Code: models.ErrorCodeInternal, Message: "internal server error"})
+		}
+	}()
+	return handler(ctx, req)
+}
+
+// errorStatus maps an ErrorObject to a gRPC status carrying the ErrorObject as detail
+func errorStatus(errorObj models.ErrorObject) error {
+	code := codes.Internal
+	switch errorObj.Code {
+	case models.ErrorCodeContentType, models.ErrorCodeInvalidRequest:
+		code = codes.InvalidArgument
+	case models.ErrorCodeCheckFailed:
+		code = codes.FailedPrecondition
+	case models.ErrorCodeUnauthorized:
+		code = codes.Unauthenticated
+	case models.ErrorCodeNotFound:
+		code = codes.NotFound
+	case models.ErrorCodeTimeout:
+		code = codes.DeadlineExceeded
+	}
+
+	st := status.New(code, errorObj.Message)
+	if detailed, err := st.WithDetails(usdkpb.FromErrorObject(errorObj)); err == nil {
+		st = detailed
+	}
+	return st.Err()
+}
+
+// grpcCallerIdentity is the gRPC counterpart of util.CallerIdentity.
+// The x-caller-id metadata is preferred, falling back to the peer host.
+func grpcCallerIdentity(ctx context.Context) string {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if callers := md.Get("x-caller-id"); len(callers) > 0 && callers[0] != "" {
+			return callers[0]
+		}
+	}
+	return grpcRemoteHost(ctx)
+}
+
+// grpcRemoteHost is the gRPC counterpart of util.RemoteHost
+func grpcRemoteHost(ctx context.Context) string {
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		host, _, err := net.SplitHostPort(p.Addr.String())
+		if err != nil {
+			return p.Addr.String()
+		}
+		return host
+	}
+	return ""
+}
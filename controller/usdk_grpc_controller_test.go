@@ -0,0 +1,160 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"net"
+	"os"
+	"sync"
+	"testing"
+	"universalsdk/models"
+	"universalsdk/proto/usdkpb"
+	"universalsdk/service"
+	"universalsdk/signing"
+
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type UsdkGrpcControllerSuite struct {
+	suite.Suite
+	server *grpc.Server
+	conn   *grpc.ClientConn
+	client usdkpb.DeviceCheckServiceClient
+}
+
+func TestUsdkGrpcControllerSuite(t *testing.T) {
+	suite.Run(t, new(UsdkGrpcControllerSuite))
+}
+
+func (suite *UsdkGrpcControllerSuite) SetupTest() {
+	suite.start()
+}
+
+// start serves a controller with the options over an in-memory listener
+func (suite *UsdkGrpcControllerSuite) start(opts ...Option) {
+	var sessionKeyMap sync.Map
+	listener := bufconn.Listen(1024 * 1024)
+
+	suite.server = grpc.NewServer(grpc.ChainUnaryInterceptor(LoggingInterceptor, RecoveryInterceptor))
+	usdkpb.RegisterDeviceCheckServiceServer(suite.server, NewUsdkGrpcController(service.NewUsdkService(&sessionKeyMap), opts...))
+	go suite.server.Serve(listener)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	suite.Require().NoError(err)
+	suite.conn = conn
+	suite.client = usdkpb.NewDeviceCheckServiceClient(conn)
+}
+
+func (suite *UsdkGrpcControllerSuite) TearDownTest() {
+	suite.conn.Close()
+	suite.server.Stop()
+}
+
+func (suite *UsdkGrpcControllerSuite) TestSuccessRequest() {
+	resp, err := suite.client.DeviceCheck(context.Background(), usdkpb.FromCollection(mockRequest()))
+	suite.Require().NoError(err)
+	suite.True(resp.GetPuppy())
+}
+
+func (suite *UsdkGrpcControllerSuite) TestInvalidRequest() {
+	_, err := suite.client.DeviceCheck(context.Background(), usdkpb.FromCollection(mockInvalidCheckTypeRequest()))
+	suite.checkStatus(err, codes.InvalidArgument, models.ErrorCodeInvalidRequest)
+
+	_, err = suite.client.DeviceCheck(context.Background(), &usdkpb.DeviceCheckRequest{})
+	suite.checkStatus(err, codes.InvalidArgument, models.ErrorCodeInvalidRequest)
+}
+
+func (suite *UsdkGrpcControllerSuite) TestSameSessionKey() {
+	req := usdkpb.FromCollection(mockRequest())
+	_, err := suite.client.DeviceCheck(context.Background(), req)
+	suite.Require().NoError(err)
+
+	_, err = suite.client.DeviceCheck(context.Background(), req)
+	suite.checkStatus(err, codes.FailedPrecondition, models.ErrorCodeCheckFailed)
+}
+
+func (suite *UsdkGrpcControllerSuite) TestSignedResponse() {
+	key, _ := signing.GenerateKey("s1")
+	signer, err := signing.NewSigner("s1", []signing.Key{key})
+	suite.Require().NoError(err)
+	suite.TearDownTest()
+	suite.start(WithSigner(signer))
+
+	req := usdkpb.FromCollection(mockRequest())
+	var trailer metadata.MD
+	resp, err := suite.client.DeviceCheck(context.Background(), req, grpc.Trailer(&trailer))
+	suite.Require().NoError(err)
+	suite.Require().Len(trailer.Get(signing.Trailer), 1)
+
+	body, err := usdkpb.SignedBytes(resp)
+	suite.Require().NoError(err)
+	claims, err := signing.NewVerifier([]signing.Key{key}).Verify(context.Background(), body, trailer.Get(signing.Trailer)[0])
+	suite.Require().NoError(err)
+	suite.True(claims.Puppy)
+	suite.True(claims.HasSessionKey(req.GetChecks()[0].GetCheckSessionKey()))
+
+	// The signature covers the response, not any other
+	resp.Puppy = !resp.Puppy
+	body, _ = usdkpb.SignedBytes(resp)
+	_, err = signing.NewVerifier([]signing.Key{key}).Verify(context.Background(), body, trailer.Get(signing.Trailer)[0])
+	suite.ErrorIs(err, signing.ErrInvalidSignature)
+}
+
+func (suite *UsdkGrpcControllerSuite) TestCallerKeys() {
+	var sessionKeyMap sync.Map
+	x := NewUsdkGrpcController(service.NewUsdkService(&sessionKeyMap), WithCallerKeys(map[string]string{"qa": "qa-key"}))
+
+	_, err := x.DeviceCheck(metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-caller-id", "qa")), usdkpb.FromCollection(mockRequest()))
+	suite.checkStatus(err, codes.Unauthenticated, models.ErrorCodeUnauthorized)
+
+	resp, err := x.DeviceCheck(metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-api-key", "qa-key")), usdkpb.FromCollection(mockRequest()))
+	suite.Require().NoError(err)
+	suite.True(resp.GetPuppy())
+}
+
+func (suite *UsdkGrpcControllerSuite) TestLoggingInterceptor() {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-caller-id", "qa", "x-request-id", "req-1"))
+	info := &grpc.UnaryServerInfo{FullMethod: "/usdk.v1.DeviceCheckService/DeviceCheck"}
+	ok := func(ctx context.Context, req interface{}) (interface{}, error) { return nil, nil }
+	LoggingInterceptor(ctx, nil, info, ok)
+
+	// The same line as the REST access log
+	suite.Regexp(`## grpc /usdk.v1.DeviceCheckService/DeviceCheck caller=qa result=OK duration=\S+ request=req-1\n$`, buf.String())
+}
+
+func (suite *UsdkGrpcControllerSuite) TestRecoveryInterceptor() {
+	info := &grpc.UnaryServerInfo{FullMethod: "/usdk.v1.DeviceCheckService/DeviceCheck"}
+	panicking := func(ctx context.Context, req interface{}) (interface{}, error) { panic("boom") }
+
+	resp, err := RecoveryInterceptor(context.Background(), nil, info, panicking)
+	suite.Nil(resp)
+	suite.checkStatus(err, codes.Internal, models.ErrorCodeInternal)
+}
+
+// checkStatus asserts the gRPC code and the ErrorObject detail of err
+func (suite *UsdkGrpcControllerSuite) checkStatus(err error, code codes.Code, errorCode int64) {
+	suite.Require().Error(err)
+	st := status.Convert(err)
+	suite.Equal(code, st.Code())
+	suite.NotEmpty(st.Message())
+
+	details := st.Details()
+	suite.Require().Len(details, 1)
+	errorObj, ok := details[0].(*usdkpb.ErrorObject)
+	suite.Require().True(ok, "expected ErrorObject detail, got %T", details[0])
+	suite.Equal(errorCode, errorObj.GetCode())
+	suite.Equal(st.Message(), errorObj.GetMessage())
+}
@@ -0,0 +1,25 @@
+package envelope
+
+import (
+	"fmt"
+	"universalsdk/config"
+)
+
+// NewEncryptorFromConfig creates an Encryptor with the configured key
+// provider, or returns nil when encryption is not configured
+func NewEncryptorFromConfig(cfg config.EncryptionConfig) (*Encryptor, error) {
+	if cfg.KeyFile == "" {
+		return nil, nil
+	}
+
+	switch cfg.Provider {
+	case "", "local":
+		provider, err := NewLocalKeyProvider(cfg.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		return NewEncryptor(provider), nil
+	default:
+		return nil, fmt.Errorf("encryption provider %s invalid, valid providers are local", cfg.Provider)
+	}
+}
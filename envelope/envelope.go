@@ -0,0 +1,195 @@
+// Package envelope encrypts sensitive values field by field before they
+// leave process memory. Every value is encrypted with its own data key,
+// which is itself encrypted (wrapped) with a key encryption key from a
+// KeyProvider, so rotating the key encryption key only needs the data keys
+// to be rewrapped. Searchable fields use deterministic tokens instead.
+package envelope
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+const (
+	ciphertextPrefix = "enc:v1:"
+	tokenPrefix      = "tok:v1:"
+)
+
+var b64 = base64.RawURLEncoding
+
+// Encryptor encrypts values and derives tokens with the keys of a KeyProvider
+type Encryptor struct {
+	provider KeyProvider
+}
+
+func NewEncryptor(provider KeyProvider) *Encryptor {
+	return &Encryptor{provider: provider}
+}
+
+// Encrypt returns the envelope of plaintext as
+// "enc:v1:<key id>:<wrapped data key>:<ciphertext>". The field, e.g. the
+// kvpKey, is authenticated so the envelope can't be moved to another field.
+func (e *Encryptor) Encrypt(field, plaintext string) (string, error) {
+	kek, err := e.provider.CurrentKey()
+	if err != nil {
+		return "", err
+	}
+
+	dek := make([]byte, KeySize)
+	if _, err := rand.Read(dek); err != nil {
+		return "", err
+	}
+	wrapped, err := seal(kek.Material, dek, []byte(kek.ID))
+	if err != nil {
+		return "", err
+	}
+	ciphertext, err := seal(dek, []byte(plaintext), []byte(field))
+	if err != nil {
+		return "", err
+	}
+	return ciphertextPrefix + kek.ID + ":" + b64.EncodeToString(wrapped) + ":" + b64.EncodeToString(ciphertext), nil
+}
+
+// Decrypt returns the plaintext of an envelope written by Encrypt for the same field
+func (e *Encryptor) Decrypt(field, envelope string) (string, error) {
+	kek, wrapped, ciphertext, err := e.parse(envelope)
+	if err != nil {
+		return "", err
+	}
+	dek, err := open(kek.Material, wrapped, []byte(kek.ID))
+	if err != nil {
+		return "", fmt.Errorf("envelope: invalid data key: %v", err)
+	}
+	plaintext, err := open(dek, ciphertext, []byte(field))
+	if err != nil {
+		return "", fmt.Errorf("envelope: invalid ciphertext: %v", err)
+	}
+	return string(plaintext), nil
+}
+
+// Rewrap wraps the data key of an envelope with the current key, leaving
+// the ciphertext untouched. Envelopes already using the current key are
+// returned as is.
+func (e *Encryptor) Rewrap(envelope string) (string, error) {
+	kek, wrapped, ciphertext, err := e.parse(envelope)
+	if err != nil {
+		return "", err
+	}
+	current, err := e.provider.CurrentKey()
+	if err != nil {
+		return "", err
+	}
+	if kek.ID == current.ID {
+		return envelope, nil
+	}
+
+	dek, err := open(kek.Material, wrapped, []byte(kek.ID))
+	if err != nil {
+		return "", fmt.Errorf("envelope: invalid data key: %v", err)
+	}
+	if wrapped, err = seal(current.Material, dek, []byte(current.ID)); err != nil {
+		return "", err
+	}
+	return ciphertextPrefix + current.ID + ":" + b64.EncodeToString(wrapped) + ":" + b64.EncodeToString(ciphertext), nil
+}
+
+// Token returns a deterministic token of the value of a field, so equal
+// values can be searched for without storing them. Tokens depend on the
+// key, use Tokens to search values tokenised before a rotation.
+func (e *Encryptor) Token(field, value string) (string, error) {
+	kek, err := e.provider.CurrentKey()
+	if err != nil {
+		return "", err
+	}
+	return token(kek, field, value), nil
+}
+
+// Tokens returns the token of the value with every key, current one first
+func (e *Encryptor) Tokens(field, value string) ([]string, error) {
+	keys, err := e.provider.Keys()
+	if err != nil {
+		return nil, err
+	}
+	tokens := make([]string, 0, len(keys))
+	for _, kek := range keys {
+		tokens = append(tokens, token(kek, field, value))
+	}
+	return tokens, nil
+}
+
+// IsEnvelope reports whether s was written by Encrypt
+func IsEnvelope(s string) bool {
+	return strings.HasPrefix(s, ciphertextPrefix)
+}
+
+func (e *Encryptor) parse(envelope string) (Key, []byte, []byte, error) {
+	parts := strings.Split(strings.TrimPrefix(envelope, ciphertextPrefix), ":")
+	if !IsEnvelope(envelope) || len(parts) != 3 {
+		return Key{}, nil, nil, fmt.Errorf("envelope: invalid format")
+	}
+	kek, err := e.provider.Key(parts[0])
+	if err != nil {
+		return Key{}, nil, nil, err
+	}
+	wrapped, err := b64.DecodeString(parts[1])
+	if err != nil {
+		return Key{}, nil, nil, fmt.Errorf("envelope: invalid data key encoding")
+	}
+	ciphertext, err := b64.DecodeString(parts[2])
+	if err != nil {
+		return Key{}, nil, nil, fmt.Errorf("envelope: invalid ciphertext encoding")
+	}
+	return kek, wrapped, ciphertext, nil
+}
+
+// token is an HMAC of the field and value with a token key derived from the
+// key encryption key, so tokens can't be used to recover data keys
+func token(kek Key, field, value string) string {
+	derive := hmac.New(sha256.New, kek.Material)
+	derive.Write([]byte("usdk token key"))
+
+	mac := hmac.New(sha256.New, derive.Sum(nil))
+	mac.Write([]byte(field))
+	mac.Write([]byte{0})
+	mac.Write([]byte(value))
+	return tokenPrefix + kek.ID + ":" + b64.EncodeToString(mac.Sum(nil))
+}
+
+// seal encrypts with AES-GCM, prefixing the random nonce
+func seal(key, plaintext, additionalData []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+func open(key, sealed, additionalData []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, fmt.Errorf("too short")
+	}
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ciphertext, additionalData)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,122 @@
+package envelope
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type EnvelopeSuite struct {
+	suite.Suite
+	keyFile   string
+	provider  *LocalKeyProvider
+	encryptor *Encryptor
+}
+
+func TestEnvelopeSuite(t *testing.T) {
+	suite.Run(t, new(EnvelopeSuite))
+}
+
+func (suite *EnvelopeSuite) SetupTest() {
+	suite.keyFile = filepath.Join(suite.T().TempDir(), "keys.json")
+	_, err := RotateKeyFile(suite.keyFile)
+	suite.Require().NoError(err)
+	suite.provider, err = NewLocalKeyProvider(suite.keyFile)
+	suite.Require().NoError(err)
+	suite.encryptor = NewEncryptor(suite.provider)
+}
+
+func (suite *EnvelopeSuite) TestRoundTrip() {
+	sealed, err := suite.encryptor.Encrypt("pii.name", "Jane Citizen")
+	suite.Require().NoError(err)
+	suite.True(IsEnvelope(sealed))
+	suite.NotContains(sealed, "Jane")
+
+	plaintext, err := suite.encryptor.Decrypt("pii.name", sealed)
+	suite.NoError(err)
+	suite.Equal("Jane Citizen", plaintext)
+
+	// Every value gets its own data key and nonce
+	again, _ := suite.encryptor.Encrypt("pii.name", "Jane Citizen")
+	suite.NotEqual(sealed, again)
+}
+
+func (suite *EnvelopeSuite) TestTampering() {
+	sealed, err := suite.encryptor.Encrypt("pii.name", "Jane Citizen")
+	suite.Require().NoError(err)
+
+	// The envelope is bound to its field
+	_, err = suite.encryptor.Decrypt("pii.address", sealed)
+	suite.Error(err)
+
+	_, err = suite.encryptor.Decrypt("pii.name", sealed[:len(sealed)-2]+"AA")
+	suite.Error(err)
+
+	_, err = suite.encryptor.Decrypt("pii.name", "Jane Citizen")
+	suite.Error(err)
+
+	parts := strings.SplitN(sealed, ":", 4)
+	_, err = suite.encryptor.Decrypt("pii.name", "enc:v1:unknown:"+parts[3])
+	suite.ErrorIs(err, ErrUnknownKey)
+}
+
+func (suite *EnvelopeSuite) TestRotation() {
+	old, _ := suite.provider.CurrentKey()
+	sealed, err := suite.encryptor.Encrypt("pii.email", "jane@example.com")
+	suite.Require().NoError(err)
+
+	current, err := RotateKeyFile(suite.keyFile)
+	suite.Require().NoError(err)
+	suite.Require().NoError(suite.provider.Reload())
+
+	// Values encrypted with the old key still decrypt
+	plaintext, err := suite.encryptor.Decrypt("pii.email", sealed)
+	suite.NoError(err)
+	suite.Equal("jane@example.com", plaintext)
+
+	rewrapped, err := suite.encryptor.Rewrap(sealed)
+	suite.Require().NoError(err)
+	suite.True(strings.HasPrefix(rewrapped, ciphertextPrefix+current+":"))
+	suite.False(strings.HasPrefix(sealed, ciphertextPrefix+current+":"))
+	suite.True(strings.HasPrefix(sealed, ciphertextPrefix+old.ID+":"))
+
+	plaintext, err = suite.encryptor.Decrypt("pii.email", rewrapped)
+	suite.NoError(err)
+	suite.Equal("jane@example.com", plaintext)
+
+	unchanged, err := suite.encryptor.Rewrap(rewrapped)
+	suite.NoError(err)
+	suite.Equal(rewrapped, unchanged)
+}
+
+func (suite *EnvelopeSuite) TestTokens() {
+	token, err := suite.encryptor.Token("id.external", "A-123")
+	suite.Require().NoError(err)
+	suite.True(strings.HasPrefix(token, tokenPrefix))
+
+	// Deterministic per field and value
+	same, _ := suite.encryptor.Token("id.external", "A-123")
+	other, _ := suite.encryptor.Token("id.external", "A-124")
+	field, _ := suite.encryptor.Token("pii.name", "A-123")
+	suite.Equal(token, same)
+	suite.NotEqual(token, other)
+	suite.NotEqual(token, field)
+
+	// Tokens of the previous key can still be searched for after a rotation
+	_, err = RotateKeyFile(suite.keyFile)
+	suite.Require().NoError(err)
+	suite.Require().NoError(suite.provider.Reload())
+
+	rotated, _ := suite.encryptor.Token("id.external", "A-123")
+	suite.NotEqual(token, rotated)
+	tokens, err := suite.encryptor.Tokens("id.external", "A-123")
+	suite.NoError(err)
+	suite.Equal([]string{rotated, token}, tokens)
+}
+
+func (suite *EnvelopeSuite) TestInvalidKeyFile() {
+	_, err := NewLocalKeyProvider(filepath.Join(suite.T().TempDir(), "missing.json"))
+	suite.Error(err)
+}
@@ -0,0 +1,173 @@
+package envelope
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// KeySize is the size in bytes of the key encryption keys (AES-256)
+const KeySize = 32
+
+// ErrUnknownKey is returned when a value was encrypted with a key the provider doesn't have
+var ErrUnknownKey = errors.New("unknown key")
+
+// Key is a key encryption key
+type Key struct {
+	ID       string
+	Material []byte
+}
+
+// KeyProvider supplies the key encryption keys. New values are encrypted
+// with the current key, older keys are kept to decrypt existing values.
+type KeyProvider interface {
+	CurrentKey() (Key, error)
+	Key(id string) (Key, error)
+
+	// Keys returns every key, current one first
+	Keys() ([]Key, error)
+}
+
+// keyFile is the JSON layout of a local key file
+type keyFile struct {
+	Current string            `json:"current"`
+	Keys    map[string]string `json:"keys"`
+}
+
+// LocalKeyProvider reads the keys from a JSON key file holding the id of the
+// current key and every key base64 encoded:
+//
+//	{"current": "k2", "keys": {"k1": "...", "k2": "..."}}
+type LocalKeyProvider struct {
+	mu      sync.RWMutex
+	path    string
+	current string
+	keys    map[string][]byte
+}
+
+// NewLocalKeyProvider loads the key file at path
+func NewLocalKeyProvider(path string) (*LocalKeyProvider, error) {
+	p := &LocalKeyProvider{path: path}
+	if err := p.Reload(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Reload reads the key file again, picking up a rotated key
+func (p *LocalKeyProvider) Reload() error {
+	kf, err := readKeyFile(p.path)
+	if err != nil {
+		return err
+	}
+
+	keys := make(map[string][]byte, len(kf.Keys))
+	for id, encoded := range kf.Keys {
+		if id == "" || strings.Contains(id, ":") {
+			return fmt.Errorf("key file %s: key id %q should be non empty without a colon", p.path, id)
+		}
+		material, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil || len(material) != KeySize {
+			return fmt.Errorf("key file %s: key %s should be %d base64 encoded bytes", p.path, id, KeySize)
+		}
+		keys[id] = material
+	}
+	if _, ok := keys[kf.Current]; !ok {
+		return fmt.Errorf("key file %s: current key %q is missing", p.path, kf.Current)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.current = kf.Current
+	p.keys = keys
+	return nil
+}
+
+func (p *LocalKeyProvider) CurrentKey() (Key, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return Key{ID: p.current, Material: p.keys[p.current]}, nil
+}
+
+func (p *LocalKeyProvider) Key(id string) (Key, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	material, ok := p.keys[id]
+	if !ok {
+		return Key{}, fmt.Errorf("%w %s", ErrUnknownKey, id)
+	}
+	return Key{ID: id, Material: material}, nil
+}
+
+func (p *LocalKeyProvider) Keys() ([]Key, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	keys := []Key{{ID: p.current, Material: p.keys[p.current]}}
+	ids := make([]string, 0, len(p.keys))
+	for id := range p.keys {
+		if id != p.current {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		keys = append(keys, Key{ID: id, Material: p.keys[id]})
+	}
+	return keys, nil
+}
+
+// RotateKeyFile adds a new random key to the key file at path, creating the
+// file if needed, and makes it the current key. Existing keys are kept so
+// values encrypted with them can still be decrypted. It returns the new key id.
+func RotateKeyFile(path string) (string, error) {
+	kf, err := readKeyFile(path)
+	if os.IsNotExist(err) {
+		kf, err = &keyFile{Keys: make(map[string]string)}, nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	material := make([]byte, KeySize)
+	if _, err := rand.Read(material); err != nil {
+		return "", err
+	}
+	id := "k" + time.Now().UTC().Format("20060102T150405.000000000Z")
+	kf.Keys[id] = base64.StdEncoding.EncodeToString(material)
+	kf.Current = id
+
+	data, err := json.MarshalIndent(kf, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	// written aside and renamed so a crash never leaves a truncated key file
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, append(data, '\n'), 0600); err != nil {
+		return "", err
+	}
+	return id, os.Rename(tmp, path)
+}
+
+func readKeyFile(path string) (*keyFile, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	kf := &keyFile{}
+	if err := json.Unmarshal(data, kf); err != nil {
+		return nil, fmt.Errorf("key file %s: %v", path, err)
+	}
+	if kf.Keys == nil {
+		kf.Keys = make(map[string]string)
+	}
+	return kf, nil
+}
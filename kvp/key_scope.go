@@ -0,0 +1,108 @@
+// Package kvp holds the rules of the activity data of a device check: the
+// canonical form of each kvpType and the uniqueness of kvpKeys. It only
+// depends on the models, so the service and its clients validate requests
+// alike.
+package kvp
+
+import (
+	"fmt"
+	"universalsdk/models"
+)
+
+// KeyScope is the part of a request within which every kvpKey must be unique
+type KeyScope string
+
+const (
+	// KeyScopeElement requires kvpKeys to be unique within each check
+	KeyScopeElement KeyScope = "element"
+
+	// KeyScopeCollection requires kvpKeys to be unique across all the checks of a request
+	KeyScopeCollection KeyScope = "collection"
+
+	// KeyScopeSession requires kvpKeys to be unique across the checks of a request sharing a checkSessionKey
+	KeyScopeSession KeyScope = "session"
+)
+
+// DefaultKeyScope lets checks of a batch request use the same kvpKeys
+const DefaultKeyScope = KeyScopeElement
+
+// ParseKeyScope returns the KeyScope named s, the default scope when s is empty
+func ParseKeyScope(s string) (KeyScope, error) {
+	switch scope := KeyScope(s); scope {
+	case "":
+		return DefaultKeyScope, nil
+	case KeyScopeElement, KeyScopeCollection, KeyScopeSession:
+		return scope, nil
+	default:
+		return "", fmt.Errorf("unknown key scope %s, valid scopes are %s, %s, %s", s, KeyScopeElement, KeyScopeCollection, KeyScopeSession)
+	}
+}
+
+// KeyTracker records the kvpKeys of a request to detect duplicates within a KeyScope
+type KeyTracker struct {
+	scope KeyScope
+
+	// kvpKeys of the current check or of the whole request, the map is
+	// cleared rather than replaced when the next check starts
+	check *models.DeviceCheckDetailsObject
+	keys  map[string]struct{}
+
+	// kvpKeys per checkSessionKey, for KeyScopeSession
+	sessions map[string]map[string]struct{}
+}
+
+// NewKeyTracker returns a KeyTracker for one request
+func NewKeyTracker(scope KeyScope) *KeyTracker {
+	return &KeyTracker{scope: scope}
+}
+
+// Add records the kvpKey of a check and returns an error if the key was already seen within the scope.
+// The kvpKeys of a check are added together, before those of the next check.
+func (t *KeyTracker) Add(dCheckDetailsObject *models.DeviceCheckDetailsObject, kvpKey string) error {
+	keys := t.group(dCheckDetailsObject)
+	if _, ok := keys[kvpKey]; ok {
+		return fmt.Errorf("KvpKey %s is not unique %s", kvpKey, t.describe(dCheckDetailsObject))
+	}
+	keys[kvpKey] = struct{}{}
+	return nil
+}
+
+// group returns the kvpKeys seen so far within the scope of the check
+func (t *KeyTracker) group(dCheckDetailsObject *models.DeviceCheckDetailsObject) map[string]struct{} {
+	switch t.scope {
+	case KeyScopeSession:
+		if t.sessions == nil {
+			t.sessions = make(map[string]map[string]struct{})
+		}
+		keys, ok := t.sessions[dCheckDetailsObject.CheckSessionKey]
+		if !ok {
+			keys = make(map[string]struct{})
+			t.sessions[dCheckDetailsObject.CheckSessionKey] = keys
+		}
+		return keys
+	case KeyScopeCollection:
+		if t.keys == nil {
+			t.keys = make(map[string]struct{})
+		}
+		return t.keys
+	default:
+		if t.keys == nil {
+			t.keys = make(map[string]struct{}, len(dCheckDetailsObject.ActivityData))
+		} else if t.check != dCheckDetailsObject {
+			clear(t.keys)
+		}
+		t.check = dCheckDetailsObject
+		return t.keys
+	}
+}
+
+func (t *KeyTracker) describe(dCheckDetailsObject *models.DeviceCheckDetailsObject) string {
+	switch t.scope {
+	case KeyScopeCollection:
+		return "within the request"
+	case KeyScopeSession:
+		return fmt.Sprintf("within checkSessionKey %q", dCheckDetailsObject.CheckSessionKey)
+	default:
+		return "within the check"
+	}
+}
@@ -0,0 +1,97 @@
+package kvp
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"universalsdk/models"
+)
+
+// Normalise parses value as dataType and returns its canonical form:
+//
+//   - general.integer: base 10 without sign or leading zeros, "007" and "+7" become "7"
+//   - general.float: shortest decimal form, "1e3" becomes "1000" and "1.50" becomes "1.5"
+//   - general.bool: "true" or "false", "TRUE", "t" and "1" become "true"
+//   - general.string: unchanged
+//
+// Values already in their canonical form are returned as is, without allocating.
+func Normalise(value string, dataType models.EnumKVPType) (string, error) {
+	var buf [32]byte
+	canonical, err := appendCanonical(buf[:0], value, dataType)
+	if err != nil {
+		return "", err
+	}
+	if canonical == nil || string(canonical) == value {
+		return value, nil
+	}
+	return string(canonical), nil
+}
+
+// appendCanonical appends the canonical form of value to dst, it returns
+// nil for the data types whose values are always canonical
+func appendCanonical(dst []byte, value string, dataType models.EnumKVPType) ([]byte, error) {
+	switch dataType {
+	case "general.integer":
+		i, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, invalidValue(dataType, err)
+		}
+		return strconv.AppendInt(dst, i, 10), nil
+	case "general.float":
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, invalidValue(dataType, err)
+		}
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return nil, fmt.Errorf("kvpValue is not a finite number")
+		}
+		return appendFloat(dst, f), nil
+	case "general.bool":
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, invalidValue(dataType, err)
+		}
+		return strconv.AppendBool(dst, b), nil
+	case "general.string", "id.external", "pii.name", "pii.address", "pii.email", "pii.phone", "pii.date":
+		return nil, nil
+	case "raw.json", "raw.xml", "raw.base64":
+		// raw values are base64 encoded so they don't interfere with the JSON structure
+		if _, err := base64.StdEncoding.DecodeString(value); err != nil {
+			return nil, fmt.Errorf("kvpValue of %s should be base64 encoded", dataType)
+		}
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("data type %s invalid", dataType)
+	}
+}
+
+// invalidValue reports a value that doesn't parse as dataType without the
+// value itself, which strconv errors quote: error messages are logged and
+// audited, activity data values must not be.
+func invalidValue(dataType models.EnumKVPType, err error) error {
+	if errors.Is(err, strconv.ErrRange) {
+		return fmt.Errorf("kvpValue is out of range for %s", dataType)
+	}
+	return fmt.Errorf("kvpValue is not a valid %s", dataType)
+}
+
+// appendFloat formats f like encoding/json does: plain decimal notation
+// unless the exponent is very small or very large.
+func appendFloat(dst []byte, f float64) []byte {
+	if f == 0 {
+		return append(dst, '0')
+	}
+	abs := math.Abs(f)
+	if abs < 1e-6 || abs >= 1e21 {
+		return strconv.AppendFloat(dst, f, 'e', -1, 64)
+	}
+	return strconv.AppendFloat(dst, f, 'f', -1, 64)
+}
+
+// ValidateDataType validates that value is of the EnumKVPType dataType
+func ValidateDataType(value string, dataType models.EnumKVPType) error {
+	_, err := Normalise(value, dataType)
+	return err
+}
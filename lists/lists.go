@@ -0,0 +1,411 @@
+// Package lists keeps the allow and deny lists operators use to force the
+// outcome of checks whose activity data matches a known value.
+package lists
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-openapi/strfmt"
+	"universalsdk/envelope"
+	"universalsdk/models"
+)
+
+// ErrNotFound is returned for an unknown entry id
+var ErrNotFound = errors.New("list entry not found")
+
+// sweepInterval is how often the expired entries are removed at most
+const sweepInterval = time.Minute
+
+// entry is a list entry with its compiled matcher
+type entry struct {
+	obj   *models.ListEntryObject
+	match func(value string) bool
+}
+
+func (e *entry) expired(now time.Time) bool {
+	return e.obj.ExpiresAt != nil && !time.Time(*e.obj.ExpiresAt).After(now)
+}
+
+// Store keeps the list entries in memory. It is safe for concurrent use.
+type Store struct {
+	mu        sync.RWMutex
+	keys      map[string]bool
+	callers   map[string]bool
+	encryptor *envelope.Encryptor
+	entries   map[string]*entry
+	now       func() time.Time
+
+	// When the expired entries were last removed
+	sweptAt time.Time
+}
+
+// Option configures a Store
+type Option func(*Store)
+
+// WithCallers allows entries scoped to one of the given callers. These are
+// the callers authenticated by their API key, without any an entry scoped
+// to a caller is refused: a declared caller identity can't be trusted.
+func WithCallers(callers []string) Option {
+	return func(s *Store) {
+		for _, caller := range callers {
+			s.callers[caller] = true
+		}
+	}
+}
+
+// WithEncryptor envelope encrypts the values of exported entries, which
+// Import decrypts, so list files don't hold activity data in plain text
+func WithEncryptor(encryptor *envelope.Encryptor) Option {
+	return func(s *Store) {
+		s.encryptor = encryptor
+	}
+}
+
+// WithClock replaces time.Now as the time entries are created at and expire by
+func WithClock(now func() time.Time) Option {
+	return func(s *Store) {
+		s.now = now
+	}
+}
+
+// NewStore returns an empty Store whose entries may match the given kvpKeys
+func NewStore(keys []string, opts ...Option) *Store {
+	s := &Store{keys: make(map[string]bool), callers: make(map[string]bool), entries: make(map[string]*entry), now: time.Now}
+	for _, key := range keys {
+		s.keys[key] = true
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Add validates the entry, gives it an id and adds it to its list
+func (s *Store) Add(obj *models.ListEntryObject) (*models.ListEntryObject, error) {
+	id, err := newID()
+	if err != nil {
+		return nil, err
+	}
+	return s.put(id, obj, s.now())
+}
+
+// Update replaces the entry with the given id, keeping its creation time
+func (s *Store) Update(id string, obj *models.ListEntryObject) (*models.ListEntryObject, error) {
+	existing, ok := s.Get(id)
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return s.put(id, obj, time.Time(existing.CreatedAt))
+}
+
+func (s *Store) put(id string, obj *models.ListEntryObject, createdAt time.Time) (*models.ListEntryObject, error) {
+	e, err := s.compile(id, obj, createdAt)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[id] = e
+	return copyEntry(e.obj), nil
+}
+
+// Get returns the entry with the given id, false once it expired
+func (s *Store) Get(id string) (*models.ListEntryObject, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	e, ok := s.entries[id]
+	if !ok || e.expired(s.now()) {
+		return nil, false
+	}
+	return copyEntry(e.obj), true
+}
+
+// Delete removes the entry with the given id
+func (s *Store) Delete(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.entries[id]; !ok {
+		return false
+	}
+	delete(s.entries, id)
+	return true
+}
+
+// List returns the entries that have not expired, oldest first
+func (s *Store) List() []*models.ListEntryObject {
+	now := s.now()
+	s.sweep(now)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	res := make([]*models.ListEntryObject, 0, len(s.entries))
+	for _, e := range s.entries {
+		if !e.expired(now) {
+			res = append(res, copyEntry(e.obj))
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		ti, tj := time.Time(res[i].CreatedAt), time.Time(res[j].CreatedAt)
+		if ti.Equal(tj) {
+			return res[i].ID < res[j].ID
+		}
+		return ti.Before(tj)
+	})
+	return res
+}
+
+// Match returns the entry matching the activity data of a check from
+// caller. Deny entries take precedence over allow entries, and older
+// entries over newer ones.
+func (s *Store) Match(caller string, check *models.DeviceCheckDetailsObject) (*models.ListEntryObject, bool) {
+	now := s.now()
+	s.sweep(now)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var denied, allowed *models.ListEntryObject
+	for _, e := range s.entries {
+		if e.expired(now) || (e.obj.Caller != "" && e.obj.Caller != caller) || !e.matches(check) {
+			continue
+		}
+		if e.obj.List == models.ListEntryObjectListDeny {
+			denied = older(denied, e.obj)
+		} else {
+			allowed = older(allowed, e.obj)
+		}
+	}
+
+	switch {
+	case denied != nil:
+		return copyEntry(denied), true
+	case allowed != nil:
+		return copyEntry(allowed), true
+	default:
+		return nil, false
+	}
+}
+
+// sweep removes the expired entries, at most once per sweepInterval
+func (s *Store) sweep(now time.Time) {
+	s.mu.RLock()
+	due := now.Sub(s.sweptAt) >= sweepInterval
+	s.mu.RUnlock()
+	if !due {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if now.Sub(s.sweptAt) < sweepInterval {
+		return
+	}
+	s.sweptAt = now
+	for id, e := range s.entries {
+		if e.expired(now) {
+			delete(s.entries, id)
+		}
+	}
+}
+
+func (e *entry) matches(check *models.DeviceCheckDetailsObject) bool {
+	for _, kvp := range check.ActivityData {
+		if kvp != nil && kvp.KvpKey == e.obj.KvpKey && e.match(kvp.KvpValue) {
+			return true
+		}
+	}
+	return false
+}
+
+// older returns the older of two entries, a nil entry being the newest
+func older(a, b *models.ListEntryObject) *models.ListEntryObject {
+	if a == nil {
+		return b
+	}
+	ta, tb := time.Time(a.CreatedAt), time.Time(b.CreatedAt)
+	if ta.Before(tb) || (ta.Equal(tb) && a.ID < b.ID) {
+		return a
+	}
+	return b
+}
+
+// Export writes the entries that have not expired as a JSON array, their
+// values encrypted with the encryptor of the Store, if any
+func (s *Store) Export(w io.Writer) error {
+	entries := s.List()
+	if s.encryptor != nil {
+		for _, obj := range entries {
+			value, err := s.encryptor.Encrypt(obj.KvpKey, obj.Value)
+			if err != nil {
+				return err
+			}
+			obj.Value = value
+		}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(entries)
+}
+
+// Import adds the entries of a JSON array written by Export, keeping their
+// ids. With replace the existing entries are removed first. Nothing is
+// imported when any entry is invalid.
+func (s *Store) Import(r io.Reader, replace bool) (int, error) {
+	var objs []*models.ListEntryObject
+	if err := json.NewDecoder(r).Decode(&objs); err != nil {
+		return 0, fmt.Errorf("invalid list entries: %v", err)
+	}
+
+	now := s.now()
+	entries := make(map[string]*entry, len(objs))
+	for i, obj := range objs {
+		if obj == nil {
+			return 0, fmt.Errorf("list entry %d is null", i)
+		}
+		if envelope.IsEnvelope(obj.Value) {
+			if s.encryptor == nil {
+				return 0, fmt.Errorf("list entry %d is encrypted, encryption is not configured", i)
+			}
+			value, err := s.encryptor.Decrypt(obj.KvpKey, obj.Value)
+			if err != nil {
+				return 0, fmt.Errorf("list entry %d: %v", i, err)
+			}
+			obj.Value = value
+		}
+		id := obj.ID
+		if id == "" {
+			var err error
+			if id, err = newID(); err != nil {
+				return 0, err
+			}
+		}
+		createdAt := time.Time(obj.CreatedAt)
+		if createdAt.IsZero() {
+			createdAt = now
+		}
+		e, err := s.compile(id, obj, createdAt)
+		if err != nil {
+			return 0, fmt.Errorf("list entry %d: %v", i, err)
+		}
+		entries[id] = e
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if replace {
+		s.entries = make(map[string]*entry, len(entries))
+	}
+	for id, e := range entries {
+		s.entries[id] = e
+	}
+	return len(entries), nil
+}
+
+// ImportFile imports the entries of a file written by Export
+func (s *Store) ImportFile(path string, replace bool) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	return s.Import(f, replace)
+}
+
+// compile validates the entry and builds its matcher
+func (s *Store) compile(id string, obj *models.ListEntryObject, createdAt time.Time) (*entry, error) {
+	if err := obj.Validate(nil); err != nil {
+		return nil, err
+	}
+	if !s.keys[obj.KvpKey] {
+		return nil, fmt.Errorf("kvpKey %s can't be listed, valid kvpKeys are %s", obj.KvpKey, strings.Join(s.listedKeys(), ", "))
+	}
+	if obj.Caller != "" && !s.callers[obj.Caller] {
+		return nil, fmt.Errorf("caller %s is not an authenticated caller", obj.Caller)
+	}
+
+	e := &entry{obj: copyEntry(obj)}
+	e.obj.ID = id
+	// the precision of strfmt.DateTime, so exported entries import unchanged
+	e.obj.CreatedAt = strfmt.DateTime(createdAt.UTC().Truncate(time.Millisecond))
+
+	switch obj.Match {
+	case models.ListEntryObjectMatchExact:
+		value := obj.Value
+		e.match = func(v string) bool { return v == value }
+	case models.ListEntryObjectMatchCidr:
+		_, ipNet, err := net.ParseCIDR(obj.Value)
+		if err != nil {
+			return nil, fmt.Errorf("value %s is not a CIDR range", obj.Value)
+		}
+		e.match = func(v string) bool {
+			ip := net.ParseIP(v)
+			return ip != nil && ipNet.Contains(ip)
+		}
+	case models.ListEntryObjectMatchPattern:
+		re, err := compilePattern(obj.Value)
+		if err != nil {
+			return nil, err
+		}
+		e.match = re.MatchString
+	}
+	return e, nil
+}
+
+func (s *Store) listedKeys() []string {
+	keys := make([]string, 0, len(s.keys))
+	for key := range s.keys {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// compilePattern turns a glob, where * matches any text and ? any character, into a regexp
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	var b strings.Builder
+	b.WriteString("^")
+	for _, r := range pattern {
+		switch r {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
+
+func copyEntry(obj *models.ListEntryObject) *models.ListEntryObject {
+	c := *obj
+	if obj.ExpiresAt != nil {
+		expiresAt := *obj.ExpiresAt
+		c.ExpiresAt = &expiresAt
+	}
+	return &c
+}
+
+func newID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
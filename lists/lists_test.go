@@ -0,0 +1,194 @@
+package lists
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+	"time"
+	"universalsdk/envelope"
+	"universalsdk/models"
+	"universalsdk/testutil"
+
+	"github.com/go-openapi/strfmt"
+	"github.com/stretchr/testify/suite"
+)
+
+type ListsSuite struct {
+	suite.Suite
+	store *Store
+	clock *testutil.Clock
+}
+
+func TestListsSuite(t *testing.T) {
+	suite.Run(t, new(ListsSuite))
+}
+
+func (suite *ListsSuite) SetupTest() {
+	suite.clock = testutil.NewClock(testutil.Epoch)
+	suite.store = NewStore([]string{"ip.address", "device.fingerprint"}, WithCallers([]string{"qa"}), WithClock(suite.clock.Now))
+}
+
+func (suite *ListsSuite) TestMatch() {
+	suite.add(models.ListEntryObjectListDeny, "ip.address", models.ListEntryObjectMatchCidr, "10.0.0.0/8", "")
+	suite.add(models.ListEntryObjectListAllow, "device.fingerprint", models.ListEntryObjectMatchPattern, "test-*", "")
+	suite.add(models.ListEntryObjectListAllow, "ip.address", models.ListEntryObjectMatchExact, "1.2.3.4", "qa")
+
+	tests := []struct {
+		caller string
+		ip     string
+		device string
+		list   string
+	}{
+		{"", "10.1.2.3", "", models.ListEntryObjectListDeny},
+		{"", "11.1.2.3", "", ""},
+		{"", "not an ip", "", ""},
+		{"", "192.168.0.1", "test-device-1", models.ListEntryObjectListAllow},
+		{"", "192.168.0.1", "prod-test-device", ""},
+		// deny takes precedence over allow
+		{"", "10.1.2.3", "test-device-1", models.ListEntryObjectListDeny},
+		// entries scoped to a caller only match its checks
+		{"qa", "1.2.3.4", "", models.ListEntryObjectListAllow},
+		{"other", "1.2.3.4", "", ""},
+	}
+	for i, test := range tests {
+		entry, ok := suite.store.Match(test.caller, check(test.ip, test.device))
+		if test.list == "" {
+			suite.False(ok, "test %d matched %v", i, entry)
+			continue
+		}
+		if suite.True(ok, "test %d", i) {
+			suite.Equal(test.list, entry.List, "test %d", i)
+		}
+	}
+}
+
+func (suite *ListsSuite) TestExpiry() {
+	entry := &models.ListEntryObject{List: "deny", KvpKey: "ip.address", Match: "exact", Value: "1.2.3.4"}
+	expiresAt := strfmt.DateTime(suite.clock.Now().Add(time.Hour))
+	entry.ExpiresAt = &expiresAt
+	added, err := suite.store.Add(entry)
+	suite.Require().NoError(err)
+	suite.Equal(strfmt.DateTime(testutil.Epoch), added.CreatedAt)
+
+	_, ok := suite.store.Match("", check("1.2.3.4", ""))
+	suite.True(ok)
+
+	// An expired entry is no longer looked up, even before it is removed
+	suite.clock.Advance(time.Hour)
+	_, ok = suite.store.Get(added.ID)
+	suite.False(ok)
+	_, err = suite.store.Update(added.ID, entry)
+	suite.Equal(ErrNotFound, err)
+
+	// nor matched, matching removes it
+	_, ok = suite.store.Match("", check("1.2.3.4", ""))
+	suite.False(ok)
+	suite.Empty(suite.store.entries)
+	suite.Empty(suite.store.List())
+}
+
+func (suite *ListsSuite) TestCrud() {
+	added := suite.add(models.ListEntryObjectListDeny, "ip.address", models.ListEntryObjectMatchExact, "1.2.3.4", "")
+	suite.NotEmpty(added.ID)
+	suite.False(time.Time(added.CreatedAt).IsZero())
+
+	added.Value = "5.6.7.8"
+	updated, err := suite.store.Update(added.ID, added)
+	suite.Require().NoError(err)
+	suite.Equal("5.6.7.8", updated.Value)
+	suite.Equal(added.CreatedAt, updated.CreatedAt)
+
+	_, err = suite.store.Update("unknown", added)
+	suite.Equal(ErrNotFound, err)
+
+	suite.True(suite.store.Delete(added.ID))
+	suite.False(suite.store.Delete(added.ID))
+	suite.Empty(suite.store.List())
+}
+
+func (suite *ListsSuite) TestInvalidEntries() {
+	invalid := []*models.ListEntryObject{
+		{List: "block", KvpKey: "ip.address", Match: "exact", Value: "1.2.3.4"},
+		{List: "deny", KvpKey: "ip.address", Match: "regexp", Value: "1.2.3.4"},
+		{List: "deny", KvpKey: "ip.address", Match: "exact"},
+		{List: "deny", KvpKey: "email.address", Match: "exact", Value: "a@b.c"},
+		{List: "deny", KvpKey: "ip.address", Match: "cidr", Value: "10.0.0.0"},
+		// only authenticated callers can be listed
+		{List: "allow", KvpKey: "ip.address", Match: "exact", Value: "1.2.3.4", Caller: "other"},
+	}
+	for i, entry := range invalid {
+		_, err := suite.store.Add(entry)
+		suite.Error(err, "entry %d", i)
+	}
+}
+
+func (suite *ListsSuite) TestExportImport() {
+	deny := suite.add(models.ListEntryObjectListDeny, "ip.address", models.ListEntryObjectMatchCidr, "10.0.0.0/8", "")
+	suite.add(models.ListEntryObjectListAllow, "device.fingerprint", models.ListEntryObjectMatchExact, "abc", "qa")
+
+	var buf bytes.Buffer
+	suite.Require().NoError(suite.store.Export(&buf))
+
+	// Entries scoped to a caller only import where the caller is authenticated
+	_, err := NewStore([]string{"ip.address", "device.fingerprint"}).Import(bytes.NewReader(buf.Bytes()), true)
+	suite.Error(err)
+
+	imported := NewStore([]string{"ip.address", "device.fingerprint"}, WithCallers([]string{"qa"}))
+	imported.Add(&models.ListEntryObject{List: "deny", KvpKey: "ip.address", Match: "exact", Value: "9.9.9.9"})
+	count, err := imported.Import(bytes.NewReader(buf.Bytes()), true)
+	suite.Require().NoError(err)
+	suite.Equal(2, count)
+	suite.Equal(suite.store.List(), imported.List())
+
+	got, ok := imported.Get(deny.ID)
+	suite.True(ok)
+	suite.Equal(deny, got)
+
+	// An invalid file imports nothing
+	_, err = imported.Import(bytes.NewBufferString(`[{"list":"deny","kvpKey":"ip.address","match":"exact","value":"1.1.1.1"},{"list":"nope"}]`), false)
+	suite.Error(err)
+	suite.Len(imported.List(), 2)
+}
+
+func (suite *ListsSuite) TestEncryptedExport() {
+	keyFile := filepath.Join(suite.T().TempDir(), "keys.json")
+	_, err := envelope.RotateKeyFile(keyFile)
+	suite.Require().NoError(err)
+	provider, err := envelope.NewLocalKeyProvider(keyFile)
+	suite.Require().NoError(err)
+	encryptor := envelope.NewEncryptor(provider)
+
+	keys := []string{"ip.address", "device.fingerprint"}
+	store := NewStore(keys, WithEncryptor(encryptor))
+	_, err = store.Add(&models.ListEntryObject{List: "deny", KvpKey: "ip.address", Match: "exact", Value: "1.2.3.4"})
+	suite.Require().NoError(err)
+
+	var buf bytes.Buffer
+	suite.Require().NoError(store.Export(&buf))
+	suite.NotContains(buf.String(), "1.2.3.4")
+
+	_, err = NewStore(keys).Import(bytes.NewReader(buf.Bytes()), true)
+	suite.Error(err)
+
+	imported := NewStore(keys, WithEncryptor(encryptor))
+	_, err = imported.Import(bytes.NewReader(buf.Bytes()), true)
+	suite.Require().NoError(err)
+	suite.Equal(store.List(), imported.List())
+}
+
+func (suite *ListsSuite) add(list, kvpKey, match, value, caller string) *models.ListEntryObject {
+	added, err := suite.store.Add(&models.ListEntryObject{List: list, KvpKey: kvpKey, Match: match, Value: value, Caller: caller, Reason: "test"})
+	suite.Require().NoError(err)
+	return added
+}
+
+func check(ip, device string) *models.DeviceCheckDetailsObject {
+	check := &models.DeviceCheckDetailsObject{ActivityType: "LOGIN"}
+	if ip != "" {
+		check.ActivityData = append(check.ActivityData, &models.KeyValuePairObject{KvpKey: "ip.address", KvpValue: ip, KvpType: models.EnumKVPTypeGeneralString})
+	}
+	if device != "" {
+		check.ActivityData = append(check.ActivityData, &models.KeyValuePairObject{KvpKey: "device.fingerprint", KvpValue: device, KvpType: models.EnumKVPTypeGeneralString})
+	}
+	return check
+}
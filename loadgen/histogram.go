@@ -0,0 +1,132 @@
+package loadgen
+
+import (
+	"math/bits"
+	"time"
+)
+
+// subBuckets per power of two, bounding the error of a percentile to about 3%
+const subBucketBits = 5
+
+// Histogram counts latencies in log-linear buckets of microseconds, so a
+// soak run of millions of requests is summarised in constant memory.
+// It is not safe for concurrent use.
+type Histogram struct {
+	counts []uint64
+	count  uint64
+	sum    time.Duration
+	min    time.Duration
+	max    time.Duration
+}
+
+// Record adds a latency
+func (h *Histogram) Record(d time.Duration) {
+	if d < 0 {
+		d = 0
+	}
+	i := bucket(uint64(d / time.Microsecond))
+	if i >= len(h.counts) {
+		counts := make([]uint64, i+1)
+		copy(counts, h.counts)
+		h.counts = counts
+	}
+	h.counts[i]++
+	if h.count == 0 || d < h.min {
+		h.min = d
+	}
+	if d > h.max {
+		h.max = d
+	}
+	h.count++
+	h.sum += d
+}
+
+// Merge adds the latencies of other
+func (h *Histogram) Merge(other *Histogram) {
+	if other.count == 0 {
+		return
+	}
+	if len(other.counts) > len(h.counts) {
+		counts := make([]uint64, len(other.counts))
+		copy(counts, h.counts)
+		h.counts = counts
+	}
+	for i, c := range other.counts {
+		h.counts[i] += c
+	}
+	if h.count == 0 || other.min < h.min {
+		h.min = other.min
+	}
+	if other.max > h.max {
+		h.max = other.max
+	}
+	h.count += other.count
+	h.sum += other.sum
+}
+
+// Count returns the number of recorded latencies
+func (h *Histogram) Count() uint64 {
+	return h.count
+}
+
+// Mean returns the mean latency
+func (h *Histogram) Mean() time.Duration {
+	if h.count == 0 {
+		return 0
+	}
+	return h.sum / time.Duration(h.count)
+}
+
+// Max returns the largest latency
+func (h *Histogram) Max() time.Duration {
+	return h.max
+}
+
+// Percentile returns the latency below which p percent of the latencies
+// fall, p being between 0 and 100
+func (h *Histogram) Percentile(p float64) time.Duration {
+	if h.count == 0 {
+		return 0
+	}
+	rank := uint64(p / 100 * float64(h.count))
+	if rank >= h.count {
+		rank = h.count - 1
+	}
+	var seen uint64
+	for i, c := range h.counts {
+		seen += c
+		if seen > rank {
+			d := time.Duration(upperBound(i)) * time.Microsecond
+			// the bounds are approximate, the extremes are exact
+			if d > h.max {
+				d = h.max
+			}
+			if d < h.min {
+				d = h.min
+			}
+			return d
+		}
+	}
+	return h.max
+}
+
+// bucket returns the index of the bucket of v. Values below
+// 2^subBucketBits have a bucket each, larger ones share a bucket with
+// values of the same power of two and the same top subBucketBits bits.
+func bucket(v uint64) int {
+	if v < 1<<subBucketBits {
+		return int(v)
+	}
+	exp := bits.Len64(v) - subBucketBits - 1
+	return (exp+1)<<subBucketBits + int(v>>exp) - 1<<subBucketBits
+}
+
+// upperBound returns the largest value of bucket i
+func upperBound(i int) uint64 {
+	if i < 1<<subBucketBits {
+		return uint64(i)
+	}
+	exp := i>>subBucketBits - 1
+	mantissa := uint64(i&(1<<subBucketBits-1)) + 1<<subBucketBits
+	return (mantissa+1)<<exp - 1
+}
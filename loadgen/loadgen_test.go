@@ -0,0 +1,190 @@
+package loadgen
+
+import (
+	"context"
+	"math"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"universalsdk/controller"
+	"universalsdk/service"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type LoadgenSuite struct {
+	suite.Suite
+}
+
+func TestLoadgenSuite(t *testing.T) {
+	suite.Run(t, new(LoadgenSuite))
+}
+
+func (suite *LoadgenSuite) TestRun() {
+	var sessionKeyMap sync.Map
+	// Retries are only answered with the original response with replay enabled
+	usdkController := controller.NewUsdkController(service.NewUsdkService(&sessionKeyMap, service.WithReplayTTL(time.Minute)))
+	server := httptest.NewServer(controller.NewRouter(controller.Routes{Usdk: usdkController}))
+	defer server.Close()
+
+	report, err := Run(context.Background(), Config{
+		URL:       server.URL,
+		Rate:      400,
+		Duration:  500 * time.Millisecond,
+		Mix:       Mix{Valid: 50, Invalid: 20, Duplicate: 15, Retry: 15},
+		MaxChecks: 3,
+		Memory:    func() (uint64, error) { return 1 << 20, nil },
+	})
+	suite.Require().NoError(err)
+
+	suite.Greater(report.Requests, uint64(100))
+	suite.GreaterOrEqual(report.Checks, report.Requests)
+	suite.Equal(report.Requests, report.Latency.Count())
+	suite.Zero(report.Unexpected(), "%+v", report.Outcomes)
+	for _, kind := range Kinds {
+		suite.NotZero(report.Kinds[kind].Sent, kind)
+	}
+	suite.NotZero(report.Outcomes[Outcome{Status: 200}])
+	suite.NotZero(report.Outcomes[Outcome{Status: 400, Code: 3}])
+	suite.GreaterOrEqual(len(report.Memory), 2)
+
+	var out strings.Builder
+	report.Print(&out)
+	suite.Contains(out.String(), "400 code 3")
+	suite.Contains(out.String(), "p99")
+	suite.Contains(out.String(), "growth 0.0MiB/h")
+}
+
+func (suite *LoadgenSuite) TestRunUnreachable() {
+	server := httptest.NewServer(nil)
+	server.Close()
+
+	report, err := Run(context.Background(), Config{URL: server.URL, Rate: 100, Duration: 100 * time.Millisecond})
+	suite.Require().NoError(err)
+	suite.NotZero(report.Outcomes[Outcome{Error: "transport error"}])
+	suite.Equal(report.Requests, report.Unexpected())
+}
+
+func (suite *LoadgenSuite) TestGenerator() {
+	kinds := func(seed int64) []Kind {
+		gen := newGenerator(seed, Mix{Valid: 1, Invalid: 1, Duplicate: 1, Retry: 1}, 3)
+		var kinds []Kind
+		for i := 0; i < 50; i++ {
+			kind, collection, original, err := gen.collection()
+			suite.Require().NoError(err)
+			suite.NotEmpty(collection)
+			suite.Equal(kind == KindRetry, original != nil)
+			if kind == KindValid {
+				gen.accepted(collection, nil, nil)
+			}
+			kinds = append(kinds, kind)
+		}
+		return kinds
+	}
+	suite.Equal(kinds(7), kinds(7))
+	suite.Contains(kinds(7), KindDuplicate)
+	suite.Contains(kinds(7), KindRetry)
+
+	// Duplicates and retries wait for a collection accepted by the server
+	gen := newGenerator(1, Mix{Duplicate: 1, Retry: 1}, 1)
+	kind, collection, _, _ := gen.collection()
+	suite.Equal(KindValid, kind)
+
+	// A retry sends the accepted collection as it was sent
+	gen.accepted(collection, []byte("body"), []byte("response"))
+	gen.mix = Mix{Retry: 1}
+	kind, _, original, _ := gen.collection()
+	suite.Equal(KindRetry, kind)
+	suite.Equal("body", string(original.body))
+}
+
+func (suite *LoadgenSuite) TestParseMix() {
+	mix, err := ParseMix("80:15:5")
+	suite.NoError(err)
+	suite.Equal(Mix{Valid: 80, Invalid: 15, Duplicate: 5}, mix)
+
+	mix, err = ParseMix("80:10:5:5")
+	suite.NoError(err)
+	suite.Equal(Mix{Valid: 80, Invalid: 10, Duplicate: 5, Retry: 5}, mix)
+
+	for _, s := range []string{"", "80:20", "a:b:c", "0:0:0", "-1:1:1", "80:15:5:", "80:15:5:-1"} {
+		_, err := ParseMix(s)
+		suite.Error(err, s)
+	}
+}
+
+func (suite *LoadgenSuite) TestHistogram() {
+	var all, odd, even Histogram
+	for us := 1; us <= 10000; us++ {
+		d := time.Duration(us) * time.Microsecond
+		all.Record(d)
+		if us%2 == 0 {
+			even.Record(d)
+		} else {
+			odd.Record(d)
+		}
+	}
+	odd.Merge(&even)
+	suite.Equal(all, odd)
+
+	suite.Equal(uint64(10000), all.Count())
+	suite.Equal(10*time.Millisecond, all.Max())
+	for _, p := range []float64{50, 90, 99, 99.9} {
+		exact := p / 100 * 10000
+		got := float64(all.Percentile(p) / time.Microsecond)
+		suite.InEpsilon(exact, got, 0.035, "p%v", p)
+	}
+	suite.Equal(10*time.Millisecond, all.Percentile(100))
+
+	var empty Histogram
+	suite.Zero(empty.Percentile(99))
+	suite.Zero(empty.Mean())
+}
+
+func (suite *LoadgenSuite) TestBuckets() {
+	for v := uint64(0); v < 1<<20; v += 1 + v/1000 {
+		i := bucket(v)
+		suite.GreaterOrEqual(upperBound(i), v, "value %d", v)
+		suite.Equal(i, bucket(upperBound(i)), "value %d", v)
+		if v > 0 {
+			suite.LessOrEqual(bucket(v-1), i)
+		}
+	}
+}
+
+func (suite *LoadgenSuite) TestMemory() {
+	rss, err := parseRSS(strings.NewReader("Name:\tuniversalsdk\nVmPeak:\t  20000 kB\nVmRSS:\t   12288 kB\nThreads:\t8\n"))
+	suite.NoError(err)
+	suite.Equal(uint64(12<<20), rss)
+
+	_, err = parseRSS(strings.NewReader("Name:\tuniversalsdk\n"))
+	suite.Error(err)
+
+	// The memory of a server anywhere, from its expvar memstats
+	var sessionKeyMap sync.Map
+	admin := controller.NewAdminController(service.NewSessionKeyService(&sessionKeyMap), "s3cret")
+	server := httptest.NewServer(controller.NewRouter(controller.Routes{Admin: admin}))
+	defer server.Close()
+	held, err := ExpvarMemory(server.Client(), server.URL+"/admin/vars", "s3cret")()
+	suite.NoError(err)
+	suite.NotZero(held)
+	_, err = ExpvarMemory(server.Client(), server.URL+"/admin/vars", "wrong")()
+	suite.ErrorContains(err, "401")
+
+	held, err = parseMemstats(strings.NewReader(`{"cmdline":[],"memstats":{"Sys":8388608,"HeapReleased":1048576}}`))
+	suite.NoError(err)
+	suite.Equal(uint64(7<<20), held)
+	_, err = parseMemstats(strings.NewReader(`{"cmdline":[]}`))
+	suite.Error(err)
+
+	// 1MiB more every 10 minutes
+	var samples []MemorySample
+	for i := 0; i < 7; i++ {
+		samples = append(samples, MemorySample{At: time.Duration(i) * 10 * time.Minute, Bytes: uint64(100+i) << 20})
+	}
+	suite.InDelta(6<<20, MemoryGrowth(samples), 1)
+	suite.Zero(MemoryGrowth(samples[:1]))
+	suite.False(math.IsNaN(MemoryGrowth([]MemorySample{{}, {}})))
+}
@@ -0,0 +1,118 @@
+package loadgen
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MemorySampler returns the memory used by the server process, in bytes
+type MemorySampler func() (uint64, error)
+
+// ProcessRSS samples the resident set size of the local process pid from
+// /proc, so it needs Linux and a server on the same host or pod
+func ProcessRSS(pid int) MemorySampler {
+	path := fmt.Sprintf("/proc/%d/status", pid)
+	return func() (uint64, error) {
+		f, err := os.Open(path)
+		if err != nil {
+			return 0, err
+		}
+		defer f.Close()
+		return parseRSS(f)
+	}
+}
+
+// ExpvarMemory samples the memory the Go runtime of the server holds from
+// the OS, Sys less HeapReleased of the memstats its /admin/vars route
+// serves, so it works wherever the server runs. url is the one of the
+// route, e.g. "http://localhost:8080/admin/vars".
+func ExpvarMemory(httpClient *http.Client, url, adminToken string) MemorySampler {
+	return func() (uint64, error) {
+		req, err := http.NewRequest("GET", url, nil)
+		if err != nil {
+			return 0, err
+		}
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			return 0, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return 0, fmt.Errorf("%s answered %s", url, resp.Status)
+		}
+		return parseMemstats(resp.Body)
+	}
+}
+
+// parseMemstats reads the memstats of an expvar response
+func parseMemstats(vars io.Reader) (uint64, error) {
+	var v struct {
+		Memstats *struct {
+			Sys          uint64
+			HeapReleased uint64
+		} `json:"memstats"`
+	}
+	if err := json.NewDecoder(vars).Decode(&v); err != nil {
+		return 0, err
+	}
+	if v.Memstats == nil {
+		return 0, fmt.Errorf("no memstats in expvar variables")
+	}
+	return v.Memstats.Sys - v.Memstats.HeapReleased, nil
+}
+
+// parseRSS reads the VmRSS line of a /proc/<pid>/status file
+func parseRSS(status io.Reader) (uint64, error) {
+	scanner := bufio.NewScanner(status)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 3 && fields[0] == "VmRSS:" && fields[2] == "kB" {
+			kb, err := strconv.ParseUint(fields[1], 10, 64)
+			if err != nil {
+				return 0, fmt.Errorf("invalid VmRSS %q", fields[1])
+			}
+			return kb << 10, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+	return 0, fmt.Errorf("no VmRSS in process status")
+}
+
+// MemorySample is the memory of the server at a time of the run
+type MemorySample struct {
+	At    time.Duration
+	Bytes uint64
+}
+
+// MemoryGrowth returns the growth of the memory in bytes per hour, the
+// slope of the least squares line through the samples. A server whose
+// memory stays flat has a growth close to zero.
+func MemoryGrowth(samples []MemorySample) float64 {
+	if len(samples) < 2 {
+		return 0
+	}
+	var sumX, sumY, sumXY, sumXX float64
+	for _, s := range samples {
+		x, y := s.At.Hours(), float64(s.Bytes)
+		sumX += x
+		sumY += y
+		sumXY += x * y
+		sumXX += x * x
+	}
+	n := float64(len(samples))
+	denominator := n*sumXX - sumX*sumX
+	if denominator == 0 {
+		return 0
+	}
+	return (n*sumXY - sumX*sumY) / denominator
+}
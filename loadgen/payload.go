@@ -0,0 +1,224 @@
+// Package loadgen sends realistic device check traffic to a server at a
+// fixed rate and reports the latency, the outcomes by ErrorObject code and
+// the memory of the server process over the run.
+package loadgen
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"universalsdk/models"
+	"universalsdk/sessionkey"
+)
+
+// Kind is the kind of collection a request sends
+type Kind string
+
+const (
+	// KindValid collections pass every validation
+	KindValid Kind = "valid"
+
+	// KindInvalid collections fail the schema or the business validations
+	KindInvalid Kind = "invalid"
+
+	// KindDuplicate collections reuse the session key of an earlier valid
+	// one in a new payload, a conflict rejected whether replay is enabled
+	// or not
+	KindDuplicate Kind = "duplicate"
+
+	// KindRetry collections send an earlier valid one again as is, which
+	// the server only answers with the original response when replay is
+	// enabled (session.replayTTL), off by default
+	KindRetry Kind = "retry"
+)
+
+// Kinds lists the kinds of collection in report order
+var Kinds = []Kind{KindValid, KindInvalid, KindDuplicate, KindRetry}
+
+// Mix is the relative weight of each kind of collection in the traffic
+type Mix struct {
+	Valid     int
+	Invalid   int
+	Duplicate int
+	Retry     int
+}
+
+// DefaultMix is mostly valid traffic with a few client mistakes. It has no
+// retries, which need a server with replay enabled.
+var DefaultMix = Mix{Valid: 90, Invalid: 7, Duplicate: 3}
+
+// ParseMix parses "valid:invalid:duplicate" weights, e.g. "90:7:3",
+// optionally followed by the weight of retries, e.g. "90:5:3:2"
+func ParseMix(s string) (Mix, error) {
+	var m Mix
+	n, _ := fmt.Sscanf(s, "%d:%d:%d:%d", &m.Valid, &m.Invalid, &m.Duplicate, &m.Retry)
+	if n < 3 || (n == 3 && strings.Count(s, ":") != 2) {
+		return Mix{}, fmt.Errorf("mix %q should be valid:invalid:duplicate[:retry] weights, e.g. 90:7:3", s)
+	}
+	if m.Valid < 0 || m.Invalid < 0 || m.Duplicate < 0 || m.Retry < 0 || m.Valid+m.Invalid+m.Duplicate+m.Retry == 0 {
+		return Mix{}, fmt.Errorf("mix %q should have positive weights", s)
+	}
+	return m, nil
+}
+
+func (m Mix) pick(r *rand.Rand) Kind {
+	n := r.Intn(m.Valid + m.Invalid + m.Duplicate + m.Retry)
+	switch {
+	case n < m.Valid:
+		return KindValid
+	case n < m.Valid+m.Invalid:
+		return KindInvalid
+	case n < m.Valid+m.Invalid+m.Duplicate:
+		return KindDuplicate
+	default:
+		return KindRetry
+	}
+}
+
+var (
+	checkTypes    = []string{"DEVICE", "BIOMETRIC", "COMBO"}
+	activityTypes = []string{"SIGNUP", "LOGIN", "PAYMENT", "CONFIRMATION"}
+	userAgents    = []string{
+		"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36",
+		"Mozilla/5.0 (iPhone; CPU iPhone OS 17_1 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Mobile/15E148",
+		"Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Mobile Safari/537.36",
+	}
+	countries = []string{"AU", "NZ", "GB", "US", "SG"}
+)
+
+// generator makes the collections of the traffic. Valid session keys are
+// random UUIDv7s so runs against the same server never collide, the rest of
+// the traffic is drawn from a seeded source.
+type generator struct {
+	mu        sync.Mutex
+	rand      *rand.Rand
+	mix       Mix
+	maxChecks int
+
+	// session keys of sent valid collections, reused by duplicates
+	used []string
+	next int
+
+	// accepted valid collections, sent again by retries
+	sent     []*sent
+	nextSent int
+}
+
+// sent is a valid collection the server accepted, as sent and answered
+type sent struct {
+	collection models.DeviceCheckDetailsObjectCollection
+	body       []byte
+	response   []byte
+}
+
+// usedKeys is how many session keys are kept for duplicates, and
+// collections for retries
+const usedKeys = 1024
+
+func newGenerator(seed int64, mix Mix, maxChecks int) *generator {
+	if maxChecks < 1 {
+		maxChecks = 1
+	}
+	return &generator{rand: rand.New(rand.NewSource(seed)), mix: mix, maxChecks: maxChecks}
+}
+
+// collection returns the next collection of the traffic and its kind. A
+// retry returns the earlier collection it sends again.
+func (g *generator) collection() (Kind, models.DeviceCheckDetailsObjectCollection, *sent, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	kind := g.mix.pick(g.rand)
+	if (kind == KindDuplicate && len(g.used) == 0) || (kind == KindRetry && len(g.sent) == 0) {
+		kind = KindValid
+	}
+	if kind == KindRetry {
+		original := g.sent[g.rand.Intn(len(g.sent))]
+		return kind, original.collection, original, nil
+	}
+
+	checks := 1 + g.rand.Intn(g.maxChecks)
+	collection := make(models.DeviceCheckDetailsObjectCollection, 0, checks)
+	for i := 0; i < checks; i++ {
+		key, err := sessionkey.New()
+		if err != nil {
+			return "", nil, nil, err
+		}
+		collection = append(collection, g.check(key))
+	}
+
+	switch kind {
+	case KindInvalid:
+		g.invalidate(collection)
+	case KindDuplicate:
+		collection[g.rand.Intn(len(collection))].CheckSessionKey = g.used[g.rand.Intn(len(g.used))]
+	}
+	return kind, collection, nil, nil
+}
+
+func (g *generator) check(sessionKey string) *models.DeviceCheckDetailsObject {
+	r := g.rand
+	fingerprint := sha256.Sum256([]byte(strconv.Itoa(r.Intn(100000))))
+	activityData := []*models.KeyValuePairObject{
+		{KvpKey: "ip.address", KvpValue: fmt.Sprintf("%d.%d.%d.%d", 1+r.Intn(223), r.Intn(256), r.Intn(256), 1+r.Intn(254)), KvpType: models.EnumKVPTypeGeneralString},
+		{KvpKey: "device.fingerprint", KvpValue: hex.EncodeToString(fingerprint[:]), KvpType: models.EnumKVPTypeGeneralString},
+		{KvpKey: "user.agent", KvpValue: userAgents[r.Intn(len(userAgents))], KvpType: models.EnumKVPTypeGeneralString},
+		{KvpKey: "country.code", KvpValue: countries[r.Intn(len(countries))], KvpType: models.EnumKVPTypeGeneralString},
+		{KvpKey: "login.attempts", KvpValue: strconv.Itoa(1 + r.Intn(5)), KvpType: models.EnumKVPTypeGeneralInteger},
+		{KvpKey: "risk.score", KvpValue: strconv.FormatFloat(r.Float64(), 'f', 3, 64), KvpType: models.EnumKVPTypeGeneralFloat},
+		{KvpKey: "vpn", KvpValue: strconv.FormatBool(r.Intn(10) == 0), KvpType: models.EnumKVPTypeGeneralBool},
+		{KvpKey: "email.address", KvpValue: fmt.Sprintf("user%d@example.com", r.Intn(1000000)), KvpType: models.EnumKVPTypePiiEmail},
+	}
+	// Not every client sends everything
+	activityData = activityData[:4+r.Intn(len(activityData)-3)]
+
+	return &models.DeviceCheckDetailsObject{
+		CheckType:       checkTypes[r.Intn(len(checkTypes))],
+		ActivityType:    activityTypes[r.Intn(len(activityTypes))],
+		CheckSessionKey: sessionKey,
+		ActivityData:    activityData,
+	}
+}
+
+// invalidate breaks one check of the collection the way clients get it wrong
+func (g *generator) invalidate(collection models.DeviceCheckDetailsObjectCollection) {
+	check := collection[g.rand.Intn(len(collection))]
+	switch g.rand.Intn(4) {
+	case 0:
+		check.CheckType = "DUMMY"
+	case 1:
+		check.ActivityData = append(check.ActivityData, &models.KeyValuePairObject{KvpKey: "login.attempts", KvpValue: "many", KvpType: models.EnumKVPTypeGeneralInteger})
+	case 2:
+		check.ActivityData = append(check.ActivityData, check.ActivityData[0])
+	default:
+		check.ActivityData = append(check.ActivityData, &models.KeyValuePairObject{KvpKey: "vpn.detected", KvpValue: "maybe", KvpType: models.EnumKVPTypeGeneralBool})
+	}
+}
+
+// accepted keeps a collection the server accepted and its session keys, a
+// key is only reused once its original request has reserved it
+func (g *generator) accepted(collection models.DeviceCheckDetailsObjectCollection, body, response []byte) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	s := &sent{collection: collection, body: body, response: response}
+	if len(g.sent) < usedKeys {
+		g.sent = append(g.sent, s)
+	} else {
+		g.sent[g.nextSent] = s
+		g.nextSent = (g.nextSent + 1) % usedKeys
+	}
+
+	for _, check := range collection {
+		if len(g.used) < usedKeys {
+			g.used = append(g.used, check.CheckSessionKey)
+			continue
+		}
+		g.used[g.next] = check.CheckSessionKey
+		g.next = (g.next + 1) % usedKeys
+	}
+}
@@ -0,0 +1,256 @@
+package loadgen
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+	"time"
+	"universalsdk/models"
+)
+
+// Outcome is the answer to a request: its status and ErrorObject code, or
+// the error of a request that got no answer
+type Outcome struct {
+	Status int
+	Code   int64
+	Error  string
+
+	// Replayed tells how the answer to a retry differs from the original one
+	Replayed string
+}
+
+func (o Outcome) String() string {
+	switch {
+	case o.Error != "":
+		return o.Error
+	case o.Replayed != "":
+		return strconv.Itoa(o.Status) + " " + o.Replayed
+	case o.Status == http.StatusOK:
+		return "200"
+	case o.Code != 0:
+		return strconv.Itoa(o.Status) + " code " + strconv.FormatInt(o.Code, 10)
+	default:
+		return strconv.Itoa(o.Status)
+	}
+}
+
+// expected reports whether a server answers a collection of the kind with the outcome
+func (o Outcome) expected(kind Kind) bool {
+	switch kind {
+	case KindValid:
+		return o.Status == http.StatusOK
+	case KindInvalid:
+		return o.Status == http.StatusBadRequest && (o.Code == models.ErrorCodeInvalidRequest || o.Code == models.ErrorCodeCheckFailed)
+	case KindDuplicate:
+		return o.Status == http.StatusBadRequest && o.Code == models.ErrorCodeCheckFailed
+	case KindRetry:
+		return o.Status == http.StatusOK && o.Replayed == ""
+	default:
+		return false
+	}
+}
+
+// KindCount counts the requests of a kind of collection
+type KindCount struct {
+	Sent       uint64 `json:"sent"`
+	Unexpected uint64 `json:"unexpected"`
+}
+
+// Report is the outcome of a run
+type Report struct {
+	Duration time.Duration
+
+	// Requests answered or failed, and the checks they carried
+	Requests uint64
+	Checks   uint64
+
+	// Skipped requests were due while every worker was busy
+	Skipped uint64
+
+	Latency  Histogram
+	Kinds    map[Kind]*KindCount
+	Outcomes map[Outcome]uint64
+	Memory   []MemorySample
+}
+
+func newReport() *Report {
+	kinds := make(map[Kind]*KindCount, len(Kinds))
+	for _, kind := range Kinds {
+		kinds[kind] = &KindCount{}
+	}
+	return &Report{Kinds: kinds, Outcomes: make(map[Outcome]uint64)}
+}
+
+func (r *Report) count(kind Kind, checks int, outcome Outcome, latency time.Duration) {
+	r.Requests++
+	r.Checks += uint64(checks)
+	r.Outcomes[outcome]++
+	if count, ok := r.Kinds[kind]; ok {
+		count.Sent++
+		if !outcome.expected(kind) {
+			count.Unexpected++
+		}
+	}
+	if outcome.Error == "" {
+		r.Latency.Record(latency)
+	}
+}
+
+func (r *Report) merge(other *Report) {
+	r.Requests += other.Requests
+	r.Checks += other.Checks
+	r.Skipped += other.Skipped
+	r.Latency.Merge(&other.Latency)
+	for kind, count := range other.Kinds {
+		r.Kinds[kind].Sent += count.Sent
+		r.Kinds[kind].Unexpected += count.Unexpected
+	}
+	for outcome, n := range other.Outcomes {
+		r.Outcomes[outcome] += n
+	}
+}
+
+// Unexpected returns how many requests weren't answered as their kind should be
+func (r *Report) Unexpected() uint64 {
+	var n uint64
+	for _, count := range r.Kinds {
+		n += count.Unexpected
+	}
+	return n
+}
+
+// rate returns n per second of the run
+func (r *Report) rate(n uint64) float64 {
+	if r.Duration <= 0 {
+		return 0
+	}
+	return float64(n) / r.Duration.Seconds()
+}
+
+// percentiles reported for the latency
+var percentiles = []float64{50, 90, 99, 99.9}
+
+// outcomes returns the outcomes from the most frequent
+func (r *Report) outcomes() []Outcome {
+	outcomes := make([]Outcome, 0, len(r.Outcomes))
+	for outcome := range r.Outcomes {
+		outcomes = append(outcomes, outcome)
+	}
+	sort.Slice(outcomes, func(i, j int) bool {
+		if r.Outcomes[outcomes[i]] != r.Outcomes[outcomes[j]] {
+			return r.Outcomes[outcomes[i]] > r.Outcomes[outcomes[j]]
+		}
+		return outcomes[i].String() < outcomes[j].String()
+	})
+	return outcomes
+}
+
+// Print writes the report for people
+func (r *Report) Print(w io.Writer) {
+	fmt.Fprintf(w, "duration %s, %d requests (%.1f/s), %d checks (%.1f/s), %d skipped\n",
+		r.Duration.Round(time.Millisecond), r.Requests, r.rate(r.Requests), r.Checks, r.rate(r.Checks), r.Skipped)
+
+	fmt.Fprintf(w, "latency  mean %s", round(r.Latency.Mean()))
+	for _, p := range percentiles {
+		fmt.Fprintf(w, "  p%s %s", strconv.FormatFloat(p, 'f', -1, 64), round(r.Latency.Percentile(p)))
+	}
+	fmt.Fprintf(w, "  max %s\n\n", round(r.Latency.Max()))
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "kind\tsent\tunexpected")
+	for _, kind := range Kinds {
+		fmt.Fprintf(tw, "%s\t%d\t%d\n", kind, r.Kinds[kind].Sent, r.Kinds[kind].Unexpected)
+	}
+	tw.Flush()
+
+	fmt.Fprintln(w)
+	tw = tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "outcome\tcount")
+	for _, outcome := range r.outcomes() {
+		fmt.Fprintf(tw, "%s\t%d\n", outcome, r.Outcomes[outcome])
+	}
+	tw.Flush()
+
+	if len(r.Memory) > 0 {
+		first, last := r.Memory[0], r.Memory[len(r.Memory)-1]
+		low, high := first.Bytes, first.Bytes
+		for _, s := range r.Memory {
+			low, high = min(low, s.Bytes), max(high, s.Bytes)
+		}
+		fmt.Fprintf(w, "\nmemory   start %s  min %s  max %s  end %s  growth %s/h over %d samples\n",
+			mib(first.Bytes), mib(low), mib(high), mib(last.Bytes), mibf(MemoryGrowth(r.Memory)), len(r.Memory))
+	}
+}
+
+// MarshalJSON writes the report for tools, with latencies in milliseconds
+func (r *Report) MarshalJSON() ([]byte, error) {
+	latency := map[string]float64{"mean": ms(r.Latency.Mean()), "max": ms(r.Latency.Max())}
+	for _, p := range percentiles {
+		latency["p"+strconv.FormatFloat(p, 'f', -1, 64)] = ms(r.Latency.Percentile(p))
+	}
+	outcomes := make(map[string]uint64, len(r.Outcomes))
+	for outcome, n := range r.Outcomes {
+		outcomes[outcome.String()] += n
+	}
+	type memorySample struct {
+		Seconds float64 `json:"seconds"`
+		Bytes   uint64  `json:"bytes"`
+	}
+	memory := make([]memorySample, 0, len(r.Memory))
+	for _, s := range r.Memory {
+		memory = append(memory, memorySample{Seconds: s.At.Seconds(), Bytes: s.Bytes})
+	}
+
+	return json.Marshal(struct {
+		DurationSeconds float64             `json:"durationSeconds"`
+		Requests        uint64              `json:"requests"`
+		RequestsPerSec  float64             `json:"requestsPerSecond"`
+		Checks          uint64              `json:"checks"`
+		ChecksPerSec    float64             `json:"checksPerSecond"`
+		Skipped         uint64              `json:"skipped"`
+		LatencyMs       map[string]float64  `json:"latencyMs"`
+		Kinds           map[Kind]*KindCount `json:"kinds"`
+		Outcomes        map[string]uint64   `json:"outcomes"`
+		Memory          []memorySample      `json:"memory,omitempty"`
+		MemoryGrowth    float64             `json:"memoryGrowthBytesPerHour,omitempty"`
+	}{
+		DurationSeconds: r.Duration.Seconds(),
+		Requests:        r.Requests,
+		RequestsPerSec:  r.rate(r.Requests),
+		Checks:          r.Checks,
+		ChecksPerSec:    r.rate(r.Checks),
+		Skipped:         r.Skipped,
+		LatencyMs:       latency,
+		Kinds:           r.Kinds,
+		Outcomes:        outcomes,
+		Memory:          memory,
+		MemoryGrowth:    MemoryGrowth(r.Memory),
+	})
+}
+
+func round(d time.Duration) time.Duration {
+	switch {
+	case d >= time.Second:
+		return d.Round(time.Millisecond)
+	case d >= time.Millisecond:
+		return d.Round(10 * time.Microsecond)
+	default:
+		return d.Round(time.Microsecond)
+	}
+}
+
+func ms(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+func mib(b uint64) string {
+	return mibf(float64(b))
+}
+
+func mibf(b float64) string {
+	return strconv.FormatFloat(b/(1<<20), 'f', 1, 64) + "MiB"
+}
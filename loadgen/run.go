@@ -0,0 +1,246 @@
+package loadgen
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+	"universalsdk/models"
+)
+
+// Config is the traffic of a run
+type Config struct {
+	// URL of the server, e.g. "http://localhost:8080"
+	URL string
+
+	// Route of the device checks, "/isgood" by default
+	Route string
+
+	// Rate of requests per second. Requests due while Concurrency requests
+	// are in flight are skipped rather than queued. Zero sends as fast as
+	// Concurrency allows.
+	Rate float64
+
+	// Duration of the run, until the context is cancelled when zero
+	Duration time.Duration
+
+	// Concurrency is the largest number of requests in flight, 16 by default
+	Concurrency int
+
+	// Mix of the kinds of collection, DefaultMix when zero
+	Mix Mix
+
+	// MaxChecks is the largest number of checks of a collection, 1 by default
+	MaxChecks int
+
+	// Seed of the generated traffic
+	Seed int64
+
+	// Timeout of a request, 10s by default
+	Timeout time.Duration
+
+	// CallerID is sent as X-Caller-Id and APIKey as X-Api-Key when set
+	CallerID string
+	APIKey   string
+
+	// Memory samples the memory of the server every SampleEvery (10s by
+	// default) when set
+	Memory      MemorySampler
+	SampleEvery time.Duration
+}
+
+// Run sends the traffic of cfg until its duration elapses or ctx is cancelled
+func Run(ctx context.Context, cfg Config) (*Report, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("loadgen: no URL")
+	}
+	if cfg.Route == "" {
+		cfg.Route = "/isgood"
+	}
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = 16
+	}
+	if cfg.Mix == (Mix{}) {
+		cfg.Mix = DefaultMix
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	if cfg.SampleEvery <= 0 {
+		cfg.SampleEvery = 10 * time.Second
+	}
+	if cfg.Duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.Duration)
+		defer cancel()
+	}
+
+	url := strings.TrimRight(cfg.URL, "/") + cfg.Route
+	httpClient := &http.Client{
+		Timeout:   cfg.Timeout,
+		Transport: &http.Transport{MaxIdleConnsPerHost: cfg.Concurrency},
+	}
+	header := http.Header{"Content-Type": {"application/json"}}
+	if cfg.CallerID != "" {
+		header.Set("X-Caller-Id", cfg.CallerID)
+	}
+	if cfg.APIKey != "" {
+		header.Set("X-Api-Key", cfg.APIKey)
+	}
+	gen := newGenerator(cfg.Seed, cfg.Mix, cfg.MaxChecks)
+	start := time.Now()
+
+	// Every worker counts on its own, merged once the run is over
+	jobs := make(chan struct{}, cfg.Concurrency)
+	workers := make([]*Report, cfg.Concurrency)
+	var wg sync.WaitGroup
+	for i := range workers {
+		workers[i] = newReport()
+		wg.Add(1)
+		go func(report *Report) {
+			defer wg.Done()
+			for range jobs {
+				send(ctx, httpClient, url, header, gen, report)
+			}
+		}(workers[i])
+	}
+
+	var memory []MemorySample
+	memoryDone := make(chan struct{})
+	go func() {
+		defer close(memoryDone)
+		if cfg.Memory != nil {
+			memory = sampleMemory(ctx, cfg.Memory, cfg.SampleEvery, start)
+		}
+	}()
+
+	skipped := pace(ctx, cfg.Rate, start, jobs)
+	close(jobs)
+	wg.Wait()
+	<-memoryDone
+
+	report := newReport()
+	for _, worker := range workers {
+		report.merge(worker)
+	}
+	report.Duration = time.Since(start)
+	report.Skipped = skipped
+	report.Memory = memory
+	return report, nil
+}
+
+// pace hands out jobs at rate until ctx is done and returns how many were
+// skipped because every worker was busy
+func pace(ctx context.Context, rate float64, start time.Time, jobs chan<- struct{}) uint64 {
+	if rate <= 0 {
+		for {
+			select {
+			case jobs <- struct{}{}:
+			case <-ctx.Done():
+				return 0
+			}
+		}
+	}
+
+	var skipped uint64
+	interval := time.Duration(float64(time.Second) / rate)
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for n := 0; ; n++ {
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return skipped
+		}
+		select {
+		case jobs <- struct{}{}:
+		default:
+			skipped++
+		}
+		// scheduled from the start so the rate doesn't drift
+		timer.Reset(time.Until(start.Add(time.Duration(n+1) * interval)))
+	}
+}
+
+// send posts the next collection and counts its outcome
+func send(ctx context.Context, httpClient *http.Client, url string, header http.Header, gen *generator, report *Report) {
+	kind, collection, original, err := gen.collection()
+	if err != nil {
+		report.count(kind, 0, Outcome{Error: err.Error()}, 0)
+		return
+	}
+	var body []byte
+	if original != nil {
+		body = original.body
+	} else if body, err = json.Marshal(collection); err != nil {
+		report.count(kind, len(collection), Outcome{Error: err.Error()}, 0)
+		return
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		report.count(kind, len(collection), Outcome{Error: err.Error()}, 0)
+		return
+	}
+	req.Header = header.Clone()
+
+	sent := time.Now()
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		// requests cut short by the end of the run are not counted
+		if ctx.Err() == nil {
+			report.count(kind, len(collection), Outcome{Error: "transport error"}, time.Since(sent))
+		}
+		return
+	}
+	data, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	latency := time.Since(sent)
+	if err != nil {
+		if ctx.Err() == nil {
+			report.count(kind, len(collection), Outcome{Error: "transport error"}, latency)
+		}
+		return
+	}
+
+	outcome := Outcome{Status: resp.StatusCode}
+	switch {
+	case resp.StatusCode != http.StatusOK:
+		var errorObj models.ErrorObject
+		if json.Unmarshal(data, &errorObj) == nil {
+			outcome.Code = errorObj.Code
+		}
+	case kind == KindValid:
+		gen.accepted(collection, body, data)
+	case kind == KindRetry && !bytes.Equal(data, original.response):
+		outcome.Replayed = "different response"
+	}
+	report.count(kind, len(collection), outcome, latency)
+}
+
+func sampleMemory(ctx context.Context, sampler MemorySampler, every time.Duration, start time.Time) []MemorySample {
+	var samples []MemorySample
+	sample := func() {
+		if bytes, err := sampler(); err == nil {
+			samples = append(samples, MemorySample{At: time.Since(start), Bytes: bytes})
+		}
+	}
+
+	sample()
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			sample()
+		case <-ctx.Done():
+			sample()
+			return samples
+		}
+	}
+}
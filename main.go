@@ -1,28 +1,23 @@
 package main
 
 import (
-	"github.com/gorilla/mux"
+	"flag"
 	"log"
-	"net/http"
-	"sync"
-	"universalsdk/controller"
-	"universalsdk/service"
+	"universalsdk/config"
+	"universalsdk/server"
 )
 
 func main() {
 
-	router := mux.NewRouter()
+	configPath := flag.String("config", "", "path to the config file")
+	flag.Parse()
 
-	var sessionKeyMap sync.Map
-
-	usdkService := service.NewUsdkService(&sessionKeyMap)
-	usdkController := controller.NewUsdkController(usdkService)
-
-	router.HandleFunc("/isgood", usdkController.DeviceCheck).Methods("POST")
-
-	log.Println("##  Starting Server on Port 8080")
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		log.Fatal("Error while loading config ", err)
+	}
 
-	err := http.ListenAndServe(":8080", router)
+	err = server.Run(cfg)
 	if err != nil {
 		log.Fatal("Error while initializing server", err)
 	}
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"fmt"
+	"universalsdk/config"
+)
+
+// Names of the middlewares in the configured order
+const (
+	NameRequestID = "requestId"
+	NameRecover   = "recover"
+	NameAccessLog = "accessLog"
+	NameTimeout   = "timeout"
+	NameBodyLimit = "bodyLimit"
+)
+
+// FromConfig returns the middlewares named in cfg.Order, outermost first.
+// A timeout or body limit of 0 leaves that middleware out.
+func FromConfig(cfg config.MiddlewareConfig) ([]Middleware, error) {
+	middlewares := make([]Middleware, 0, len(cfg.Order))
+	seen := make(map[string]bool)
+	for _, name := range cfg.Order {
+		if seen[name] {
+			return nil, fmt.Errorf("middleware %s is listed twice", name)
+		}
+		seen[name] = true
+
+		switch name {
+		case NameRequestID:
+			middlewares = append(middlewares, RequestID(cfg.RequestIDHeader))
+		case NameRecover:
+			middlewares = append(middlewares, Recover())
+		case NameAccessLog:
+			middlewares = append(middlewares, AccessLog())
+		case NameTimeout:
+			if cfg.Timeout > 0 {
+				middlewares = append(middlewares, Timeout(cfg.Timeout))
+			}
+		case NameBodyLimit:
+			if cfg.MaxBodyBytes > 0 {
+				middlewares = append(middlewares, BodyLimit(cfg.MaxBodyBytes))
+			}
+		default:
+			return nil, fmt.Errorf("middleware %s invalid, valid middlewares are %s, %s, %s, %s, %s",
+				name, NameRequestID, NameRecover, NameAccessLog, NameTimeout, NameBodyLimit)
+		}
+	}
+	return middlewares, nil
+}
@@ -0,0 +1,158 @@
+// Package middleware holds the cross-cutting behaviour of the REST server:
+// request IDs, panic recovery, access logging, timeouts and body limits.
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"net/http"
+	"regexp"
+	"runtime/debug"
+	"strconv"
+	"time"
+	"universalsdk/models"
+	"universalsdk/util"
+)
+
+// Middleware wraps a handler with additional behaviour
+type Middleware func(http.Handler) http.Handler
+
+// Chain wraps h with the middlewares, the first one being the outermost
+func Chain(h http.Handler, middlewares ...Middleware) http.Handler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		h = middlewares[i](h)
+	}
+	return h
+}
+
+// DefaultRequestIDHeader carries the request ID of a request and its response
+const DefaultRequestIDHeader = "X-Request-Id"
+
+type requestIDKey struct{}
+
+// validRequestID limits the request IDs taken from the client to what is safe to log
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,128}$`)
+
+// RequestID gives every request an ID, the one sent in header or a random
+// one, available with RequestIDFromContext and returned in the same header
+func RequestID(header string) Middleware {
+	if header == "" {
+		header = DefaultRequestIDHeader
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get(header)
+			if !validRequestID.MatchString(id) {
+				id = newRequestID()
+			}
+			w.Header().Set(header, id)
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id)))
+		})
+	}
+}
+
+// RequestIDFromContext returns the ID given by the RequestID middleware
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// statusRecorder captures the status code written by a handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status, r.wroteHeader = status, true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	if !r.wroteHeader {
+		r.status, r.wroteHeader = http.StatusOK, true
+	}
+	return r.ResponseWriter.Write(b)
+}
+
+// Recover turns a panic of a handler into a 500 ErrorObject, logging the stack
+func Recover() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rec := &statusRecorder{ResponseWriter: w}
+			defer func() {
+				p := recover()
+				if p == nil {
+					return
+				}
+				if p == http.ErrAbortHandler {
+					panic(p)
+				}
+				log.Printf(" ## Panic serving %s %s request=%s: %v\n%s", r.Method, r.URL.Path, RequestIDFromContext(r.Context()), p, debug.Stack())
+				if !rec.wroteHeader {
+					errorObj := models.ErrorObject{Code: models.ErrorCodeInternal, Message: "internal server error"}
+					util.RespondWithStatus(w, http.StatusInternalServerError, errorObj)
+				}
+			}()
+			next.ServeHTTP(rec, r)
+		})
+	}
+}
+
+// AccessLog logs every request with its outcome and duration
+func AccessLog() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(rec, r)
+			LogRequest("rest", r.Method+" "+r.URL.Path, util.CallerIdentity(r), strconv.Itoa(rec.status), RequestIDFromContext(r.Context()), time.Since(start))
+		})
+	}
+}
+
+// LogRequest writes the access log line, in the same format for every transport
+func LogRequest(transport, method, caller, result, requestID string, elapsed time.Duration) {
+	log.Printf("## %s %s caller=%s result=%s duration=%s request=%s", transport, method, caller, result, elapsed, requestID)
+}
+
+// Timeout answers a 503 ErrorObject to requests not handled within d.
+// The context of the request is cancelled at the same time.
+func Timeout(d time.Duration) Middleware {
+	body := `{"code":` + strconv.FormatInt(models.ErrorCodeTimeout, 10) + `,"message":"request timed out"}`
+	return func(next http.Handler) http.Handler {
+		timeout := http.TimeoutHandler(next, d, body)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Only sent with the timeout body, handlers set their own Content-Type
+			w.Header().Set("Content-Type", "application/json")
+			timeout.ServeHTTP(w, r)
+		})
+	}
+}
+
+// BodyLimit rejects request bodies larger than n bytes with a 413 ErrorObject.
+// Bodies without Content-Length fail when read past the limit.
+func BodyLimit(n int64) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > n {
+				errorObj := models.ErrorObject{Code: models.ErrorCodeInvalidRequest, Message: "request body too large"}
+				util.RespondWithStatus(w, http.StatusRequestEntityTooLarge, errorObj)
+				return
+			}
+			r.Body = http.MaxBytesReader(w, r.Body, n)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package middleware
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"universalsdk/config"
+	"universalsdk/models"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type MiddlewareSuite struct {
+	suite.Suite
+}
+
+func TestMiddlewareSuite(t *testing.T) {
+	suite.Run(t, new(MiddlewareSuite))
+}
+
+func (suite *MiddlewareSuite) TestChainOrder() {
+	var order []string
+	trace := func(name string) Middleware {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				order = append(order, name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+	handler := Chain(http.NotFoundHandler(), trace("a"), trace("b"), trace("c"))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	suite.Equal([]string{"a", "b", "c"}, order)
+}
+
+func (suite *MiddlewareSuite) TestRequestID() {
+	var seen string
+	handler := RequestID("")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = RequestIDFromContext(r.Context())
+	}))
+
+	response := httptest.NewRecorder()
+	handler.ServeHTTP(response, httptest.NewRequest("GET", "/", nil))
+	suite.Len(seen, 32)
+	suite.Equal(seen, response.Header().Get(DefaultRequestIDHeader))
+
+	// A valid ID of the client is kept, anything else is replaced
+	for id, kept := range map[string]bool{"abc-123": true, "bad id\n": false, strings.Repeat("a", 129): false} {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set(DefaultRequestIDHeader, id)
+		response = httptest.NewRecorder()
+		handler.ServeHTTP(response, req)
+		suite.Equal(kept, seen == id, id)
+		suite.Equal(seen, response.Header().Get(DefaultRequestIDHeader))
+	}
+}
+
+func (suite *MiddlewareSuite) TestRecover() {
+	handler := Recover()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+	response := httptest.NewRecorder()
+	handler.ServeHTTP(response, httptest.NewRequest("POST", "/isgood", nil))
+
+	suite.Equal(http.StatusInternalServerError, response.Code)
+	errorObj := decodeErrorObject(response)
+	suite.Equal(models.ErrorCodeInternal, errorObj.Code)
+	suite.NotContains(response.Body.String(), "boom")
+}
+
+func (suite *MiddlewareSuite) TestTimeout() {
+	handler := Timeout(10 * time.Millisecond)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	response := httptest.NewRecorder()
+	handler.ServeHTTP(response, httptest.NewRequest("POST", "/isgood", nil))
+
+	suite.Equal(http.StatusServiceUnavailable, response.Code)
+	suite.Equal("application/json", response.Header().Get("Content-Type"))
+	suite.Equal(models.ErrorCodeTimeout, decodeErrorObject(response).Code)
+}
+
+func (suite *MiddlewareSuite) TestBodyLimit() {
+	var readErr error
+	handler := BodyLimit(10)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, readErr = io.ReadAll(r.Body)
+	}))
+
+	response := httptest.NewRecorder()
+	handler.ServeHTTP(response, httptest.NewRequest("POST", "/isgood", strings.NewReader(strings.Repeat("a", 11))))
+	suite.Equal(http.StatusRequestEntityTooLarge, response.Code)
+	suite.Equal(models.ErrorCodeInvalidRequest, decodeErrorObject(response).Code)
+
+	// Without Content-Length reading past the limit fails
+	req := httptest.NewRequest("POST", "/isgood", strings.NewReader(strings.Repeat("a", 11)))
+	req.ContentLength = -1
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	var maxBytesErr *http.MaxBytesError
+	suite.ErrorAs(readErr, &maxBytesErr)
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/isgood", strings.NewReader("small")))
+	suite.NoError(readErr)
+}
+
+func (suite *MiddlewareSuite) TestFromConfig() {
+	middlewares, err := FromConfig(config.MiddlewareConfig{Order: []string{NameRequestID, NameAccessLog, NameRecover, NameTimeout, NameBodyLimit}})
+	suite.NoError(err)
+	suite.Len(middlewares, 3)
+
+	_, err = FromConfig(config.MiddlewareConfig{Order: []string{NameRecover, "gzip"}})
+	suite.Error(err)
+	_, err = FromConfig(config.MiddlewareConfig{Order: []string{NameRecover, NameRecover}})
+	suite.EqualError(err, "middleware recover is listed twice")
+}
+
+func decodeErrorObject(response *httptest.ResponseRecorder) models.ErrorObject {
+	var errorObj models.ErrorObject
+	json.Unmarshal(response.Body.Bytes(), &errorObj)
+	return errorObj
+}
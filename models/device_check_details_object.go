@@ -25,7 +25,7 @@ type DeviceCheckDetailsObject struct {
 	// A collection of loosely typed Key-Value-Pairs, which contain arbitrary data to be passed on to the verification services.
 	// The API will verify that:
 	//
-	//   * the list of "Keys" provided are unique to the call (no double-ups)
+	//   * the list of "Keys" provided are unique (no double-ups) within the check, or the whole call or checkSessionKey depending on the configured key scope
 	//   * that the Value provided matches the Type specified.
 	//
 	// Should the verification fail, the error message returned will include information for each KVP pair that fails.
@@ -40,7 +40,8 @@ type DeviceCheckDetailsObject struct {
 	//  - CONFIRMATION: User has confirmed an action and you wish to double check they're still legitimate
 	//
 	//  You can also supply vendor specific activityTypes if you know them. To do this, make the first character an underscore _.
-	//  So for example, to use BioCatch's LOGIN_3 type, you can send "_LOGIN_3" as a value. Note, if you do this, there is no error checking on the Frankie side, and thus if you supply an incorrect value, the call will fail.
+	//  So for example, to use BioCatch's LOGIN_3 type, you can send "_LOGIN_3" as a value. When the server has a registry of vendor activityTypes
+	//  the value is checked against the ones registered for the checkType, otherwise there is no error checking on the Frankie side, and thus if you supply an incorrect value, the call will fail.
 	//
 	// Enum: [SIGNUP LOGIN PAYMENT CONFIRMATION _<Vendor Specific List>]
 	ActivityType string `json:"activityType,omitempty"`
@@ -58,6 +59,12 @@ type DeviceCheckDetailsObject struct {
 	//
 	// Enum: [DEVICE BIOMETRIC COMBO]
 	CheckType string `json:"checkType,omitempty"`
+
+	// Groups the checks of one user journey, such as a login followed by a payment and its confirmation.
+	// Each check of the journey still has its own unique checkSessionKey, and its activityType must be allowed
+	// after the activityType of the previous check of the journey.
+	//
+	JourneyID string `json:"journeyId,omitempty"`
 }
 
 // Validate validates this device check details object
@@ -0,0 +1,68 @@
+package models
+
+import (
+	"strings"
+	"sync"
+
+	strfmt "github.com/go-openapi/strfmt"
+)
+
+// The values of the generated enums as sets, built on first use since the
+// enums are filled by init functions
+var (
+	enumSetsOnce    sync.Once
+	checkTypeSet    map[string]struct{}
+	activityTypeSet map[string]struct{}
+	kvpTypeSet      map[EnumKVPType]struct{}
+)
+
+func buildEnumSets() {
+	checkTypeSet = make(map[string]struct{})
+	for _, v := range deviceCheckDetailsObjectTypeCheckTypePropEnum {
+		checkTypeSet[v.(string)] = struct{}{}
+	}
+	activityTypeSet = make(map[string]struct{})
+	for _, v := range deviceCheckDetailsObjectTypeActivityTypePropEnum {
+		activityTypeSet[v.(string)] = struct{}{}
+	}
+	kvpTypeSet = make(map[EnumKVPType]struct{})
+	for _, v := range enumKVPTypeEnum {
+		kvpTypeSet[v.(EnumKVPType)] = struct{}{}
+	}
+}
+
+// ValidateFast returns the same error as Validate. A valid collection is
+// checked with set lookups, without the reflection and allocations of the
+// generated validate.Enum calls, which only run to describe an invalid one.
+func (m DeviceCheckDetailsObjectCollection) ValidateFast(formats strfmt.Registry) error {
+	enumSetsOnce.Do(buildEnumSets)
+
+	for _, elem := range m {
+		if elem != nil && !elem.validEnums() {
+			return m.Validate(formats)
+		}
+	}
+	return nil
+}
+
+func (m *DeviceCheckDetailsObject) validEnums() bool {
+	if m.CheckType != "" {
+		if _, ok := checkTypeSet[m.CheckType]; !ok {
+			return false
+		}
+	}
+	if m.ActivityType != "" && !strings.HasPrefix(m.ActivityType, "_") {
+		if _, ok := activityTypeSet[m.ActivityType]; !ok {
+			return false
+		}
+	}
+	for _, kvp := range m.ActivityData {
+		if kvp == nil || kvp.KvpType == "" {
+			continue
+		}
+		if _, ok := kvpTypeSet[kvp.KvpType]; !ok {
+			return false
+		}
+	}
+	return true
+}
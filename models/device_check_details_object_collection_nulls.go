@@ -0,0 +1,26 @@
+package models
+
+import (
+	"strconv"
+
+	"github.com/go-openapi/errors"
+)
+
+// ValidateNotNull reports every null check and null activity data entry of
+// the collection. The generated Validate skips them, but no layer after
+// validation expects them.
+func (m DeviceCheckDetailsObjectCollection) ValidateNotNull() []error {
+	var errs []error
+	for i, elem := range m {
+		if elem == nil {
+			errs = append(errs, errors.Required(strconv.Itoa(i), "body"))
+			continue
+		}
+		for j, kvp := range elem.ActivityData {
+			if kvp == nil {
+				errs = append(errs, errors.Required(strconv.Itoa(i)+".activityData."+strconv.Itoa(j), "body"))
+			}
+		}
+	}
+	return errs
+}
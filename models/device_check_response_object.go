@@ -0,0 +1,89 @@
+package models
+
+import (
+	"strconv"
+
+	strfmt "github.com/go-openapi/strfmt"
+
+	"github.com/go-openapi/errors"
+	"github.com/go-openapi/swag"
+)
+
+// DeviceCheckResponseObject The response of a device check: the verdict of the
+// generated PuppyObject along with how it was reached. The verdict is embedded
+// so the response encodes as a PuppyObject with the extra fields beside it.
+// swagger:model DeviceCheckResponseObject
+type DeviceCheckResponseObject struct {
+
+	// The velocity rules exceeded by the checks of the request
+	Flags []*VelocityFlagObject `json:"flags,omitempty"`
+
+	// The allow or deny list entries that forced the outcome of checks of the request
+	ListMatches []*ListMatchObject `json:"listMatches,omitempty"`
+
+	PuppyObject
+
+	// One result per check of the request, in request order. Only returned when enabled.
+	Results []*DeviceCheckResultObject `json:"results,omitempty"`
+}
+
+// Validate validates this device check response object
+func (m *DeviceCheckResponseObject) Validate(formats strfmt.Registry) error {
+	var res []error
+
+	if err := m.PuppyObject.Validate(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.validateResults(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if len(res) > 0 {
+		return errors.CompositeValidationError(res...)
+	}
+	return nil
+}
+
+func (m *DeviceCheckResponseObject) validateResults(formats strfmt.Registry) error {
+
+	if swag.IsZero(m.Results) { // not required
+		return nil
+	}
+
+	for i := 0; i < len(m.Results); i++ {
+		if swag.IsZero(m.Results[i]) { // not required
+			continue
+		}
+
+		if m.Results[i] != nil {
+			if err := m.Results[i].Validate(formats); err != nil {
+				if ve, ok := err.(*errors.Validation); ok {
+					return ve.ValidateName("results" + "." + strconv.Itoa(i))
+				}
+				return err
+			}
+		}
+
+	}
+
+	return nil
+}
+
+// MarshalBinary interface implementation
+func (m *DeviceCheckResponseObject) MarshalBinary() ([]byte, error) {
+	if m == nil {
+		return nil, nil
+	}
+	return swag.WriteJSON(m)
+}
+
+// UnmarshalBinary interface implementation
+func (m *DeviceCheckResponseObject) UnmarshalBinary(b []byte) error {
+	var res DeviceCheckResponseObject
+	if err := swag.ReadJSON(b, &res); err != nil {
+		return err
+	}
+	*m = res
+	return nil
+}
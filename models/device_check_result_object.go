@@ -0,0 +1,78 @@
+package models
+
+import (
+	"strconv"
+
+	strfmt "github.com/go-openapi/strfmt"
+
+	"github.com/go-openapi/errors"
+	"github.com/go-openapi/swag"
+)
+
+// DeviceCheckResultObject The outcome of a single DeviceCheckDetailsObject of the request.
+// swagger:model DeviceCheckResultObject
+type DeviceCheckResultObject struct {
+
+	// The activity data of the check, with every value in its canonical form
+	ActivityData []*KeyValuePairObject `json:"activityData,omitempty"`
+
+	// The session key of the check
+	CheckSessionKey string `json:"checkSessionKey,omitempty"`
+}
+
+// Validate validates this device check result object
+func (m *DeviceCheckResultObject) Validate(formats strfmt.Registry) error {
+	var res []error
+
+	if err := m.validateActivityData(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if len(res) > 0 {
+		return errors.CompositeValidationError(res...)
+	}
+	return nil
+}
+
+func (m *DeviceCheckResultObject) validateActivityData(formats strfmt.Registry) error {
+
+	if swag.IsZero(m.ActivityData) { // not required
+		return nil
+	}
+
+	for i := 0; i < len(m.ActivityData); i++ {
+		if swag.IsZero(m.ActivityData[i]) { // not required
+			continue
+		}
+
+		if m.ActivityData[i] != nil {
+			if err := m.ActivityData[i].Validate(formats); err != nil {
+				if ve, ok := err.(*errors.Validation); ok {
+					return ve.ValidateName("activityData" + "." + strconv.Itoa(i))
+				}
+				return err
+			}
+		}
+
+	}
+
+	return nil
+}
+
+// MarshalBinary interface implementation
+func (m *DeviceCheckResultObject) MarshalBinary() ([]byte, error) {
+	if m == nil {
+		return nil, nil
+	}
+	return swag.WriteJSON(m)
+}
+
+// UnmarshalBinary interface implementation
+func (m *DeviceCheckResultObject) UnmarshalBinary(b []byte) error {
+	var res DeviceCheckResultObject
+	if err := swag.ReadJSON(b, &res); err != nil {
+		return err
+	}
+	*m = res
+	return nil
+}
@@ -0,0 +1,44 @@
+package models
+
+// The id.*, pii.* and raw.* kvpTypes are not in the swagger source of
+// EnumKVPType yet. They are added to its enum here rather than in the
+// generated file, which go-swagger would overwrite.
+const (
+
+	// EnumKVPTypeIDExternal captures enum value "id.external"
+	EnumKVPTypeIDExternal EnumKVPType = "id.external"
+
+	// EnumKVPTypePiiName captures enum value "pii.name"
+	EnumKVPTypePiiName EnumKVPType = "pii.name"
+
+	// EnumKVPTypePiiAddress captures enum value "pii.address"
+	EnumKVPTypePiiAddress EnumKVPType = "pii.address"
+
+	// EnumKVPTypePiiEmail captures enum value "pii.email"
+	EnumKVPTypePiiEmail EnumKVPType = "pii.email"
+
+	// EnumKVPTypePiiPhone captures enum value "pii.phone"
+	EnumKVPTypePiiPhone EnumKVPType = "pii.phone"
+
+	// EnumKVPTypePiiDate captures enum value "pii.date"
+	EnumKVPTypePiiDate EnumKVPType = "pii.date"
+
+	// EnumKVPTypeRawJSON captures enum value "raw.json"
+	EnumKVPTypeRawJSON EnumKVPType = "raw.json"
+
+	// EnumKVPTypeRawXML captures enum value "raw.xml"
+	EnumKVPTypeRawXML EnumKVPType = "raw.xml"
+
+	// EnumKVPTypeRawBase64 captures enum value "raw.base64"
+	EnumKVPTypeRawBase64 EnumKVPType = "raw.base64"
+)
+
+func init() {
+	for _, v := range []EnumKVPType{
+		EnumKVPTypeIDExternal,
+		EnumKVPTypePiiName, EnumKVPTypePiiAddress, EnumKVPTypePiiEmail, EnumKVPTypePiiPhone, EnumKVPTypePiiDate,
+		EnumKVPTypeRawJSON, EnumKVPTypeRawXML, EnumKVPTypeRawBase64,
+	} {
+		enumKVPTypeEnum = append(enumKVPTypeEnum, v)
+	}
+}
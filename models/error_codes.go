@@ -0,0 +1,26 @@
+package models
+
+// Codes returned in ErrorObject.Code. They are part of the API contract
+// and must not be renumbered.
+const (
+	// ErrorCodeContentType the request Content-Type is not supported
+	ErrorCodeContentType int64 = 1
+
+	// ErrorCodeInvalidRequest the request could not be parsed or failed schema validation
+	ErrorCodeInvalidRequest int64 = 2
+
+	// ErrorCodeCheckFailed the request failed the business validations of the service
+	ErrorCodeCheckFailed int64 = 3
+
+	// ErrorCodeUnauthorized the request lacks valid credentials
+	ErrorCodeUnauthorized int64 = 4
+
+	// ErrorCodeNotFound the requested resource does not exist
+	ErrorCodeNotFound int64 = 5
+
+	// ErrorCodeInternal the server failed while processing the request
+	ErrorCodeInternal int64 = 6
+
+	// ErrorCodeTimeout the request was not processed in time
+	ErrorCodeTimeout int64 = 7
+)
@@ -0,0 +1,58 @@
+package models
+
+import (
+	strfmt "github.com/go-openapi/strfmt"
+
+	"github.com/go-openapi/swag"
+)
+
+// JourneyObject is the history of the checks of a journey
+// swagger:model JourneyObject
+type JourneyObject struct {
+
+	// The journey ID shared by the checks
+	JourneyID string `json:"journeyId"`
+
+	// The successful checks of the journey, oldest first
+	Steps []*JourneyStepObject `json:"steps"`
+}
+
+// Validate validates this journey object
+func (m *JourneyObject) Validate(formats strfmt.Registry) error {
+	return nil
+}
+
+// MarshalBinary interface implementation
+func (m *JourneyObject) MarshalBinary() ([]byte, error) {
+	if m == nil {
+		return nil, nil
+	}
+	return swag.WriteJSON(m)
+}
+
+// UnmarshalBinary interface implementation
+func (m *JourneyObject) UnmarshalBinary(b []byte) error {
+	var res JourneyObject
+	if err := swag.ReadJSON(b, &res); err != nil {
+		return err
+	}
+	*m = res
+	return nil
+}
+
+// JourneyStepObject describes one check of a journey
+// swagger:model JourneyStepObject
+type JourneyStepObject struct {
+
+	// The activity type of the check
+	ActivityType string `json:"activityType,omitempty"`
+
+	// The session key of the check
+	CheckSessionKey string `json:"checkSessionKey,omitempty"`
+
+	// The check type of the check
+	CheckType string `json:"checkType,omitempty"`
+
+	// When the check passed
+	CheckedAt strfmt.DateTime `json:"checkedAt"`
+}
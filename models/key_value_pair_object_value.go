@@ -0,0 +1,51 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Typed accessors of KeyValuePairObject. They parse KvpValue according to
+// KvpType, so rules and providers do not have to re-implement the parsing.
+
+// IntValue returns the value of a general.integer pair
+func (m *KeyValuePairObject) IntValue() (int64, error) {
+	if m.KvpType != EnumKVPTypeGeneralInteger {
+		return 0, m.typeError(EnumKVPTypeGeneralInteger)
+	}
+	return strconv.ParseInt(m.KvpValue, 10, 64)
+}
+
+// FloatValue returns the value of a general.float or general.integer pair
+func (m *KeyValuePairObject) FloatValue() (float64, error) {
+	if m.KvpType != EnumKVPTypeGeneralFloat && m.KvpType != EnumKVPTypeGeneralInteger {
+		return 0, m.typeError(EnumKVPTypeGeneralFloat)
+	}
+	return strconv.ParseFloat(m.KvpValue, 64)
+}
+
+// BoolValue returns the value of a general.bool pair
+func (m *KeyValuePairObject) BoolValue() (bool, error) {
+	if m.KvpType != EnumKVPTypeGeneralBool {
+		return false, m.typeError(EnumKVPTypeGeneralBool)
+	}
+	return strconv.ParseBool(m.KvpValue)
+}
+
+// Value returns the parsed value as int64, float64, bool or string depending on KvpType
+func (m *KeyValuePairObject) Value() (interface{}, error) {
+	switch m.KvpType {
+	case EnumKVPTypeGeneralInteger:
+		return m.IntValue()
+	case EnumKVPTypeGeneralFloat:
+		return m.FloatValue()
+	case EnumKVPTypeGeneralBool:
+		return m.BoolValue()
+	default:
+		return m.KvpValue, nil
+	}
+}
+
+func (m *KeyValuePairObject) typeError(expected EnumKVPType) error {
+	return fmt.Errorf("kvpKey %s is of type %s, not %s", m.KvpKey, m.KvpType, expected)
+}
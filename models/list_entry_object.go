@@ -0,0 +1,188 @@
+package models
+
+import (
+	"encoding/json"
+
+	strfmt "github.com/go-openapi/strfmt"
+
+	"github.com/go-openapi/errors"
+	"github.com/go-openapi/swag"
+	"github.com/go-openapi/validate"
+)
+
+// ListEntryObject An entry of the allow or deny list, matched against the value of a kvpKey of the activity data.
+// swagger:model ListEntryObject
+type ListEntryObject struct {
+
+	// Only checks of this caller are matched, every caller when empty
+	Caller string `json:"caller,omitempty"`
+
+	// When the entry was added
+	// Read Only: true
+	CreatedAt strfmt.DateTime `json:"createdAt,omitempty"`
+
+	// The entry is ignored from this time on, it never expires when empty
+	ExpiresAt *strfmt.DateTime `json:"expiresAt,omitempty"`
+
+	// Generated when the entry is added
+	// Read Only: true
+	ID string `json:"id,omitempty"`
+
+	// The kvpKey whose value is matched
+	// Required: true
+	KvpKey string `json:"kvpKey"`
+
+	// "allow" forces checks to pass, "deny" forces them to fail
+	// Required: true
+	// Enum: [allow deny]
+	List string `json:"list"`
+
+	// How value is matched:
+	//
+	//   - exact: the kvpValue equals value
+	//   - cidr: the kvpValue is an IP address within the CIDR range value
+	//   - pattern: the kvpValue matches the glob value, where * matches any text and ? any character
+	//
+	// Required: true
+	// Enum: [exact cidr pattern]
+	Match string `json:"match"`
+
+	// Why the entry was added, returned with every match
+	Reason string `json:"reason,omitempty"`
+
+	// The value, CIDR range or pattern matched
+	// Required: true
+	Value string `json:"value"`
+}
+
+// Validate validates this list entry object
+func (m *ListEntryObject) Validate(formats strfmt.Registry) error {
+	var res []error
+
+	if err := validate.RequiredString("kvpKey", "body", m.KvpKey); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.validateList(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.validateMatch(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := validate.RequiredString("value", "body", m.Value); err != nil {
+		res = append(res, err)
+	}
+
+	if len(res) > 0 {
+		return errors.CompositeValidationError(res...)
+	}
+	return nil
+}
+
+var listEntryObjectTypeListPropEnum []interface{}
+
+var listEntryObjectTypeMatchPropEnum []interface{}
+
+func init() {
+	var res []string
+	if err := json.Unmarshal([]byte(`["allow","deny"]`), &res); err != nil {
+		panic(err)
+	}
+	for _, v := range res {
+		listEntryObjectTypeListPropEnum = append(listEntryObjectTypeListPropEnum, v)
+	}
+
+	res = nil
+	if err := json.Unmarshal([]byte(`["exact","cidr","pattern"]`), &res); err != nil {
+		panic(err)
+	}
+	for _, v := range res {
+		listEntryObjectTypeMatchPropEnum = append(listEntryObjectTypeMatchPropEnum, v)
+	}
+}
+
+const (
+
+	// ListEntryObjectListAllow captures enum value "allow"
+	ListEntryObjectListAllow string = "allow"
+
+	// ListEntryObjectListDeny captures enum value "deny"
+	ListEntryObjectListDeny string = "deny"
+
+	// ListEntryObjectMatchExact captures enum value "exact"
+	ListEntryObjectMatchExact string = "exact"
+
+	// ListEntryObjectMatchCidr captures enum value "cidr"
+	ListEntryObjectMatchCidr string = "cidr"
+
+	// ListEntryObjectMatchPattern captures enum value "pattern"
+	ListEntryObjectMatchPattern string = "pattern"
+)
+
+func (m *ListEntryObject) validateList(formats strfmt.Registry) error {
+
+	if err := validate.RequiredString("list", "body", m.List); err != nil {
+		return err
+	}
+
+	// value enum
+	if err := validate.Enum("list", "body", m.List, listEntryObjectTypeListPropEnum); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (m *ListEntryObject) validateMatch(formats strfmt.Registry) error {
+
+	if err := validate.RequiredString("match", "body", m.Match); err != nil {
+		return err
+	}
+
+	// value enum
+	if err := validate.Enum("match", "body", m.Match, listEntryObjectTypeMatchPropEnum); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// MarshalBinary interface implementation
+func (m *ListEntryObject) MarshalBinary() ([]byte, error) {
+	if m == nil {
+		return nil, nil
+	}
+	return swag.WriteJSON(m)
+}
+
+// UnmarshalBinary interface implementation
+func (m *ListEntryObject) UnmarshalBinary(b []byte) error {
+	var res ListEntryObject
+	if err := swag.ReadJSON(b, &res); err != nil {
+		return err
+	}
+	*m = res
+	return nil
+}
+
+// ListMatchObject A list entry that matched a check and forced its outcome.
+// swagger:model ListMatchObject
+type ListMatchObject struct {
+
+	// The session key of the check
+	CheckSessionKey string `json:"checkSessionKey,omitempty"`
+
+	// The id of the matching entry
+	EntryID string `json:"entryId"`
+
+	// The kvpKey whose value matched
+	KvpKey string `json:"kvpKey"`
+
+	// "allow" or "deny"
+	List string `json:"list"`
+
+	// The reason of the entry
+	Reason string `json:"reason,omitempty"`
+}
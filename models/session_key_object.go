@@ -0,0 +1,52 @@
+package models
+
+import (
+	strfmt "github.com/go-openapi/strfmt"
+
+	"github.com/go-openapi/swag"
+)
+
+// SessionKeyObject describes a reserved checkSessionKey
+// swagger:model SessionKeyObject
+type SessionKeyObject struct {
+
+	// The reserved session key
+	CheckSessionKey string `json:"checkSessionKey"`
+
+	// When the session key was first used
+	ReservedAt strfmt.DateTime `json:"reservedAt"`
+}
+
+// Validate validates this session key object
+func (m *SessionKeyObject) Validate(formats strfmt.Registry) error {
+	return nil
+}
+
+// MarshalBinary interface implementation
+func (m *SessionKeyObject) MarshalBinary() ([]byte, error) {
+	if m == nil {
+		return nil, nil
+	}
+	return swag.WriteJSON(m)
+}
+
+// UnmarshalBinary interface implementation
+func (m *SessionKeyObject) UnmarshalBinary(b []byte) error {
+	var res SessionKeyObject
+	if err := swag.ReadJSON(b, &res); err != nil {
+		return err
+	}
+	*m = res
+	return nil
+}
+
+// SessionKeyListObject lists reserved session keys
+// swagger:model SessionKeyListObject
+type SessionKeyListObject struct {
+
+	// Number of matching session keys
+	Count int64 `json:"count"`
+
+	// The matching session keys, omitted when only counting
+	SessionKeys []*SessionKeyObject `json:"sessionKeys,omitempty"`
+}
@@ -0,0 +1,58 @@
+package models
+
+import (
+	strfmt "github.com/go-openapi/strfmt"
+
+	"github.com/go-openapi/swag"
+)
+
+// VelocityFlagObject A velocity rule exceeded by a check, or not enforced.
+// swagger:model VelocityFlagObject
+type VelocityFlagObject struct {
+
+	// What the rule does when exceeded, "reject" fails the check and "flag" only reports it
+	// Enum: [reject flag]
+	Action string `json:"action"`
+
+	// The session key of the check
+	CheckSessionKey string `json:"checkSessionKey,omitempty"`
+
+	// Number of checks counted within the window, including this one
+	Count int64 `json:"count"`
+
+	// Number of checks allowed within the window
+	Limit int64 `json:"limit"`
+
+	// The counter of the rule couldn't be updated and Count is 0. Action is the one
+	// applied: "flag" when the rule fails open, "reject" when it fails closed.
+	NotEnforced bool `json:"notEnforced,omitempty"`
+
+	// Name of the velocity rule
+	Rule string `json:"rule"`
+
+	// The sliding window of the rule, e.g. "1h0m0s"
+	Window string `json:"window"`
+}
+
+// Validate validates this velocity flag object
+func (m *VelocityFlagObject) Validate(formats strfmt.Registry) error {
+	return nil
+}
+
+// MarshalBinary interface implementation
+func (m *VelocityFlagObject) MarshalBinary() ([]byte, error) {
+	if m == nil {
+		return nil, nil
+	}
+	return swag.WriteJSON(m)
+}
+
+// UnmarshalBinary interface implementation
+func (m *VelocityFlagObject) UnmarshalBinary(b []byte) error {
+	var res VelocityFlagObject
+	if err := swag.ReadJSON(b, &res); err != nil {
+		return err
+	}
+	*m = res
+	return nil
+}
@@ -0,0 +1,159 @@
+package usdkpb
+
+import (
+	"universalsdk/models"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// SignedBytes returns the encoding of a response its signing.Trailer
+// signs, the deterministic protobuf encoding of the message
+func SignedBytes(m *PuppyObject) ([]byte, error) {
+	return proto.MarshalOptions{Deterministic: true}.Marshal(m)
+}
+
+// ToCollection converts the request to the swagger model used by the service
+func (x *DeviceCheckRequest) ToCollection() models.DeviceCheckDetailsObjectCollection {
+	checks := x.GetChecks()
+	collection := make(models.DeviceCheckDetailsObjectCollection, len(checks))
+	for i, check := range checks {
+		if check == nil {
+			continue
+		}
+		collection[i] = &models.DeviceCheckDetailsObject{
+			ActivityData:    toKeyValuePairs(check.GetActivityData()),
+			ActivityType:    check.GetActivityType(),
+			CheckSessionKey: check.GetCheckSessionKey(),
+			CheckType:       check.GetCheckType(),
+			JourneyID:       check.GetJourneyId(),
+		}
+	}
+	return collection
+}
+
+// FromCollection converts the swagger model to a request message
+func FromCollection(collection models.DeviceCheckDetailsObjectCollection) *DeviceCheckRequest {
+	req := &DeviceCheckRequest{Checks: make([]*DeviceCheckDetailsObject, 0, len(collection))}
+	for _, elem := range collection {
+		if elem == nil {
+			req.Checks = append(req.Checks, &DeviceCheckDetailsObject{})
+			continue
+		}
+		req.Checks = append(req.Checks, &DeviceCheckDetailsObject{
+			ActivityData:    fromKeyValuePairs(elem.ActivityData),
+			ActivityType:    elem.ActivityType,
+			CheckSessionKey: elem.CheckSessionKey,
+			CheckType:       elem.CheckType,
+			JourneyId:       elem.JourneyID,
+		})
+	}
+	return req
+}
+
+// FromPuppy converts the service response to its message
+func FromPuppy(puppy *models.DeviceCheckResponseObject) *PuppyObject {
+	if puppy == nil {
+		return nil
+	}
+	msg := &PuppyObject{Puppy: puppy.Puppy}
+	for _, result := range puppy.Results {
+		if result == nil {
+			continue
+		}
+		msg.Results = append(msg.Results, &DeviceCheckResultObject{
+			ActivityData:    fromKeyValuePairs(result.ActivityData),
+			CheckSessionKey: result.CheckSessionKey,
+		})
+	}
+	for _, flag := range puppy.Flags {
+		if flag == nil {
+			continue
+		}
+		msg.Flags = append(msg.Flags, &VelocityFlagObject{
+			Rule:            flag.Rule,
+			Action:          flag.Action,
+			CheckSessionKey: flag.CheckSessionKey,
+			Count:           flag.Count,
+			Limit:           flag.Limit,
+			Window:          flag.Window,
+			NotEnforced:     flag.NotEnforced,
+		})
+	}
+	for _, match := range puppy.ListMatches {
+		if match == nil {
+			continue
+		}
+		msg.ListMatches = append(msg.ListMatches, &ListMatchObject{
+			CheckSessionKey: match.CheckSessionKey,
+			EntryId:         match.EntryID,
+			KvpKey:          match.KvpKey,
+			List:            match.List,
+			Reason:          match.Reason,
+		})
+	}
+	return msg
+}
+
+// ToPuppy converts the response message to the swagger model
+func (x *PuppyObject) ToPuppy() *models.DeviceCheckResponseObject {
+	puppy := &models.DeviceCheckResponseObject{PuppyObject: models.PuppyObject{Puppy: x.GetPuppy()}}
+	for _, result := range x.GetResults() {
+		puppy.Results = append(puppy.Results, &models.DeviceCheckResultObject{
+			ActivityData:    toKeyValuePairs(result.GetActivityData()),
+			CheckSessionKey: result.GetCheckSessionKey(),
+		})
+	}
+	for _, flag := range x.GetFlags() {
+		puppy.Flags = append(puppy.Flags, &models.VelocityFlagObject{
+			Rule:            flag.GetRule(),
+			Action:          flag.GetAction(),
+			CheckSessionKey: flag.GetCheckSessionKey(),
+			Count:           flag.GetCount(),
+			Limit:           flag.GetLimit(),
+			Window:          flag.GetWindow(),
+			NotEnforced:     flag.GetNotEnforced(),
+		})
+	}
+	for _, match := range x.GetListMatches() {
+		puppy.ListMatches = append(puppy.ListMatches, &models.ListMatchObject{
+			CheckSessionKey: match.GetCheckSessionKey(),
+			EntryID:         match.GetEntryId(),
+			KvpKey:          match.GetKvpKey(),
+			List:            match.GetList(),
+			Reason:          match.GetReason(),
+		})
+	}
+	return puppy
+}
+
+func fromKeyValuePairs(kvps []*models.KeyValuePairObject) []*KeyValuePairObject {
+	var res []*KeyValuePairObject
+	for _, kvp := range kvps {
+		if kvp == nil {
+			continue
+		}
+		res = append(res, &KeyValuePairObject{
+			KvpKey:   kvp.KvpKey,
+			KvpValue: kvp.KvpValue,
+			KvpType:  string(kvp.KvpType),
+		})
+	}
+	return res
+}
+
+func toKeyValuePairs(kvps []*KeyValuePairObject) []*models.KeyValuePairObject {
+	var res []*models.KeyValuePairObject
+	for _, kvp := range kvps {
+		res = append(res, &models.KeyValuePairObject{
+			KvpKey:   kvp.GetKvpKey(),
+			KvpValue: kvp.GetKvpValue(),
+			KvpType:  models.EnumKVPType(kvp.GetKvpType()),
+		})
+	}
+	return res
+}
+
+// FromErrorObject converts an ErrorObject to its message
+func FromErrorObject(errorObj models.ErrorObject) *ErrorObject {
+	return &ErrorObject{Code: errorObj.Code, Message: errorObj.Message}
+}
@@ -0,0 +1,5 @@
+// Package usdkpb holds the protobuf messages and gRPC service generated
+// from proto/usdk.proto, plus conversions to and from the swagger models.
+package usdkpb
+
+//go:generate protoc -I .. --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative ../usdk.proto
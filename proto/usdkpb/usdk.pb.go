@@ -0,0 +1,687 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.12
+// 	protoc        (unknown)
+// source: usdk.proto
+
+package usdkpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Individual key-value pair
+type KeyValuePairObject struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Name of the data
+	KvpKey string `protobuf:"bytes,1,opt,name=kvp_key,json=kvpKey,proto3" json:"kvp_key,omitempty"`
+	// Value of the data
+	KvpValue string `protobuf:"bytes,2,opt,name=kvp_value,json=kvpValue,proto3" json:"kvp_value,omitempty"`
+	// One of the enumKVPType values, e.g. general.string
+	KvpType       string `protobuf:"bytes,3,opt,name=kvp_type,json=kvpType,proto3" json:"kvp_type,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KeyValuePairObject) Reset() {
+	*x = KeyValuePairObject{}
+	mi := &file_usdk_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KeyValuePairObject) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KeyValuePairObject) ProtoMessage() {}
+
+func (x *KeyValuePairObject) ProtoReflect() protoreflect.Message {
+	mi := &file_usdk_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KeyValuePairObject.ProtoReflect.Descriptor instead.
+func (*KeyValuePairObject) Descriptor() ([]byte, []int) {
+	return file_usdk_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *KeyValuePairObject) GetKvpKey() string {
+	if x != nil {
+		return x.KvpKey
+	}
+	return ""
+}
+
+func (x *KeyValuePairObject) GetKvpValue() string {
+	if x != nil {
+		return x.KvpValue
+	}
+	return ""
+}
+
+func (x *KeyValuePairObject) GetKvpType() string {
+	if x != nil {
+		return x.KvpType
+	}
+	return ""
+}
+
+// Details passed on to the device/biometric checking service
+type DeviceCheckDetailsObject struct {
+	state        protoimpl.MessageState `protogen:"open.v1"`
+	ActivityData []*KeyValuePairObject  `protobuf:"bytes,1,rep,name=activity_data,json=activityData,proto3" json:"activity_data,omitempty"`
+	// SIGNUP, LOGIN, PAYMENT, CONFIRMATION or a vendor specific _TYPE
+	ActivityType string `protobuf:"bytes,2,opt,name=activity_type,json=activityType,proto3" json:"activity_type,omitempty"`
+	// The unique session based ID that will be checked against the service
+	CheckSessionKey string `protobuf:"bytes,3,opt,name=check_session_key,json=checkSessionKey,proto3" json:"check_session_key,omitempty"`
+	// DEVICE, BIOMETRIC or COMBO
+	CheckType string `protobuf:"bytes,4,opt,name=check_type,json=checkType,proto3" json:"check_type,omitempty"`
+	// Groups the checks of one user journey
+	JourneyId     string `protobuf:"bytes,5,opt,name=journey_id,json=journeyId,proto3" json:"journey_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeviceCheckDetailsObject) Reset() {
+	*x = DeviceCheckDetailsObject{}
+	mi := &file_usdk_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeviceCheckDetailsObject) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeviceCheckDetailsObject) ProtoMessage() {}
+
+func (x *DeviceCheckDetailsObject) ProtoReflect() protoreflect.Message {
+	mi := &file_usdk_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeviceCheckDetailsObject.ProtoReflect.Descriptor instead.
+func (*DeviceCheckDetailsObject) Descriptor() ([]byte, []int) {
+	return file_usdk_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *DeviceCheckDetailsObject) GetActivityData() []*KeyValuePairObject {
+	if x != nil {
+		return x.ActivityData
+	}
+	return nil
+}
+
+func (x *DeviceCheckDetailsObject) GetActivityType() string {
+	if x != nil {
+		return x.ActivityType
+	}
+	return ""
+}
+
+func (x *DeviceCheckDetailsObject) GetCheckSessionKey() string {
+	if x != nil {
+		return x.CheckSessionKey
+	}
+	return ""
+}
+
+func (x *DeviceCheckDetailsObject) GetCheckType() string {
+	if x != nil {
+		return x.CheckType
+	}
+	return ""
+}
+
+func (x *DeviceCheckDetailsObject) GetJourneyId() string {
+	if x != nil {
+		return x.JourneyId
+	}
+	return ""
+}
+
+// Mirrors the DeviceCheckDetailsObjectCollection body of the REST API
+type DeviceCheckRequest struct {
+	state         protoimpl.MessageState      `protogen:"open.v1"`
+	Checks        []*DeviceCheckDetailsObject `protobuf:"bytes,1,rep,name=checks,proto3" json:"checks,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeviceCheckRequest) Reset() {
+	*x = DeviceCheckRequest{}
+	mi := &file_usdk_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeviceCheckRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeviceCheckRequest) ProtoMessage() {}
+
+func (x *DeviceCheckRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_usdk_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeviceCheckRequest.ProtoReflect.Descriptor instead.
+func (*DeviceCheckRequest) Descriptor() ([]byte, []int) {
+	return file_usdk_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *DeviceCheckRequest) GetChecks() []*DeviceCheckDetailsObject {
+	if x != nil {
+		return x.Checks
+	}
+	return nil
+}
+
+// Everyone gets a puppy if the SDK output is good.
+type PuppyObject struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Puppy bool                   `protobuf:"varint,1,opt,name=puppy,proto3" json:"puppy,omitempty"`
+	// One result per check of the request, in request order. Only returned when enabled.
+	Results []*DeviceCheckResultObject `protobuf:"bytes,2,rep,name=results,proto3" json:"results,omitempty"`
+	// The velocity rules exceeded by the checks of the request
+	Flags []*VelocityFlagObject `protobuf:"bytes,3,rep,name=flags,proto3" json:"flags,omitempty"`
+	// The allow or deny list entries that forced the outcome of checks of the request
+	ListMatches   []*ListMatchObject `protobuf:"bytes,4,rep,name=list_matches,json=listMatches,proto3" json:"list_matches,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PuppyObject) Reset() {
+	*x = PuppyObject{}
+	mi := &file_usdk_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PuppyObject) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PuppyObject) ProtoMessage() {}
+
+func (x *PuppyObject) ProtoReflect() protoreflect.Message {
+	mi := &file_usdk_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PuppyObject.ProtoReflect.Descriptor instead.
+func (*PuppyObject) Descriptor() ([]byte, []int) {
+	return file_usdk_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *PuppyObject) GetPuppy() bool {
+	if x != nil {
+		return x.Puppy
+	}
+	return false
+}
+
+func (x *PuppyObject) GetResults() []*DeviceCheckResultObject {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+func (x *PuppyObject) GetFlags() []*VelocityFlagObject {
+	if x != nil {
+		return x.Flags
+	}
+	return nil
+}
+
+func (x *PuppyObject) GetListMatches() []*ListMatchObject {
+	if x != nil {
+		return x.ListMatches
+	}
+	return nil
+}
+
+// A list entry that matched a check and forced its outcome
+type ListMatchObject struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The session key of the check
+	CheckSessionKey string `protobuf:"bytes,1,opt,name=check_session_key,json=checkSessionKey,proto3" json:"check_session_key,omitempty"`
+	// The id of the matching entry
+	EntryId string `protobuf:"bytes,2,opt,name=entry_id,json=entryId,proto3" json:"entry_id,omitempty"`
+	// The kvpKey whose value matched
+	KvpKey string `protobuf:"bytes,3,opt,name=kvp_key,json=kvpKey,proto3" json:"kvp_key,omitempty"`
+	// "allow" or "deny"
+	List string `protobuf:"bytes,4,opt,name=list,proto3" json:"list,omitempty"`
+	// The reason of the entry
+	Reason        string `protobuf:"bytes,5,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListMatchObject) Reset() {
+	*x = ListMatchObject{}
+	mi := &file_usdk_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListMatchObject) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListMatchObject) ProtoMessage() {}
+
+func (x *ListMatchObject) ProtoReflect() protoreflect.Message {
+	mi := &file_usdk_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListMatchObject.ProtoReflect.Descriptor instead.
+func (*ListMatchObject) Descriptor() ([]byte, []int) {
+	return file_usdk_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ListMatchObject) GetCheckSessionKey() string {
+	if x != nil {
+		return x.CheckSessionKey
+	}
+	return ""
+}
+
+func (x *ListMatchObject) GetEntryId() string {
+	if x != nil {
+		return x.EntryId
+	}
+	return ""
+}
+
+func (x *ListMatchObject) GetKvpKey() string {
+	if x != nil {
+		return x.KvpKey
+	}
+	return ""
+}
+
+func (x *ListMatchObject) GetList() string {
+	if x != nil {
+		return x.List
+	}
+	return ""
+}
+
+func (x *ListMatchObject) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+// A velocity rule exceeded by a check, or not enforced
+type VelocityFlagObject struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Name of the velocity rule
+	Rule string `protobuf:"bytes,1,opt,name=rule,proto3" json:"rule,omitempty"`
+	// "reject" fails the check, "flag" only reports it
+	Action string `protobuf:"bytes,2,opt,name=action,proto3" json:"action,omitempty"`
+	// The session key of the check
+	CheckSessionKey string `protobuf:"bytes,3,opt,name=check_session_key,json=checkSessionKey,proto3" json:"check_session_key,omitempty"`
+	// Number of checks counted within the window, including this one
+	Count int64 `protobuf:"varint,4,opt,name=count,proto3" json:"count,omitempty"`
+	// Number of checks allowed within the window
+	Limit int64 `protobuf:"varint,5,opt,name=limit,proto3" json:"limit,omitempty"`
+	// The sliding window of the rule, e.g. "1h0m0s"
+	Window string `protobuf:"bytes,6,opt,name=window,proto3" json:"window,omitempty"`
+	// The counter of the rule couldn't be updated, the action is the one
+	// applied: "flag" when the rule fails open, "reject" when it fails closed
+	NotEnforced   bool `protobuf:"varint,7,opt,name=not_enforced,json=notEnforced,proto3" json:"not_enforced,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *VelocityFlagObject) Reset() {
+	*x = VelocityFlagObject{}
+	mi := &file_usdk_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VelocityFlagObject) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VelocityFlagObject) ProtoMessage() {}
+
+func (x *VelocityFlagObject) ProtoReflect() protoreflect.Message {
+	mi := &file_usdk_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VelocityFlagObject.ProtoReflect.Descriptor instead.
+func (*VelocityFlagObject) Descriptor() ([]byte, []int) {
+	return file_usdk_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *VelocityFlagObject) GetRule() string {
+	if x != nil {
+		return x.Rule
+	}
+	return ""
+}
+
+func (x *VelocityFlagObject) GetAction() string {
+	if x != nil {
+		return x.Action
+	}
+	return ""
+}
+
+func (x *VelocityFlagObject) GetCheckSessionKey() string {
+	if x != nil {
+		return x.CheckSessionKey
+	}
+	return ""
+}
+
+func (x *VelocityFlagObject) GetCount() int64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *VelocityFlagObject) GetLimit() int64 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *VelocityFlagObject) GetWindow() string {
+	if x != nil {
+		return x.Window
+	}
+	return ""
+}
+
+func (x *VelocityFlagObject) GetNotEnforced() bool {
+	if x != nil {
+		return x.NotEnforced
+	}
+	return false
+}
+
+// The outcome of a single DeviceCheckDetailsObject of the request
+type DeviceCheckResultObject struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The activity data of the check, with every value in its canonical form
+	ActivityData []*KeyValuePairObject `protobuf:"bytes,1,rep,name=activity_data,json=activityData,proto3" json:"activity_data,omitempty"`
+	// The session key of the check
+	CheckSessionKey string `protobuf:"bytes,2,opt,name=check_session_key,json=checkSessionKey,proto3" json:"check_session_key,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *DeviceCheckResultObject) Reset() {
+	*x = DeviceCheckResultObject{}
+	mi := &file_usdk_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeviceCheckResultObject) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeviceCheckResultObject) ProtoMessage() {}
+
+func (x *DeviceCheckResultObject) ProtoReflect() protoreflect.Message {
+	mi := &file_usdk_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeviceCheckResultObject.ProtoReflect.Descriptor instead.
+func (*DeviceCheckResultObject) Descriptor() ([]byte, []int) {
+	return file_usdk_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *DeviceCheckResultObject) GetActivityData() []*KeyValuePairObject {
+	if x != nil {
+		return x.ActivityData
+	}
+	return nil
+}
+
+func (x *DeviceCheckResultObject) GetCheckSessionKey() string {
+	if x != nil {
+		return x.CheckSessionKey
+	}
+	return ""
+}
+
+// Attached as a status detail to every failed call
+type ErrorObject struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Code  int64                  `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"`
+	// Description of what went wrong (if we can tell)
+	Message       string `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ErrorObject) Reset() {
+	*x = ErrorObject{}
+	mi := &file_usdk_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ErrorObject) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ErrorObject) ProtoMessage() {}
+
+func (x *ErrorObject) ProtoReflect() protoreflect.Message {
+	mi := &file_usdk_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ErrorObject.ProtoReflect.Descriptor instead.
+func (*ErrorObject) Descriptor() ([]byte, []int) {
+	return file_usdk_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ErrorObject) GetCode() int64 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+func (x *ErrorObject) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+var File_usdk_proto protoreflect.FileDescriptor
+
+const file_usdk_proto_rawDesc = "" +
+	"\n" +
+	"\n" +
+	"usdk.proto\x12\ausdk.v1\"e\n" +
+	"\x12KeyValuePairObject\x12\x17\n" +
+	"\akvp_key\x18\x01 \x01(\tR\x06kvpKey\x12\x1b\n" +
+	"\tkvp_value\x18\x02 \x01(\tR\bkvpValue\x12\x19\n" +
+	"\bkvp_type\x18\x03 \x01(\tR\akvpType\"\xeb\x01\n" +
+	"\x18DeviceCheckDetailsObject\x12@\n" +
+	"\ractivity_data\x18\x01 \x03(\v2\x1b.usdk.v1.KeyValuePairObjectR\factivityData\x12#\n" +
+	"\ractivity_type\x18\x02 \x01(\tR\factivityType\x12*\n" +
+	"\x11check_session_key\x18\x03 \x01(\tR\x0fcheckSessionKey\x12\x1d\n" +
+	"\n" +
+	"check_type\x18\x04 \x01(\tR\tcheckType\x12\x1d\n" +
+	"\n" +
+	"journey_id\x18\x05 \x01(\tR\tjourneyId\"O\n" +
+	"\x12DeviceCheckRequest\x129\n" +
+	"\x06checks\x18\x01 \x03(\v2!.usdk.v1.DeviceCheckDetailsObjectR\x06checks\"\xcf\x01\n" +
+	"\vPuppyObject\x12\x14\n" +
+	"\x05puppy\x18\x01 \x01(\bR\x05puppy\x12:\n" +
+	"\aresults\x18\x02 \x03(\v2 .usdk.v1.DeviceCheckResultObjectR\aresults\x121\n" +
+	"\x05flags\x18\x03 \x03(\v2\x1b.usdk.v1.VelocityFlagObjectR\x05flags\x12;\n" +
+	"\flist_matches\x18\x04 \x03(\v2\x18.usdk.v1.ListMatchObjectR\vlistMatches\"\x9d\x01\n" +
+	"\x0fListMatchObject\x12*\n" +
+	"\x11check_session_key\x18\x01 \x01(\tR\x0fcheckSessionKey\x12\x19\n" +
+	"\bentry_id\x18\x02 \x01(\tR\aentryId\x12\x17\n" +
+	"\akvp_key\x18\x03 \x01(\tR\x06kvpKey\x12\x12\n" +
+	"\x04list\x18\x04 \x01(\tR\x04list\x12\x16\n" +
+	"\x06reason\x18\x05 \x01(\tR\x06reason\"\xd3\x01\n" +
+	"\x12VelocityFlagObject\x12\x12\n" +
+	"\x04rule\x18\x01 \x01(\tR\x04rule\x12\x16\n" +
+	"\x06action\x18\x02 \x01(\tR\x06action\x12*\n" +
+	"\x11check_session_key\x18\x03 \x01(\tR\x0fcheckSessionKey\x12\x14\n" +
+	"\x05count\x18\x04 \x01(\x03R\x05count\x12\x14\n" +
+	"\x05limit\x18\x05 \x01(\x03R\x05limit\x12\x16\n" +
+	"\x06window\x18\x06 \x01(\tR\x06window\x12!\n" +
+	"\fnot_enforced\x18\a \x01(\bR\vnotEnforced\"\x87\x01\n" +
+	"\x17DeviceCheckResultObject\x12@\n" +
+	"\ractivity_data\x18\x01 \x03(\v2\x1b.usdk.v1.KeyValuePairObjectR\factivityData\x12*\n" +
+	"\x11check_session_key\x18\x02 \x01(\tR\x0fcheckSessionKey\";\n" +
+	"\vErrorObject\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\x03R\x04code\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage2V\n" +
+	"\x12DeviceCheckService\x12@\n" +
+	"\vDeviceCheck\x12\x1b.usdk.v1.DeviceCheckRequest\x1a\x14.usdk.v1.PuppyObjectB\x1bZ\x19universalsdk/proto/usdkpbb\x06proto3"
+
+var (
+	file_usdk_proto_rawDescOnce sync.Once
+	file_usdk_proto_rawDescData []byte
+)
+
+func file_usdk_proto_rawDescGZIP() []byte {
+	file_usdk_proto_rawDescOnce.Do(func() {
+		file_usdk_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_usdk_proto_rawDesc), len(file_usdk_proto_rawDesc)))
+	})
+	return file_usdk_proto_rawDescData
+}
+
+var file_usdk_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_usdk_proto_goTypes = []any{
+	(*KeyValuePairObject)(nil),       // 0: usdk.v1.KeyValuePairObject
+	(*DeviceCheckDetailsObject)(nil), // 1: usdk.v1.DeviceCheckDetailsObject
+	(*DeviceCheckRequest)(nil),       // 2: usdk.v1.DeviceCheckRequest
+	(*PuppyObject)(nil),              // 3: usdk.v1.PuppyObject
+	(*ListMatchObject)(nil),          // 4: usdk.v1.ListMatchObject
+	(*VelocityFlagObject)(nil),       // 5: usdk.v1.VelocityFlagObject
+	(*DeviceCheckResultObject)(nil),  // 6: usdk.v1.DeviceCheckResultObject
+	(*ErrorObject)(nil),              // 7: usdk.v1.ErrorObject
+}
+var file_usdk_proto_depIdxs = []int32{
+	0, // 0: usdk.v1.DeviceCheckDetailsObject.activity_data:type_name -> usdk.v1.KeyValuePairObject
+	1, // 1: usdk.v1.DeviceCheckRequest.checks:type_name -> usdk.v1.DeviceCheckDetailsObject
+	6, // 2: usdk.v1.PuppyObject.results:type_name -> usdk.v1.DeviceCheckResultObject
+	5, // 3: usdk.v1.PuppyObject.flags:type_name -> usdk.v1.VelocityFlagObject
+	4, // 4: usdk.v1.PuppyObject.list_matches:type_name -> usdk.v1.ListMatchObject
+	0, // 5: usdk.v1.DeviceCheckResultObject.activity_data:type_name -> usdk.v1.KeyValuePairObject
+	2, // 6: usdk.v1.DeviceCheckService.DeviceCheck:input_type -> usdk.v1.DeviceCheckRequest
+	3, // 7: usdk.v1.DeviceCheckService.DeviceCheck:output_type -> usdk.v1.PuppyObject
+	7, // [7:8] is the sub-list for method output_type
+	6, // [6:7] is the sub-list for method input_type
+	6, // [6:6] is the sub-list for extension type_name
+	6, // [6:6] is the sub-list for extension extendee
+	0, // [0:6] is the sub-list for field type_name
+}
+
+func init() { file_usdk_proto_init() }
+func file_usdk_proto_init() {
+	if File_usdk_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_usdk_proto_rawDesc), len(file_usdk_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   8,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_usdk_proto_goTypes,
+		DependencyIndexes: file_usdk_proto_depIdxs,
+		MessageInfos:      file_usdk_proto_msgTypes,
+	}.Build()
+	File_usdk_proto = out.File
+	file_usdk_proto_goTypes = nil
+	file_usdk_proto_depIdxs = nil
+}
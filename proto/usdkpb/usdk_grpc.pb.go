@@ -0,0 +1,129 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.2
+// - protoc             (unknown)
+// source: usdk.proto
+
+package usdkpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	DeviceCheckService_DeviceCheck_FullMethodName = "/usdk.v1.DeviceCheckService/DeviceCheck"
+)
+
+// DeviceCheckServiceClient is the client API for DeviceCheckService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// DeviceCheckService is the gRPC transport of the /isgood REST API.
+type DeviceCheckServiceClient interface {
+	// DeviceCheck validates the collection of device checks.
+	// Failures are returned as a status carrying an ErrorObject detail.
+	DeviceCheck(ctx context.Context, in *DeviceCheckRequest, opts ...grpc.CallOption) (*PuppyObject, error)
+}
+
+type deviceCheckServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewDeviceCheckServiceClient(cc grpc.ClientConnInterface) DeviceCheckServiceClient {
+	return &deviceCheckServiceClient{cc}
+}
+
+func (c *deviceCheckServiceClient) DeviceCheck(ctx context.Context, in *DeviceCheckRequest, opts ...grpc.CallOption) (*PuppyObject, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PuppyObject)
+	err := c.cc.Invoke(ctx, DeviceCheckService_DeviceCheck_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// DeviceCheckServiceServer is the server API for DeviceCheckService service.
+// All implementations must embed UnimplementedDeviceCheckServiceServer
+// for forward compatibility.
+//
+// DeviceCheckService is the gRPC transport of the /isgood REST API.
+type DeviceCheckServiceServer interface {
+	// DeviceCheck validates the collection of device checks.
+	// Failures are returned as a status carrying an ErrorObject detail.
+	DeviceCheck(context.Context, *DeviceCheckRequest) (*PuppyObject, error)
+	mustEmbedUnimplementedDeviceCheckServiceServer()
+}
+
+// UnimplementedDeviceCheckServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedDeviceCheckServiceServer struct{}
+
+func (UnimplementedDeviceCheckServiceServer) DeviceCheck(context.Context, *DeviceCheckRequest) (*PuppyObject, error) {
+	return nil, status.Error(codes.Unimplemented, "method DeviceCheck not implemented")
+}
+func (UnimplementedDeviceCheckServiceServer) mustEmbedUnimplementedDeviceCheckServiceServer() {}
+func (UnimplementedDeviceCheckServiceServer) testEmbeddedByValue()                            {}
+
+// UnsafeDeviceCheckServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to DeviceCheckServiceServer will
+// result in compilation errors.
+type UnsafeDeviceCheckServiceServer interface {
+	mustEmbedUnimplementedDeviceCheckServiceServer()
+}
+
+func RegisterDeviceCheckServiceServer(s grpc.ServiceRegistrar, srv DeviceCheckServiceServer) {
+	// If the following call panics, it indicates UnimplementedDeviceCheckServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&DeviceCheckService_ServiceDesc, srv)
+}
+
+func _DeviceCheckService_DeviceCheck_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeviceCheckRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DeviceCheckServiceServer).DeviceCheck(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DeviceCheckService_DeviceCheck_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DeviceCheckServiceServer).DeviceCheck(ctx, req.(*DeviceCheckRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// DeviceCheckService_ServiceDesc is the grpc.ServiceDesc for DeviceCheckService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var DeviceCheckService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "usdk.v1.DeviceCheckService",
+	HandlerType: (*DeviceCheckServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "DeviceCheck",
+			Handler:    _DeviceCheckService_DeviceCheck_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "usdk.proto",
+}
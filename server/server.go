@@ -0,0 +1,200 @@
+package server
+
+import (
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"sync"
+	"universalsdk/audit"
+	"universalsdk/compress"
+	"universalsdk/config"
+	"universalsdk/controller"
+	"universalsdk/envelope"
+	"universalsdk/lists"
+	"universalsdk/middleware"
+	"universalsdk/models"
+	"universalsdk/proto/usdkpb"
+	"universalsdk/service"
+	"universalsdk/signing"
+	"universalsdk/velocity"
+
+	"google.golang.org/grpc"
+)
+
+// Run wires the services and controllers from cfg and starts the REST
+// server and, when configured, the gRPC server. It blocks until the REST
+// server stops.
+func Run(cfg *config.Config) error {
+
+	var sessionKeyMap sync.Map
+
+	encryptor, err := envelope.NewEncryptorFromConfig(cfg.Encryption)
+	if err != nil {
+		return err
+	}
+
+	controllerOpts := []controller.Option{controller.WithCompression(compress.Config{
+		Enabled:              cfg.Server.Compression.Enabled,
+		MinSize:              cfg.Server.Compression.MinSize,
+		MaxDecompressedBytes: cfg.Server.Compression.MaxDecompressedBytes,
+	})}
+	if cfg.Audit.Enabled {
+		auditLogger, err := audit.NewLoggerFromConfig(cfg.Audit, encryptor)
+		if err != nil {
+			return err
+		}
+		defer auditLogger.Close()
+		controllerOpts = append(controllerOpts, controller.WithAuditLogger(auditLogger))
+	}
+
+	signer, err := signing.NewSignerFromConfig(cfg.Signing)
+	if err != nil {
+		return err
+	}
+	if signer != nil {
+		controllerOpts = append(controllerOpts, controller.WithSigner(signer))
+	}
+
+	callerKeys := make(map[string]string, len(cfg.Check.Callers))
+	callerIDs := make([]string, 0, len(cfg.Check.Callers))
+	for _, caller := range cfg.Check.Callers {
+		if caller.ID == "" || caller.APIKey == "" {
+			return errors.New("check.callers needs both an id and an apiKey")
+		}
+		callerKeys[caller.ID] = caller.APIKey
+		callerIDs = append(callerIDs, caller.ID)
+	}
+	if len(callerKeys) > 0 {
+		controllerOpts = append(controllerOpts, controller.WithCallerKeys(callerKeys))
+	}
+
+	keyScope, err := service.ParseKeyScope(cfg.Check.KeyScope)
+	if err != nil {
+		return err
+	}
+
+	serviceOpts := []service.Option{
+		service.WithReplayTTL(cfg.Session.ReplayTTL),
+		service.WithEchoNormalised(cfg.Check.EchoNormalised),
+		service.WithKeyScope(keyScope),
+	}
+	if cfg.Check.Semantic.Enabled {
+		keys := make(map[string]string)
+		for _, key := range cfg.Check.Semantic.Keys {
+			keys[key.Key] = key.Validator
+		}
+		catalogue, err := service.NewSemanticCatalogue(keys)
+		if err != nil {
+			return err
+		}
+		serviceOpts = append(serviceOpts, service.WithSemanticCatalogue(catalogue))
+	}
+
+	if len(cfg.Check.Schemas) > 0 {
+		schemas, err := service.NewSchemaSet(schemasFromConfig(cfg.Check.Schemas))
+		if err != nil {
+			return err
+		}
+		serviceOpts = append(serviceOpts, service.WithSchemas(schemas))
+	}
+
+	if len(cfg.Check.VendorActivityTypes) > 0 {
+		registry, err := service.NewActivityTypeRegistry(vendorActivityTypesFromConfig(cfg.Check.VendorActivityTypes))
+		if err != nil {
+			return err
+		}
+		serviceOpts = append(serviceOpts, service.WithActivityTypeRegistry(registry))
+	}
+
+	if len(cfg.Velocity.Rules) > 0 {
+		checker, err := velocity.NewCheckerFromConfig(cfg.Velocity, encryptor)
+		if err != nil {
+			return err
+		}
+		defer checker.Close()
+		serviceOpts = append(serviceOpts, service.WithVelocity(checker))
+	}
+
+	listStore := lists.NewStore(cfg.Lists.Keys, lists.WithCallers(callerIDs), lists.WithEncryptor(encryptor))
+	if cfg.Lists.File != "" {
+		count, err := listStore.ImportFile(cfg.Lists.File, true)
+		if err != nil {
+			return err
+		}
+		log.Printf("##  Imported %d list entries from %s", count, cfg.Lists.File)
+	}
+	serviceOpts = append(serviceOpts, service.WithLists(listStore))
+
+	transitions := service.DefaultTransitions()
+	for _, transition := range cfg.Journey.Transitions {
+		transitions[transition.ActivityType] = transition.After
+	}
+	journeys := service.NewJourneyStore(transitions, cfg.Journey.TTL)
+	serviceOpts = append(serviceOpts, service.WithJourneyStore(journeys))
+
+	usdkService := service.NewUsdkService(&sessionKeyMap, serviceOpts...)
+	usdkController := controller.NewUsdkController(usdkService, controllerOpts...)
+
+	routes := controller.Routes{
+		DeviceCheckRoute: cfg.Server.Route,
+		Usdk:             usdkController,
+		Journey:          controller.NewJourneyController(service.NewJourneyService(journeys)),
+	}
+	if signer != nil {
+		routes.Signing = controller.NewSigningController(signer)
+	}
+	if cfg.Admin.Token != "" {
+		routes.Admin = controller.NewAdminController(service.NewSessionKeyService(&sessionKeyMap), cfg.Admin.Token)
+		routes.Lists = controller.NewListController(listStore)
+	}
+	middlewares, err := middleware.FromConfig(cfg.Server.Middleware)
+	if err != nil {
+		return err
+	}
+	router := controller.NewRouter(routes, middlewares...)
+
+	if cfg.Grpc.Addr != "" {
+		grpcServer := grpc.NewServer(grpc.ChainUnaryInterceptor(controller.LoggingInterceptor, controller.RecoveryInterceptor))
+		usdkpb.RegisterDeviceCheckServiceServer(grpcServer, controller.NewUsdkGrpcController(usdkService, controllerOpts...))
+
+		listener, err := net.Listen("tcp", cfg.Grpc.Addr)
+		if err != nil {
+			return err
+		}
+		defer grpcServer.Stop()
+
+		log.Printf("##  Starting gRPC Server on %s", cfg.Grpc.Addr)
+		go func() {
+			if err := grpcServer.Serve(listener); err != nil {
+				log.Print("Error while serving grpc ", err)
+			}
+		}()
+	}
+
+	log.Printf("##  Starting Server on %s", cfg.Server.Addr)
+
+	return http.ListenAndServe(cfg.Server.Addr, router)
+}
+
+// schemasFromConfig converts the configured activity schemas to the service schemas
+func schemasFromConfig(configs []config.SchemaConfig) []service.ActivitySchema {
+	schemas := make([]service.ActivitySchema, 0, len(configs))
+	for _, c := range configs {
+		schema := service.ActivitySchema{ActivityType: c.ActivityType, CheckType: c.CheckType, Forbidden: c.Forbidden}
+		for _, req := range c.Required {
+			schema.Required = append(schema.Required, service.RequiredKey{Key: req.Key, Type: models.EnumKVPType(req.Type)})
+		}
+		schemas = append(schemas, schema)
+	}
+	return schemas
+}
+
+// vendorActivityTypesFromConfig converts the configured vendor activityTypes to the service registry entries
+func vendorActivityTypesFromConfig(configs []config.VendorActivityTypesConfig) []service.VendorActivityTypes {
+	vendors := make([]service.VendorActivityTypes, 0, len(configs))
+	for _, c := range configs {
+		vendors = append(vendors, service.VendorActivityTypes{Vendor: c.Vendor, CheckType: c.CheckType, ActivityTypes: c.ActivityTypes})
+	}
+	return vendors
+}
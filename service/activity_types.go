@@ -0,0 +1,81 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"universalsdk/models"
+)
+
+// VendorActivityTypes lists the vendor specific activityTypes, starting with
+// an underscore, a vendor supports for a checkType. An empty CheckType makes
+// them valid for every checkType.
+type VendorActivityTypes struct {
+	Vendor        string
+	CheckType     string
+	ActivityTypes []string
+}
+
+type vendorActivityType struct {
+	vendor    string
+	checkType string
+}
+
+// ActivityTypeRegistry holds the registered vendor activityTypes
+type ActivityTypeRegistry map[string][]vendorActivityType
+
+// NewActivityTypeRegistry validates the vendor activityTypes and indexes them by activityType
+func NewActivityTypeRegistry(vendors []VendorActivityTypes) (ActivityTypeRegistry, error) {
+	registry := ActivityTypeRegistry{}
+	for _, vendor := range vendors {
+		if vendor.Vendor == "" {
+			return nil, fmt.Errorf("vendor activity types require a vendor")
+		}
+		if vendor.CheckType != "" {
+			check := models.DeviceCheckDetailsObject{CheckType: vendor.CheckType}
+			if err := check.Validate(nil); err != nil {
+				return nil, fmt.Errorf("vendor %s: %s", vendor.Vendor, err.Error())
+			}
+		}
+		for _, activityType := range vendor.ActivityTypes {
+			if !strings.HasPrefix(activityType, "_") || len(activityType) < 2 {
+				return nil, fmt.Errorf("vendor %s activityType %s should start with an underscore", vendor.Vendor, activityType)
+			}
+			registry[activityType] = append(registry[activityType], vendorActivityType{vendor.Vendor, vendor.CheckType})
+		}
+	}
+	return registry, nil
+}
+
+// validate checks that a vendor specific activityType is registered for the checkType of the check
+func (r ActivityTypeRegistry) validate(check *models.DeviceCheckDetailsObject) error {
+	if !strings.HasPrefix(check.ActivityType, "_") {
+		return nil
+	}
+
+	for _, registered := range r[check.ActivityType] {
+		if registered.checkType == "" || registered.checkType == check.CheckType {
+			return nil
+		}
+	}
+
+	choices := r.choices(check.CheckType)
+	if len(choices) == 0 {
+		return fmt.Errorf("activityType %s is not a registered vendor activityType, there are none for checkType %s", check.ActivityType, check.CheckType)
+	}
+	return fmt.Errorf("activityType %s is not a registered vendor activityType for checkType %s, valid choices are %s", check.ActivityType, check.CheckType, strings.Join(choices, ", "))
+}
+
+// choices lists the vendor activityTypes valid for checkType as "activityType (vendor)"
+func (r ActivityTypeRegistry) choices(checkType string) []string {
+	var choices []string
+	for activityType, registrations := range r {
+		for _, registered := range registrations {
+			if registered.checkType == "" || registered.checkType == checkType {
+				choices = append(choices, fmt.Sprintf("%s (%s)", activityType, registered.vendor))
+			}
+		}
+	}
+	sort.Strings(choices)
+	return choices
+}
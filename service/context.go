@@ -0,0 +1,16 @@
+package service
+
+import "context"
+
+type callerKey struct{}
+
+// WithCaller returns a context carrying the identity of the caller of a device check
+func WithCaller(ctx context.Context, caller string) context.Context {
+	return context.WithValue(ctx, callerKey{}, caller)
+}
+
+// CallerFromContext returns the caller identity set by WithCaller, empty when unknown
+func CallerFromContext(ctx context.Context) string {
+	caller, _ := ctx.Value(callerKey{}).(string)
+	return caller
+}
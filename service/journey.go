@@ -0,0 +1,215 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-openapi/strfmt"
+	"universalsdk/models"
+)
+
+// JourneyStart stands for the start of a journey in Transitions
+const JourneyStart = "START"
+
+// DefaultJourneyTTL is how long a journey is kept after its last check by default
+const DefaultJourneyTTL = 24 * time.Hour
+
+// Transitions lists, per activityType, the activityTypes the previous check
+// of a journey may have, JourneyStart allowing it as the first check.
+// activityTypes without an entry are allowed at any point of a journey.
+type Transitions map[string][]string
+
+// DefaultTransitions lets a journey start with a SIGNUP or LOGIN, requires a
+// PAYMENT to follow another check and a CONFIRMATION to follow a PAYMENT.
+func DefaultTransitions() Transitions {
+	return Transitions{
+		models.DeviceCheckDetailsObjectActivityTypeSIGNUP: {JourneyStart},
+		models.DeviceCheckDetailsObjectActivityTypeLOGIN: {JourneyStart,
+			models.DeviceCheckDetailsObjectActivityTypeSIGNUP,
+			models.DeviceCheckDetailsObjectActivityTypeLOGIN,
+			models.DeviceCheckDetailsObjectActivityTypePAYMENT,
+			models.DeviceCheckDetailsObjectActivityTypeCONFIRMATION},
+		models.DeviceCheckDetailsObjectActivityTypePAYMENT: {
+			models.DeviceCheckDetailsObjectActivityTypeSIGNUP,
+			models.DeviceCheckDetailsObjectActivityTypeLOGIN,
+			models.DeviceCheckDetailsObjectActivityTypePAYMENT,
+			models.DeviceCheckDetailsObjectActivityTypeCONFIRMATION},
+		models.DeviceCheckDetailsObjectActivityTypeCONFIRMATION: {
+			models.DeviceCheckDetailsObjectActivityTypePAYMENT},
+	}
+}
+
+func (t Transitions) allowed(previous, activityType string) bool {
+	after, ok := t[activityType]
+	if !ok {
+		return true
+	}
+	for _, allowed := range after {
+		if allowed == previous {
+			return true
+		}
+	}
+	return false
+}
+
+// JourneyStore keeps the history of the journeys checked within its TTL. It
+// is shared by the UsdkService recording the checks and the JourneyService
+// reading them.
+type JourneyStore struct {
+	mu          sync.Mutex
+	journeys    map[string][]*models.JourneyStepObject
+	transitions Transitions
+
+	// How long a journey is kept after its last check, forever when 0
+	ttl time.Duration
+
+	// When the expired journeys were last dropped
+	sweptAt time.Time
+
+	// The time journeys are looked up at
+	now func() time.Time
+}
+
+// JourneyOption configures optional behaviour of a JourneyStore
+type JourneyOption func(*JourneyStore)
+
+// WithJourneyClock replaces time.Now as the time journeys are looked up at,
+// the UsdkService records checks at the time of its own clock.
+func WithJourneyClock(now func() time.Time) JourneyOption {
+	return func(s *JourneyStore) {
+		s.now = now
+	}
+}
+
+// NewJourneyStore returns an empty JourneyStore enforcing transitions and
+// forgetting a journey ttl after its last check, a later check with its
+// journeyId starts it again. Journeys are kept forever when ttl is 0.
+func NewJourneyStore(transitions Transitions, ttl time.Duration, opts ...JourneyOption) *JourneyStore {
+	s := &JourneyStore{journeys: make(map[string][]*models.JourneyStepObject), transitions: transitions, ttl: ttl, now: time.Now}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// validate returns an error when a check with a journeyId is not allowed
+// after the previous one, without recording anything.
+func (s *JourneyStore) validate(deviceCheckCollection models.DeviceCheckDetailsObjectCollection, checkedAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(checkedAt)
+	return s.allowed(deviceCheckCollection, checkedAt)
+}
+
+// record adds the checks with a journeyId to their journey. Checks of the
+// same journey within the collection follow each other in order. Nothing is
+// recorded when any check is not allowed after the previous one, as a
+// concurrent check may have continued the journey since validate.
+func (s *JourneyStore) record(deviceCheckCollection models.DeviceCheckDetailsObjectCollection, checkedAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(checkedAt)
+	if err := s.allowed(deviceCheckCollection, checkedAt); err != nil {
+		return err
+	}
+
+	now := strfmt.DateTime(checkedAt)
+	for _, elem := range deviceCheckCollection {
+		if elem == nil || elem.JourneyID == "" {
+			continue
+		}
+		s.journeys[elem.JourneyID] = append(s.journeys[elem.JourneyID], &models.JourneyStepObject{
+			ActivityType:    elem.ActivityType,
+			CheckSessionKey: elem.CheckSessionKey,
+			CheckType:       elem.CheckType,
+			CheckedAt:       now,
+		})
+	}
+	return nil
+}
+
+// allowed checks the transitions of the collection, s.mu must be held
+func (s *JourneyStore) allowed(deviceCheckCollection models.DeviceCheckDetailsObjectCollection, checkedAt time.Time) error {
+	previous := make(map[string]string)
+	for _, elem := range deviceCheckCollection {
+		if elem == nil || elem.JourneyID == "" {
+			continue
+		}
+
+		last, ok := previous[elem.JourneyID]
+		if !ok {
+			last = JourneyStart
+			if steps := s.steps(elem.JourneyID, checkedAt); len(steps) > 0 {
+				last = steps[len(steps)-1].ActivityType
+			}
+		}
+
+		if !s.transitions.allowed(last, elem.ActivityType) {
+			return s.transitionError(elem, last)
+		}
+		previous[elem.JourneyID] = elem.ActivityType
+	}
+	return nil
+}
+
+// steps returns the steps of the journey, dropping it when it expired by now
+func (s *JourneyStore) steps(journeyID string, now time.Time) []*models.JourneyStepObject {
+	steps := s.journeys[journeyID]
+	if len(steps) > 0 && s.expired(steps, now) {
+		delete(s.journeys, journeyID)
+		return nil
+	}
+	return steps
+}
+
+func (s *JourneyStore) expired(steps []*models.JourneyStepObject, now time.Time) bool {
+	return s.ttl > 0 && now.Sub(time.Time(steps[len(steps)-1].CheckedAt)) > s.ttl
+}
+
+// sweep drops the expired journeys, at most once per TTL
+func (s *JourneyStore) sweep(now time.Time) {
+	if s.ttl <= 0 || now.Sub(s.sweptAt) < s.ttl {
+		return
+	}
+	s.sweptAt = now
+	for journeyID, steps := range s.journeys {
+		if len(steps) > 0 && s.expired(steps, now) {
+			delete(s.journeys, journeyID)
+		}
+	}
+}
+
+func (s *JourneyStore) transitionError(elem *models.DeviceCheckDetailsObject, previous string) error {
+	after := append([]string(nil), s.transitions[elem.ActivityType]...)
+	sort.Strings(after)
+	if previous == JourneyStart {
+		return fmt.Errorf("activityType %s cannot start journeyId %s, it is allowed after %s", elem.ActivityType, elem.JourneyID, strings.Join(after, ", "))
+	}
+	return fmt.Errorf("activityType %s is not allowed after %s in journeyId %s, it is allowed after %s", elem.ActivityType, previous, elem.JourneyID, strings.Join(after, ", "))
+}
+
+type journeyServiceImpl struct {
+	store *JourneyStore
+}
+
+// NewJourneyService exposes the journeys recorded by the UsdkService sharing the same store
+func NewJourneyService(store *JourneyStore) JourneyService {
+	return journeyServiceImpl{store: store}
+}
+
+// LookupJourney returns the steps of the journey, false once it expired
+func (j journeyServiceImpl) LookupJourney(journeyID string) (*models.JourneyObject, bool) {
+	j.store.mu.Lock()
+	defer j.store.mu.Unlock()
+
+	steps := j.store.steps(journeyID, j.store.now())
+	if len(steps) == 0 {
+		return nil, false
+	}
+	return &models.JourneyObject{JourneyID: journeyID, Steps: append([]*models.JourneyStepObject(nil), steps...)}, true
+}
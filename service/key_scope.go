@@ -0,0 +1,27 @@
+package service
+
+import "universalsdk/kvp"
+
+// KeyScope is the part of a request within which every kvpKey must be unique
+type KeyScope = kvp.KeyScope
+
+const (
+	KeyScopeElement    = kvp.KeyScopeElement
+	KeyScopeCollection = kvp.KeyScopeCollection
+	KeyScopeSession    = kvp.KeyScopeSession
+
+	DefaultKeyScope = kvp.DefaultKeyScope
+)
+
+// KeyTracker records the kvpKeys of a request to detect duplicates within a KeyScope
+type KeyTracker = kvp.KeyTracker
+
+// ParseKeyScope returns the KeyScope named s, the default scope when s is empty
+func ParseKeyScope(s string) (KeyScope, error) {
+	return kvp.ParseKeyScope(s)
+}
+
+// NewKeyTracker returns a KeyTracker for one request
+func NewKeyTracker(scope KeyScope) *KeyTracker {
+	return kvp.NewKeyTracker(scope)
+}
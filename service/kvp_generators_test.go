@@ -0,0 +1,82 @@
+package service
+
+import (
+	"encoding/base64"
+	"math"
+	"math/rand"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"universalsdk/models"
+)
+
+// validKVP generates a KeyValuePairObject whose kvpValue is valid for its
+// kvpType, in any of the forms accepted before normalisation
+type validKVP struct {
+	*models.KeyValuePairObject
+}
+
+func (validKVP) Generate(r *rand.Rand, size int) reflect.Value {
+	kvpType := pick(r, []models.EnumKVPType{
+		models.EnumKVPTypeGeneralInteger, models.EnumKVPTypeGeneralFloat, models.EnumKVPTypeGeneralBool,
+		models.EnumKVPTypeGeneralString, models.EnumKVPTypePiiName, models.EnumKVPTypeIDExternal, models.EnumKVPTypeRawBase64,
+	})
+
+	var value string
+	switch kvpType {
+	case models.EnumKVPTypeGeneralInteger:
+		value = strconv.FormatInt(r.Int63n(1<<uint(r.Intn(62)+1))-r.Int63n(1<<uint(r.Intn(62)+1)), 10)
+		if r.Intn(4) == 0 {
+			value = pick(r, []string{"+", "0", "00"}) + strings.TrimPrefix(value, "-")
+		}
+	case models.EnumKVPTypeGeneralFloat:
+		f := r.NormFloat64() * math.Pow(10, float64(r.Intn(40)-20))
+		value = strconv.FormatFloat(f, pick(r, []byte{'e', 'E', 'f', 'g'}), -1, 64)
+	case models.EnumKVPTypeGeneralBool:
+		value = pick(r, []string{"true", "false", "TRUE", "False", "t", "F", "1", "0"})
+	case models.EnumKVPTypeRawBase64:
+		value = base64.StdEncoding.EncodeToString(randomBytes(r, size))
+	default:
+		value = string(randomBytes(r, size))
+	}
+	return reflect.ValueOf(validKVP{&models.KeyValuePairObject{KvpKey: "gen." + string(kvpType), KvpValue: value, KvpType: kvpType}})
+}
+
+// invalidKVP generates a KeyValuePairObject whose kvpValue can't be parsed
+// as its kvpType, or whose kvpType doesn't exist
+type invalidKVP struct {
+	*models.KeyValuePairObject
+}
+
+func (invalidKVP) Generate(r *rand.Rand, size int) reflect.Value {
+	invalid := map[models.EnumKVPType][]string{
+		models.EnumKVPTypeGeneralInteger:   {"", "1.5", "1e3", "0x10", "1_000", "9223372036854775808", "seven", " 7"},
+		models.EnumKVPTypeGeneralFloat:     {"", "NaN", "Inf", "-Infinity", "1,5", "1e309", "one", "1.5.0"},
+		models.EnumKVPTypeGeneralBool:      {"", "yes", "no", "2", "truth", "on"},
+		models.EnumKVPTypeRawJSON:          {`{"a":1}`, "e30", "====", "not base64!"},
+		models.EnumKVPType("general.date"): {"2020-01-01", ""},
+	}
+	types := make([]models.EnumKVPType, 0, len(invalid))
+	for kvpType := range invalid {
+		types = append(types, kvpType)
+	}
+	// map order is random, sort it for reproducible runs
+	sort.Slice(types, func(i, j int) bool { return types[i] < types[j] })
+
+	kvpType := pick(r, types)
+	value := pick(r, invalid[kvpType])
+	return reflect.ValueOf(invalidKVP{&models.KeyValuePairObject{KvpKey: "gen." + string(kvpType), KvpValue: value, KvpType: kvpType}})
+}
+
+func pick[T any](r *rand.Rand, choices []T) T {
+	return choices[r.Intn(len(choices))]
+}
+
+func randomBytes(r *rand.Rand, size int) []byte {
+	b := make([]byte, r.Intn(size+1))
+	for i := range b {
+		b[i] = byte(' ' + r.Intn('~'-' '))
+	}
+	return b
+}
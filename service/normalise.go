@@ -0,0 +1,21 @@
+package service
+
+import (
+	"universalsdk/kvp"
+	"universalsdk/models"
+)
+
+// normaliseValue returns the canonical form of value, see kvp.Normalise
+func normaliseValue(value string, dataType models.EnumKVPType) (string, error) {
+	return kvp.Normalise(value, dataType)
+}
+
+// normaliseActivityData replaces every kvpValue of the check with its canonical form.
+// Values that fail to parse are left untouched, they are reported by validateActivityData.
+func normaliseActivityData(dCheckDetailsObject *models.DeviceCheckDetailsObject) {
+	for _, kvp := range dCheckDetailsObject.ActivityData {
+		if canonical, err := normaliseValue(kvp.KvpValue, kvp.KvpType); err == nil {
+			kvp.KvpValue = canonical
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+	"universalsdk/models"
+)
+
+// requestFingerprint identifies the content of a request
+func requestFingerprint(deviceCheckCollection models.DeviceCheckDetailsObjectCollection) string {
+	data, err := json.Marshal(deviceCheckCollection)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// replayRecord returns the record of the original request when the session
+// keys of the request were reserved by an identical, completed request within ttl of now.
+// Keys the original request never reached, because it failed on an earlier
+// element, may be absent. When the identical request is still running its
+// Done channel is returned instead, to wait for its outcome. Any other use
+// of a reserved key is left to validateSessionKey to reject.
+func replayRecord(deviceCheckCollection models.DeviceCheckDetailsObjectCollection, fingerprint string, sessionKeyMap *sync.Map, ttl time.Duration, now time.Time) (*sessionKeyRecord, <-chan struct{}) {
+	if fingerprint == "" {
+		return nil, nil
+	}
+
+	var original *sessionKeyRecord
+	for _, elem := range deviceCheckCollection {
+		if elem == nil || elem.CheckSessionKey == "" {
+			continue
+		}
+
+		value, ok := sessionKeyMap.Load(elem.CheckSessionKey)
+		if !ok {
+			continue
+		}
+		record, ok := value.(*sessionKeyRecord)
+		if !ok || record.Fingerprint != fingerprint || now.Sub(record.ReservedAt) > ttl {
+			return nil, nil
+		}
+		if !record.Completed {
+			if record.running() {
+				return nil, record.Done
+			}
+			return nil, nil
+		}
+		original = record
+	}
+
+	return original, nil
+}
+
+// running reports whether the request that reserved the key is still running
+func (r *sessionKeyRecord) running() bool {
+	if r.Done == nil {
+		return false
+	}
+	select {
+	case <-r.Done:
+		return false
+	default:
+		return true
+	}
+}
+
+// outcome returns a copy of the stored response or error of the request
+func (r *sessionKeyRecord) outcome() (*models.DeviceCheckResponseObject, error) {
+	if r.Error != "" || r.Response == nil {
+		return nil, errors.New(r.Error)
+	}
+	resp := *r.Response
+	return &resp, nil
+}
+
+// storeResponse keeps the outcome of a request with the session keys it reserved
+func storeResponse(deviceCheckCollection models.DeviceCheckDetailsObjectCollection, fingerprint string, sessionKeyMap *sync.Map, resp *models.DeviceCheckResponseObject, err error) {
+	for _, elem := range deviceCheckCollection {
+		if elem == nil || elem.CheckSessionKey == "" {
+			continue
+		}
+
+		value, ok := sessionKeyMap.Load(elem.CheckSessionKey)
+		if !ok {
+			continue
+		}
+		record, ok := value.(*sessionKeyRecord)
+		if !ok || record.Completed || record.Fingerprint != fingerprint {
+			continue
+		}
+
+		completed := *record
+		completed.Completed = true
+		completed.Response = resp
+		if err != nil {
+			completed.Error = err.Error()
+		}
+		sessionKeyMap.CompareAndSwap(elem.CheckSessionKey, record, &completed)
+	}
+}
+
+// sweepReplays forgets the outcomes of the requests reserved more than the
+// replay TTL ago, so responses holding activity data aren't kept for the
+// life of the process. It runs at most once per TTL, an outcome is kept
+// for up to twice the TTL, or until the next check of an idle server.
+// The session keys stay reserved.
+func (u usdkServiceImpl) sweepReplays(now time.Time) {
+	last := u.sweptAt.Load()
+	if now.UnixNano()-last < int64(u.replayTTL) || !u.sweptAt.CompareAndSwap(last, now.UnixNano()) {
+		return
+	}
+
+	u.sessionKeyMap.Range(func(key, value interface{}) bool {
+		record, ok := value.(*sessionKeyRecord)
+		if ok && record.Completed && (record.Response != nil || record.Error != "") && now.Sub(record.ReservedAt) > u.replayTTL {
+			expired := *record
+			expired.Response = nil
+			expired.Error = ""
+			u.sessionKeyMap.CompareAndSwap(key, record, &expired)
+		}
+		return true
+	})
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"fmt"
+	"universalsdk/models"
+)
+
+// RequiredKey is a kvpKey an activity must contain, with the type of its value
+type RequiredKey struct {
+	Key  string
+	Type models.EnumKVPType
+}
+
+// ActivitySchema declares the kvpKeys an activityType must and must not
+// contain. When CheckType is set the schema only applies to that checkType
+// and takes precedence over a schema for the activityType alone.
+type ActivitySchema struct {
+	ActivityType string
+	CheckType    string
+	Required     []RequiredKey
+	Forbidden    []string
+}
+
+type schemaKey struct {
+	activityType string
+	checkType    string
+}
+
+// SchemaSet holds the activity schemas by activityType and checkType
+type SchemaSet map[schemaKey]ActivitySchema
+
+// NewSchemaSet validates the schemas and indexes them by activityType and checkType
+func NewSchemaSet(schemas []ActivitySchema) (SchemaSet, error) {
+	set := SchemaSet{}
+	for _, schema := range schemas {
+		if schema.ActivityType == "" {
+			return nil, fmt.Errorf("activity schema requires an activityType")
+		}
+		key := schemaKey{schema.ActivityType, schema.CheckType}
+		if _, ok := set[key]; ok {
+			return nil, fmt.Errorf("duplicate activity schema for %s", schema.name())
+		}
+
+		required := make(map[string]bool)
+		for _, req := range schema.Required {
+			if err := req.Type.Validate(nil); err != nil {
+				return nil, fmt.Errorf("activity schema %s kvpKey %s: %s", schema.name(), req.Key, err.Error())
+			}
+			required[req.Key] = true
+		}
+		for _, forbidden := range schema.Forbidden {
+			if required[forbidden] {
+				return nil, fmt.Errorf("activity schema %s kvpKey %s is both required and forbidden", schema.name(), forbidden)
+			}
+		}
+		set[key] = schema
+	}
+	return set, nil
+}
+
+// lookup returns the schema of the check, preferring one specific to its checkType
+func (s SchemaSet) lookup(check *models.DeviceCheckDetailsObject) (ActivitySchema, bool) {
+	if schema, ok := s[schemaKey{check.ActivityType, check.CheckType}]; ok {
+		return schema, true
+	}
+	schema, ok := s[schemaKey{check.ActivityType, ""}]
+	return schema, ok
+}
+
+// validate returns one error per required kvpKey that is missing or of the
+// wrong type and per forbidden kvpKey that is present
+func (s SchemaSet) validate(check *models.DeviceCheckDetailsObject) []string {
+	schema, ok := s.lookup(check)
+	if !ok {
+		return nil
+	}
+
+	present := make(map[string]*models.KeyValuePairObject)
+	for _, kvp := range check.ActivityData {
+		present[kvp.KvpKey] = kvp
+	}
+
+	var errstrings []string
+	for _, req := range schema.Required {
+		kvp, ok := present[req.Key]
+		if !ok {
+			errstrings = append(errstrings, fmt.Sprintf("KvpKey %s is required for %s", req.Key, schema.name()))
+			continue
+		}
+		if kvp.KvpType != req.Type {
+			errstrings = append(errstrings, fmt.Sprintf("KvpKey %s should be %s for %s got %s", req.Key, req.Type, schema.name(), kvp.KvpType))
+		}
+	}
+	for _, forbidden := range schema.Forbidden {
+		if _, ok := present[forbidden]; ok {
+			errstrings = append(errstrings, fmt.Sprintf("KvpKey %s is not allowed for %s", forbidden, schema.name()))
+		}
+	}
+	return errstrings
+}
+
+func (schema ActivitySchema) name() string {
+	if schema.CheckType == "" {
+		return "activityType " + schema.ActivityType
+	}
+	return fmt.Sprintf("activityType %s checkType %s", schema.ActivityType, schema.CheckType)
+}
@@ -0,0 +1,246 @@
+package service
+
+import (
+	"fmt"
+	"net"
+	"net/mail"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"universalsdk/models"
+)
+
+// semanticValidator checks the meaning of a KVP value beyond its data type.
+// The whole check is passed so related keys (currency and amount) can be validated together.
+type semanticValidator func(kvp *models.KeyValuePairObject, check *models.DeviceCheckDetailsObject) error
+
+// Semantic validators by name
+var semanticValidators = map[string]semanticValidator{
+	"ip":                validateIP,
+	"ipv4":              validateIPv4,
+	"ipv6":              validateIPv6,
+	"mac":               validateMAC,
+	"country":           validateCountry,
+	"currency":          validateCurrency,
+	"amount":            validateAmount,
+	"userAgent":         validateUserAgent,
+	"deviceFingerprint": validateDeviceFingerprint,
+	"latitude":          validateLatitude,
+	"longitude":         validateLongitude,
+	"latLong":           validateLatLong,
+	"email":             validateEmail,
+}
+
+// Well-known kvpKey names and the semantic validator applied to them
+var wellKnownKeys = map[string]string{
+	"ip.address":         "ip",
+	"ipv4.address":       "ipv4",
+	"ipv6.address":       "ipv6",
+	"mac.address":        "mac",
+	"country.code":       "country",
+	"address.country":    "country",
+	"currency.code":      "currency",
+	"payment.amount":     "amount",
+	"user.agent":         "userAgent",
+	"device.fingerprint": "deviceFingerprint",
+	"geo.latitude":       "latitude",
+	"geo.longitude":      "longitude",
+	"geo.location":       "latLong",
+	"email.address":      "email",
+}
+
+// The kvpKey holding the currency of payment.amount
+const currencyKey = "currency.code"
+
+// SemanticCatalogue maps kvpKey names to their semantic validator
+type SemanticCatalogue map[string]semanticValidator
+
+// NewSemanticCatalogue returns the catalogue of well-known keys. keys adds
+// or overrides entries, mapping a kvpKey to a validator name; an empty
+// validator name removes the key from the catalogue.
+func NewSemanticCatalogue(keys map[string]string) (SemanticCatalogue, error) {
+	catalogue := SemanticCatalogue{}
+	for key, name := range wellKnownKeys {
+		catalogue[key] = semanticValidators[name]
+	}
+
+	for key, name := range keys {
+		if name == "" {
+			delete(catalogue, key)
+			continue
+		}
+		validator, ok := semanticValidators[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown semantic validator %s for kvpKey %s, valid validators are %s", name, key, strings.Join(SemanticValidatorNames(), ", "))
+		}
+		catalogue[key] = validator
+	}
+	return catalogue, nil
+}
+
+// SemanticValidatorNames lists the validators that can be assigned to a kvpKey
+func SemanticValidatorNames() []string {
+	names := make([]string, 0, len(semanticValidators))
+	for name := range semanticValidators {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// validate returns one error per KVP of the check whose value is not meaningful for its key
+func (c SemanticCatalogue) validate(check *models.DeviceCheckDetailsObject) []string {
+	var errstrings []string
+	for _, kvp := range check.ActivityData {
+		validator, ok := c[kvp.KvpKey]
+		if !ok {
+			continue
+		}
+		if err := validator(kvp, check); err != nil {
+			errstrings = append(errstrings, fmt.Sprintf("KvpKey %s %s", kvp.KvpKey, err.Error()))
+		}
+	}
+	return errstrings
+}
+
+func validateIP(kvp *models.KeyValuePairObject, _ *models.DeviceCheckDetailsObject) error {
+	if net.ParseIP(kvp.KvpValue) == nil {
+		return fmt.Errorf("is not a valid IP address")
+	}
+	return nil
+}
+
+func validateIPv4(kvp *models.KeyValuePairObject, _ *models.DeviceCheckDetailsObject) error {
+	ip := net.ParseIP(kvp.KvpValue)
+	if ip == nil || ip.To4() == nil || strings.Contains(kvp.KvpValue, ":") {
+		return fmt.Errorf("is not a valid IPv4 address")
+	}
+	return nil
+}
+
+func validateIPv6(kvp *models.KeyValuePairObject, _ *models.DeviceCheckDetailsObject) error {
+	ip := net.ParseIP(kvp.KvpValue)
+	if ip == nil || !strings.Contains(kvp.KvpValue, ":") {
+		return fmt.Errorf("is not a valid IPv6 address")
+	}
+	return nil
+}
+
+func validateMAC(kvp *models.KeyValuePairObject, _ *models.DeviceCheckDetailsObject) error {
+	mac, err := net.ParseMAC(kvp.KvpValue)
+	if err != nil || (len(mac) != 6 && len(mac) != 8) {
+		return fmt.Errorf("is not a valid MAC address")
+	}
+	return nil
+}
+
+func validateCountry(kvp *models.KeyValuePairObject, _ *models.DeviceCheckDetailsObject) error {
+	if !isoCountryCodes[kvp.KvpValue] {
+		return fmt.Errorf("is not an ISO 3166-1 alpha-2 country code")
+	}
+	return nil
+}
+
+func validateCurrency(kvp *models.KeyValuePairObject, _ *models.DeviceCheckDetailsObject) error {
+	if _, ok := isoCurrencyMinorUnits[kvp.KvpValue]; !ok {
+		return fmt.Errorf("is not an ISO 4217 currency code")
+	}
+	return nil
+}
+
+// validateAmount requires a non-negative decimal amount with no more
+// decimals than the minor units of the currency.code of the same check.
+func validateAmount(kvp *models.KeyValuePairObject, check *models.DeviceCheckDetailsObject) error {
+	amount, err := strconv.ParseFloat(kvp.KvpValue, 64)
+	if err != nil || amount < 0 || strings.ContainsAny(kvp.KvpValue, "eEnNiI") {
+		return fmt.Errorf("is not a valid amount")
+	}
+
+	currency := ""
+	for _, other := range check.ActivityData {
+		if other.KvpKey == currencyKey {
+			currency = other.KvpValue
+		}
+	}
+	if currency == "" {
+		return fmt.Errorf("requires %s in the same check", currencyKey)
+	}
+	minorUnits, ok := isoCurrencyMinorUnits[currency]
+	if !ok {
+		// reported against currency.code
+		return nil
+	}
+
+	decimals := 0
+	if i := strings.Index(kvp.KvpValue, "."); i >= 0 {
+		decimals = len(kvp.KvpValue) - i - 1
+	}
+	if decimals > minorUnits {
+		return fmt.Errorf("has more than the %d decimals allowed for its %s", minorUnits, currencyKey)
+	}
+	return nil
+}
+
+func validateUserAgent(kvp *models.KeyValuePairObject, _ *models.DeviceCheckDetailsObject) error {
+	if strings.TrimSpace(kvp.KvpValue) == "" || len(kvp.KvpValue) > 1024 {
+		return fmt.Errorf("is not a valid user agent")
+	}
+	for _, r := range kvp.KvpValue {
+		if r > unicode.MaxASCII || !unicode.IsPrint(r) {
+			return fmt.Errorf("is not a valid user agent, it contains non printable characters")
+		}
+	}
+	return nil
+}
+
+// validateDeviceFingerprint accepts the hex encoding of an MD5, SHA-1, SHA-256 or SHA-512 hash
+func validateDeviceFingerprint(kvp *models.KeyValuePairObject, _ *models.DeviceCheckDetailsObject) error {
+	switch len(kvp.KvpValue) {
+	case 32, 40, 64, 128:
+	default:
+		return fmt.Errorf("is not a hex encoded MD5, SHA-1, SHA-256 or SHA-512 hash")
+	}
+	for _, r := range kvp.KvpValue {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", r) {
+			return fmt.Errorf("is not a hex encoded MD5, SHA-1, SHA-256 or SHA-512 hash")
+		}
+	}
+	return nil
+}
+
+func validateLatitude(kvp *models.KeyValuePairObject, _ *models.DeviceCheckDetailsObject) error {
+	return validateCoordinate(kvp.KvpValue, "latitude", 90)
+}
+
+func validateLongitude(kvp *models.KeyValuePairObject, _ *models.DeviceCheckDetailsObject) error {
+	return validateCoordinate(kvp.KvpValue, "longitude", 180)
+}
+
+// validateLatLong accepts "latitude,longitude"
+func validateLatLong(kvp *models.KeyValuePairObject, _ *models.DeviceCheckDetailsObject) error {
+	parts := strings.Split(kvp.KvpValue, ",")
+	if len(parts) != 2 {
+		return fmt.Errorf("is not a latitude,longitude pair")
+	}
+	if err := validateCoordinate(strings.TrimSpace(parts[0]), "latitude", 90); err != nil {
+		return err
+	}
+	return validateCoordinate(strings.TrimSpace(parts[1]), "longitude", 180)
+}
+
+func validateCoordinate(value, name string, limit float64) error {
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil || f < -limit || f > limit {
+		return fmt.Errorf("is not a valid %s", name)
+	}
+	return nil
+}
+
+func validateEmail(kvp *models.KeyValuePairObject, _ *models.DeviceCheckDetailsObject) error {
+	addr, err := mail.ParseAddress(kvp.KvpValue)
+	if err != nil || addr.Address != kvp.KvpValue {
+		return fmt.Errorf("is not a valid email address")
+	}
+	return nil
+}
@@ -1,7 +1,26 @@
 package service
 
-import "universalsdk/models"
+import (
+	"context"
+	"universalsdk/models"
+)
 
 type UsdkService interface {
-	DeviceCheck(deviceCheckCollection models.DeviceCheckDetailsObjectCollection) (*models.PuppyObject, error)
+	DeviceCheck(deviceCheckCollection models.DeviceCheckDetailsObjectCollection) (*models.DeviceCheckResponseObject, error)
+
+	// DeviceCheckContext is DeviceCheck for the caller set on ctx with WithCaller
+	DeviceCheckContext(ctx context.Context, deviceCheckCollection models.DeviceCheckDetailsObjectCollection) (*models.DeviceCheckResponseObject, error)
+}
+
+// SessionKeyService gives operators access to the reserved session keys
+type SessionKeyService interface {
+	LookupSessionKey(sessionKey string) (*models.SessionKeyObject, bool)
+	DeleteSessionKey(sessionKey string) bool
+	ListSessionKeys(prefix string) []*models.SessionKeyObject
+	CountSessionKeys(prefix string) int64
+}
+
+// JourneyService gives access to the history of the checks of a journey
+type JourneyService interface {
+	LookupJourney(journeyID string) (*models.JourneyObject, bool)
 }
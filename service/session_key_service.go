@@ -0,0 +1,91 @@
+package service
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-openapi/strfmt"
+	"universalsdk/models"
+)
+
+// sessionKeyRecord is the value stored against every reserved session key
+type sessionKeyRecord struct {
+	ReservedAt time.Time
+
+	// Fingerprint of the request that reserved the key
+	Fingerprint string
+
+	// Closed once that request completed, nil without replay
+	Done chan struct{}
+
+	// Outcome of that request, set once it completed. Response and Error
+	// are cleared when the replay TTL has passed.
+	Completed bool
+	Response  *models.DeviceCheckResponseObject
+	Error     string
+}
+
+type sessionKeyServiceImpl struct {
+	sessionKeyMap *sync.Map
+}
+
+// NewSessionKeyService exposes the session keys reserved by the UsdkService
+// sharing the same sessionKeyMap.
+func NewSessionKeyService(sessionKeyMap *sync.Map) SessionKeyService {
+	return sessionKeyServiceImpl{sessionKeyMap: sessionKeyMap}
+}
+
+func (s sessionKeyServiceImpl) LookupSessionKey(sessionKey string) (*models.SessionKeyObject, bool) {
+	value, ok := s.sessionKeyMap.Load(sessionKey)
+	if !ok {
+		return nil, false
+	}
+	return toSessionKeyObject(sessionKey, value), true
+}
+
+func (s sessionKeyServiceImpl) DeleteSessionKey(sessionKey string) bool {
+	_, ok := s.sessionKeyMap.LoadAndDelete(sessionKey)
+	return ok
+}
+
+// ListSessionKeys returns the session keys starting with prefix, oldest first
+func (s sessionKeyServiceImpl) ListSessionKeys(prefix string) []*models.SessionKeyObject {
+	res := make([]*models.SessionKeyObject, 0)
+	s.sessionKeyMap.Range(func(key, value interface{}) bool {
+		sessionKey := key.(string)
+		if strings.HasPrefix(sessionKey, prefix) {
+			res = append(res, toSessionKeyObject(sessionKey, value))
+		}
+		return true
+	})
+
+	sort.Slice(res, func(i, j int) bool {
+		ti, tj := time.Time(res[i].ReservedAt), time.Time(res[j].ReservedAt)
+		if ti.Equal(tj) {
+			return res[i].CheckSessionKey < res[j].CheckSessionKey
+		}
+		return ti.Before(tj)
+	})
+	return res
+}
+
+func (s sessionKeyServiceImpl) CountSessionKeys(prefix string) int64 {
+	var count int64
+	s.sessionKeyMap.Range(func(key, value interface{}) bool {
+		if strings.HasPrefix(key.(string), prefix) {
+			count++
+		}
+		return true
+	})
+	return count
+}
+
+func toSessionKeyObject(sessionKey string, value interface{}) *models.SessionKeyObject {
+	obj := &models.SessionKeyObject{CheckSessionKey: sessionKey}
+	if record, ok := value.(*sessionKeyRecord); ok {
+		obj.ReservedAt = strfmt.DateTime(record.ReservedAt)
+	}
+	return obj
+}
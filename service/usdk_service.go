@@ -1,71 +1,349 @@
 package service
 
 import (
+	"context"
 	"fmt"
 	"log"
-	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
+	"universalsdk/kvp"
+	"universalsdk/lists"
 	"universalsdk/models"
+	"universalsdk/velocity"
 )
 
 type usdkServiceImpl struct {
-	sessionKeyMap *sync.Map
+	sessionKeyMap  *sync.Map
+	replayTTL      time.Duration
+	echoNormalised bool
+	semantic       SemanticCatalogue
+	schemas        SchemaSet
+	activityTypes  ActivityTypeRegistry
+	keyScope       KeyScope
+	journeys       *JourneyStore
+	velocity       *velocity.Checker
+	lists          *lists.Store
+	now            func() time.Time
+
+	// When the replayed outcomes were last swept, in Unix nanoseconds
+	sweptAt *atomic.Int64
+}
+
+// Option configures optional behaviour of the UsdkService
+type Option func(*usdkServiceImpl)
+
+// WithReplayTTL makes an identical retry of a request within ttl return the
+// original response instead of a duplicate session key error. A retry
+// arriving while the original request is still running waits for its
+// outcome. Replay is disabled by default.
+func WithReplayTTL(ttl time.Duration) Option {
+	return func(u *usdkServiceImpl) {
+		u.replayTTL = ttl
+	}
+}
+
+// WithEchoNormalised returns the normalised activity data of every check in the response
+func WithEchoNormalised(echo bool) Option {
+	return func(u *usdkServiceImpl) {
+		u.echoNormalised = echo
+	}
+}
+
+// WithSemanticCatalogue validates the meaning of the values of well-known
+// kvpKeys, such as ip.address or country.code, on top of their data type.
+func WithSemanticCatalogue(catalogue SemanticCatalogue) Option {
+	return func(u *usdkServiceImpl) {
+		u.semantic = catalogue
+	}
 }
 
-func NewUsdkService(sessionKeyMap *sync.Map) UsdkService {
-	return usdkServiceImpl{sessionKeyMap: sessionKeyMap}
+// WithSchemas enforces the required and forbidden kvpKeys of each activityType
+func WithSchemas(schemas SchemaSet) Option {
+	return func(u *usdkServiceImpl) {
+		u.schemas = schemas
+	}
+}
+
+// WithActivityTypeRegistry rejects vendor specific activityTypes that are
+// not registered for the checkType. Without a registry any activityType
+// starting with an underscore is accepted.
+func WithActivityTypeRegistry(registry ActivityTypeRegistry) Option {
+	return func(u *usdkServiceImpl) {
+		u.activityTypes = registry
+	}
+}
+
+// WithKeyScope sets the part of a request within which kvpKeys must be unique
+func WithKeyScope(scope KeyScope) Option {
+	return func(u *usdkServiceImpl) {
+		u.keyScope = scope
+	}
+}
+
+// WithJourneyStore records journeys in store, so they can be read back by a
+// JourneyService sharing it. By default journeys are kept in a store of
+// the service itself with the DefaultTransitions.
+func WithJourneyStore(store *JourneyStore) Option {
+	return func(u *usdkServiceImpl) {
+		u.journeys = store
+	}
+}
+
+// WithVelocity counts every valid check against the velocity rules of the
+// checker. A tripped reject rule makes the response puppy false, every
+// tripped rule is listed in its flags.
+func WithVelocity(checker *velocity.Checker) Option {
+	return func(u *usdkServiceImpl) {
+		u.velocity = checker
+	}
+}
+
+// WithLists forces the outcome of checks matching an allow or deny list
+// entry: a deny match makes the response puppy false, an allow match passes
+// the check without counting it for velocity.
+func WithLists(store *lists.Store) Option {
+	return func(u *usdkServiceImpl) {
+		u.lists = store
+	}
+}
+
+// WithClock replaces time.Now as the time of the checks, which session key
+// reservations, replays, velocity and journeys are based on. Lists and
+// journey lookups have a clock of their own, lists.WithClock and
+// WithJourneyClock.
+func WithClock(now func() time.Time) Option {
+	return func(u *usdkServiceImpl) {
+		u.now = now
+	}
+}
+
+func NewUsdkService(sessionKeyMap *sync.Map, opts ...Option) UsdkService {
+	u := usdkServiceImpl{sessionKeyMap: sessionKeyMap, keyScope: DefaultKeyScope, journeys: NewJourneyStore(DefaultTransitions(), DefaultJourneyTTL), now: time.Now, sweptAt: new(atomic.Int64)}
+	for _, opt := range opts {
+		opt(&u)
+	}
+	return u
 }
 
 // This service layer function will perform business validations related to session key and activity data
-func (u usdkServiceImpl) DeviceCheck(deviceCheckCollection models.DeviceCheckDetailsObjectCollection) (*models.PuppyObject, error) {
+func (u usdkServiceImpl) DeviceCheck(deviceCheckCollection models.DeviceCheckDetailsObjectCollection) (*models.DeviceCheckResponseObject, error) {
+	return u.DeviceCheckContext(context.Background(), deviceCheckCollection)
+}
+
+func (u usdkServiceImpl) DeviceCheckContext(ctx context.Context, deviceCheckCollection models.DeviceCheckDetailsObjectCollection) (*models.DeviceCheckResponseObject, error) {
 	log.Printf("##  Usdk Service ##")
 
-	activityDataMap := make(map[string]bool)
+	// Nothing below handles null checks or activity data
+	if errs := deviceCheckCollection.ValidateNotNull(); len(errs) > 0 {
+		return nil, errs[0]
+	}
+
+	now := u.now()
+	if u.replayTTL <= 0 {
+		return u.deviceCheck(ctx, deviceCheckCollection, sessionKeyRecord{ReservedAt: now}, now)
+	}
+	u.sweepReplays(now)
+
+	// Only replays need the fingerprint, it costs an encoding of the request
+	fingerprint := requestFingerprint(deviceCheckCollection)
+	for {
+		original, running := replayRecord(deviceCheckCollection, fingerprint, u.sessionKeyMap, u.replayTTL, now)
+		if running != nil {
+			// An identical request is still running, replay its outcome once it completed
+			select {
+			case <-running:
+				continue
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+		if original != nil {
+			log.Printf("##  Replaying response of identical request ##")
+			return original.outcome()
+		}
+		break
+	}
+
+	reservation := sessionKeyRecord{ReservedAt: now, Fingerprint: fingerprint, Done: make(chan struct{})}
+	defer close(reservation.Done)
+	resp, err := u.deviceCheck(ctx, deviceCheckCollection, reservation, now)
+	storeResponse(deviceCheckCollection, fingerprint, u.sessionKeyMap, resp, err)
+	return resp, err
+}
+
+// deviceCheck reserves the session keys of the checks with a copy of reservation
+func (u usdkServiceImpl) deviceCheck(ctx context.Context, deviceCheckCollection models.DeviceCheckDetailsObjectCollection, reservation sessionKeyRecord, now time.Time) (*models.DeviceCheckResponseObject, error) {
+
+	keys := NewKeyTracker(u.keyScope)
 
 	// iterating deviceCheckCollection to validate session key, activity data 'kvpKey' uniqueness and data type
 	for _, elem := range deviceCheckCollection {
 		// Validate Session Key
-		err := validateSessionKey(elem, u.sessionKeyMap)
+		err := validateSessionKey(elem, reservation, u.sessionKeyMap)
 		if err != nil {
 			return nil, err
 		}
 
+		// Validate vendor specific Activity Type
+		if u.activityTypes != nil {
+			if err := u.activityTypes.validate(elem); err != nil {
+				return nil, err
+			}
+		}
+
 		// Validate Activity Data
-		errArray := validateActivityData(elem, activityDataMap)
+		errArray := validateActivityData(elem, keys)
+		if u.semantic != nil {
+			errArray = append(errArray, u.semantic.validate(elem)...)
+		}
+		if u.schemas != nil {
+			errArray = append(errArray, u.schemas.validate(elem)...)
+		}
 		if errArray != nil && len(errArray) > 0 {
 			str := strings.Join(errArray, ",")
 			return nil, fmt.Errorf("activity data validation %s", str)
 		}
 	}
 
-	return &models.PuppyObject{Puppy: true}, nil
+	// Validate the journey transitions, so a rejected check is never counted for velocity
+	if err := u.journeys.validate(deviceCheckCollection, now); err != nil {
+		return nil, err
+	}
+
+	// Every value is valid, replace them with their canonical form so lists
+	// and velocity match "007" and "7" alike
+	for _, elem := range deviceCheckCollection {
+		normaliseActivityData(elem)
+	}
+
+	resp := &models.DeviceCheckResponseObject{PuppyObject: models.PuppyObject{Puppy: true}}
+
+	// Every check is valid, match them against the allow and deny lists
+	var allowed map[*models.DeviceCheckDetailsObject]bool
+	if u.lists != nil {
+		allowed = make(map[*models.DeviceCheckDetailsObject]bool)
+		u.matchLists(ctx, deviceCheckCollection, resp, allowed)
+		if !resp.Puppy {
+			return resp, nil
+		}
+	}
+
+	// count the ones not allowed
+	if u.velocity != nil {
+		u.checkVelocity(ctx, deviceCheckCollection, resp, allowed, now)
+		if !resp.Puppy {
+			return resp, nil
+		}
+	}
+
+	// and add them to their journey
+	if err := u.journeys.record(deviceCheckCollection, now); err != nil {
+		return nil, err
+	}
+
+	if u.echoNormalised {
+		for _, elem := range deviceCheckCollection {
+			resp.Results = append(resp.Results, &models.DeviceCheckResultObject{
+				CheckSessionKey: elem.CheckSessionKey,
+				ActivityData:    elem.ActivityData,
+			})
+		}
+	}
+
+	return resp, nil
+}
+
+// matchLists adds the list entry matching each check to resp, sets puppy
+// to false when a check is denied and marks the allowed checks.
+func (u usdkServiceImpl) matchLists(ctx context.Context, deviceCheckCollection models.DeviceCheckDetailsObjectCollection, resp *models.DeviceCheckResponseObject, allowed map[*models.DeviceCheckDetailsObject]bool) {
+	caller := CallerFromContext(ctx)
+	for _, elem := range deviceCheckCollection {
+		entry, ok := u.lists.Match(caller, elem)
+		if !ok {
+			continue
+		}
+
+		log.Printf("##  Check %s matches %s list entry %s ##", elem.CheckSessionKey, entry.List, entry.ID)
+		resp.ListMatches = append(resp.ListMatches, &models.ListMatchObject{
+			CheckSessionKey: elem.CheckSessionKey,
+			EntryID:         entry.ID,
+			KvpKey:          entry.KvpKey,
+			List:            entry.List,
+			Reason:          entry.Reason,
+		})
+		if entry.List == models.ListEntryObjectListDeny {
+			resp.Puppy = false
+		} else {
+			allowed[elem] = true
+		}
+	}
+}
+
+// checkVelocity adds a flag to resp for every velocity rule tripped by a
+// check that is not allowed, and sets puppy to false when one of them
+// rejects the check. A rule whose store fails is flagged as not enforced,
+// and rejects the check when the rule fails closed.
+func (u usdkServiceImpl) checkVelocity(ctx context.Context, deviceCheckCollection models.DeviceCheckDetailsObjectCollection, resp *models.DeviceCheckResponseObject, allowed map[*models.DeviceCheckDetailsObject]bool, now time.Time) {
+	for _, elem := range deviceCheckCollection {
+		if allowed[elem] {
+			continue
+		}
+
+		trips, err := u.velocity.Check(ctx, elem, now)
+		if err != nil {
+			log.Printf("velocity check of %s not enforced: %v", elem.CheckSessionKey, err)
+		}
+
+		for _, trip := range trips {
+			flag := &models.VelocityFlagObject{
+				Action:          trip.Rule.Action,
+				CheckSessionKey: elem.CheckSessionKey,
+				Count:           trip.Count,
+				Limit:           trip.Rule.Limit,
+				Rule:            trip.Rule.Name,
+				Window:          trip.Rule.Window.String(),
+			}
+			if trip.Err != nil {
+				flag.NotEnforced = true
+				flag.Action = velocity.ActionFlag
+				if trip.Rule.FailsClosed() {
+					flag.Action = velocity.ActionReject
+				}
+			}
+			resp.Flags = append(resp.Flags, flag)
+			if flag.Action == velocity.ActionReject {
+				resp.Puppy = false
+			}
+		}
+	}
 }
 
 // The function validates the session key
 // Session key must be unique or an error will be returned.
-func validateSessionKey(dCheckDetailsObject *models.DeviceCheckDetailsObject, sessionKeyMap *sync.Map) error {
+// The fingerprint of the request is kept with the key so identical retries can be recognised.
+func validateSessionKey(dCheckDetailsObject *models.DeviceCheckDetailsObject, reservation sessionKeyRecord, sessionKeyMap *sync.Map) error {
 
 	if dCheckDetailsObject.CheckSessionKey == "" {
 		return nil
 	}
 
-	_, ok := sessionKeyMap.Load(dCheckDetailsObject.CheckSessionKey)
+	record := reservation
+	_, loaded := sessionKeyMap.LoadOrStore(dCheckDetailsObject.CheckSessionKey, &record)
 
-	if ok {
+	if loaded {
 		return fmt.Errorf("checkSessionKey should be unique")
 	}
 
-	sessionKeyMap.Store(dCheckDetailsObject.CheckSessionKey, "Y")
-
 	return nil
 }
 
 // The function validate
-// * the list of "Keys" in ActivityData are unique to the call (no double-ups)
+// * the list of "Keys" in ActivityData are unique within the key scope (no double-ups)
 // * that the Value provided matches the Type specified.
 // Should the verification fail, the error message returned will include information for each KVP pair that fails.
-func validateActivityData(dCheckDetailsObject *models.DeviceCheckDetailsObject, activityMap map[string]bool) []string {
+func validateActivityData(dCheckDetailsObject *models.DeviceCheckDetailsObject, keys *KeyTracker) []string {
 
 	var errstrings []string
 
@@ -76,11 +354,9 @@ func validateActivityData(dCheckDetailsObject *models.DeviceCheckDetailsObject,
 
 	for _, elem := range dCheckDetailsObject.ActivityData {
 		// Validate uniqueness of KvpKey
-		keyResp := activityMap[elem.KvpKey]
-		if keyResp {
-			errstrings = append(errstrings, fmt.Sprintf("KvpKey %s is not unique", elem.KvpKey))
+		if err := keys.Add(dCheckDetailsObject, elem.KvpKey); err != nil {
+			errstrings = append(errstrings, err.Error())
 		}
-		activityMap[elem.KvpKey] = true
 
 		// Validate Data Type of Kvp
 		err := validateDataType(elem.KvpValue, elem.KvpType)
@@ -94,21 +370,5 @@ func validateActivityData(dCheckDetailsObject *models.DeviceCheckDetailsObject,
 
 // This function validates that provided value is of dataType mentioned in EnumKVPType
 func validateDataType(value string, dataType models.EnumKVPType) error {
-	var err error
-
-	switch dataType {
-	case "general.integer":
-		_, err = strconv.ParseInt(value, 10, 64)
-	case "general.float":
-		_, err = strconv.ParseFloat(value, 64)
-	case "general.bool":
-		_, err = strconv.ParseBool(value)
-	case "general.string":
-
-	default:
-		err = fmt.Errorf("data type %s invalid", dataType)
-	}
-
-	return err
-
+	return kvp.ValidateDataType(value, dataType)
 }
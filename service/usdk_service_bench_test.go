@@ -0,0 +1,59 @@
+package service
+
+import (
+	"io"
+	"log"
+	"os"
+	"sync"
+	"testing"
+	"universalsdk/models"
+	"universalsdk/testutil"
+)
+
+// BenchmarkDeviceCheck measures the business validations of valid
+// requests. The session keys are released after every check so each
+// iteration reserves them again.
+func BenchmarkDeviceCheck(b *testing.B) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	for _, size := range testutil.BatchSizes {
+		collection := testutil.Batch(size.Checks, size.KVPs)
+
+		b.Run(size.Name, func(b *testing.B) {
+			var sessionKeyMap sync.Map
+			usdkService := NewUsdkService(&sessionKeyMap)
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := usdkService.DeviceCheck(collection); err != nil {
+					b.Fatal(err)
+				}
+				release(&sessionKeyMap, collection)
+			}
+		})
+	}
+}
+
+func BenchmarkValidateActivityData(b *testing.B) {
+	for _, size := range testutil.BatchSizes {
+		collection := testutil.Batch(size.Checks, size.KVPs)
+
+		b.Run(size.Name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				keys := NewKeyTracker(DefaultKeyScope)
+				for _, elem := range collection {
+					if errs := validateActivityData(elem, keys); len(errs) > 0 {
+						b.Fatal(errs)
+					}
+				}
+			}
+		})
+	}
+}
+
+func release(sessionKeyMap *sync.Map, collection models.DeviceCheckDetailsObjectCollection) {
+	for _, elem := range collection {
+		sessionKeyMap.Delete(elem.CheckSessionKey)
+	}
+}
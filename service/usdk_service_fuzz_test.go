@@ -0,0 +1,108 @@
+package service
+
+import (
+	"sync"
+	"testing"
+	"time"
+	"universalsdk/lists"
+	"universalsdk/models"
+)
+
+// FuzzDeviceCheck runs a check built from fuzzed fields through every
+// optional validation. It must be rejected or pass, never panic.
+func FuzzDeviceCheck(f *testing.F) {
+	f.Add("DEVICE", "SIGNUP", "fuzz-1", "ip.address", "1.23.45.123", "general.string")
+	f.Add("BIOMETRIC", "PAYMENT", "fuzz-2", "payment.amount", "10.005", "general.float")
+	f.Add("COMBO", "_LOGIN_3", "", "geo.location", "91,181", "general.string")
+	f.Add("DEVICE", "LOGIN", "fuzz-3", "raw.body", "e30=", "raw.json")
+	f.Add("", "", "", "", "", "")
+
+	catalogue, err := NewSemanticCatalogue(nil)
+	if err != nil {
+		f.Fatal(err)
+	}
+	var sessionKeyMap sync.Map
+	usdkService := NewUsdkService(&sessionKeyMap,
+		WithSemanticCatalogue(catalogue),
+		WithLists(lists.NewStore([]string{"ip.address"})),
+		WithEchoNormalised(true),
+		WithReplayTTL(time.Minute))
+
+	f.Fuzz(func(t *testing.T, checkType, activityType, sessionKey, kvpKey, kvpValue, kvpType string) {
+		collection := models.DeviceCheckDetailsObjectCollection{{
+			CheckType:       checkType,
+			ActivityType:    activityType,
+			CheckSessionKey: sessionKey,
+			ActivityData:    []*models.KeyValuePairObject{{KvpKey: kvpKey, KvpValue: kvpValue, KvpType: models.EnumKVPType(kvpType)}},
+		}}
+
+		resp, err := usdkService.DeviceCheck(collection)
+		if err == nil && resp == nil {
+			t.Fatalf("no response nor error for %#v", collection[0])
+		}
+	})
+}
+
+// FuzzValidateDataType checks that a value is valid exactly when it
+// normalises, and that its canonical form is valid and stable
+func FuzzValidateDataType(f *testing.F) {
+	f.Add("007", "general.integer")
+	f.Add("1e3", "general.float")
+	f.Add("TRUE", "general.bool")
+	f.Add("e30=", "raw.json")
+
+	f.Fuzz(func(t *testing.T, value, kvpType string) {
+		dataType := models.EnumKVPType(kvpType)
+		err := validateDataType(value, dataType)
+		canonical, normaliseErr := normaliseValue(value, dataType)
+		if (err == nil) != (normaliseErr == nil) {
+			t.Fatalf("%s %q: validate %v, normalise %v", kvpType, value, err, normaliseErr)
+		}
+		if err != nil {
+			return
+		}
+		if err := validateDataType(canonical, dataType); err != nil {
+			t.Fatalf("%s %q: canonical %q invalid: %v", kvpType, value, canonical, err)
+		}
+		if again, _ := normaliseValue(canonical, dataType); again != canonical {
+			t.Fatalf("%s %q: canonical %q normalises to %q", kvpType, value, canonical, again)
+		}
+	})
+}
+
+// FuzzDeviceCheckFlow submits a check twice. A rejected check stays
+// rejected, an accepted one is replayed or rejected as a duplicate
+// unless it has no checkSessionKey, which is optional.
+func FuzzDeviceCheckFlow(f *testing.F) {
+	f.Add("flow-1", "1.23.45.123", "general.string", false)
+	f.Add("flow-2", "007", "general.integer", true)
+	f.Add("", "maybe", "general.bool", true)
+
+	f.Fuzz(func(t *testing.T, sessionKey, kvpValue, kvpType string, replay bool) {
+		var sessionKeyMap sync.Map
+		var opts []Option
+		if replay {
+			opts = append(opts, WithReplayTTL(time.Minute))
+		}
+		usdkService := NewUsdkService(&sessionKeyMap, opts...)
+		check := func() (*models.DeviceCheckResponseObject, error) {
+			return usdkService.DeviceCheck(models.DeviceCheckDetailsObjectCollection{{
+				CheckType:       models.DeviceCheckDetailsObjectCheckTypeDEVICE,
+				ActivityType:    models.DeviceCheckDetailsObjectActivityTypeSIGNUP,
+				CheckSessionKey: sessionKey,
+				ActivityData:    []*models.KeyValuePairObject{{KvpKey: "flow.value", KvpValue: kvpValue, KvpType: models.EnumKVPType(kvpType)}},
+			}})
+		}
+
+		first, firstErr := check()
+		second, secondErr := check()
+		switch {
+		case firstErr != nil && secondErr == nil:
+			t.Fatalf("rejected check %q %s %q accepted on retry", sessionKey, kvpType, kvpValue)
+		case firstErr == nil && replay && (secondErr != nil || second.Puppy != first.Puppy):
+			t.Fatalf("accepted check %q not replayed: %v", sessionKey, secondErr)
+		case firstErr == nil && !replay && sessionKey != "" && secondErr == nil:
+			t.Fatalf("accepted check %q accepted again", sessionKey)
+		}
+	})
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"testing/quick"
+	"universalsdk/models"
+)
+
+// quickConfig runs the property tests with a fixed seed so failures can be reproduced
+func quickConfig() *quick.Config {
+	return &quick.Config{MaxCount: 500, Rand: rand.New(rand.NewSource(1))}
+}
+
+func (suite *UsdkServiceSuite) TestPropertyValidKVP() {
+	// Valid values normalise to a canonical form that is itself valid and stable
+	property := func(kvp validKVP) bool {
+		canonical, err := normaliseValue(kvp.KvpValue, kvp.KvpType)
+		if err != nil {
+			suite.T().Logf("%s %q: %v", kvp.KvpType, kvp.KvpValue, err)
+			return false
+		}
+		again, err := normaliseValue(canonical, kvp.KvpType)
+		return err == nil && again == canonical
+	}
+	suite.NoError(quick.Check(property, quickConfig()))
+}
+
+func (suite *UsdkServiceSuite) TestPropertyInvalidKVP() {
+	property := func(kvp invalidKVP) bool {
+		return validateDataType(kvp.KvpValue, kvp.KvpType) != nil
+	}
+	suite.NoError(quick.Check(property, quickConfig()))
+}
+
+func (suite *UsdkServiceSuite) TestPropertyDeviceCheck() {
+	var sessionKeyMap sync.Map
+	usdkService := NewUsdkService(&sessionKeyMap, WithEchoNormalised(true))
+	sessions := 0
+
+	// A check of valid KVPs with unique keys passes, echoing canonical values,
+	// and fails once an invalid KVP is added
+	property := func(kvps []validKVP, bad invalidKVP) bool {
+		sessions++
+		check := &models.DeviceCheckDetailsObject{
+			CheckType:       models.DeviceCheckDetailsObjectCheckTypeDEVICE,
+			ActivityType:    models.DeviceCheckDetailsObjectActivityTypeLOGIN,
+			CheckSessionKey: "property-" + strconv.Itoa(sessions),
+		}
+		for i, kvp := range kvps {
+			kvp.KvpKey += "." + strconv.Itoa(i)
+			check.ActivityData = append(check.ActivityData, kvp.KeyValuePairObject)
+		}
+
+		invalid := *check
+		invalid.CheckSessionKey += "-invalid"
+		invalid.ActivityData = append(append([]*models.KeyValuePairObject{}, check.ActivityData...), bad.KeyValuePairObject)
+		if _, err := usdkService.DeviceCheck(models.DeviceCheckDetailsObjectCollection{&invalid}); err == nil ||
+			!strings.Contains(err.Error(), "KvpKey "+bad.KvpKey) {
+			suite.T().Logf("invalid %s %q: %v", bad.KvpType, bad.KvpValue, err)
+			return false
+		}
+
+		resp, err := usdkService.DeviceCheck(models.DeviceCheckDetailsObjectCollection{check})
+		if err != nil || !resp.Puppy || len(resp.Results) != 1 {
+			suite.T().Logf("valid check failure: %v", err)
+			return false
+		}
+		for _, kvp := range resp.Results[0].ActivityData {
+			if canonical, err := normaliseValue(kvp.KvpValue, kvp.KvpType); err != nil || canonical != kvp.KvpValue {
+				return false
+			}
+		}
+		return true
+	}
+	suite.NoError(quick.Check(property, &quick.Config{MaxCount: 100, Rand: rand.New(rand.NewSource(1))}))
+}
@@ -1,13 +1,17 @@
 package service
 
 import (
+	"context"
+	"fmt"
 	"github.com/stretchr/testify/suite"
 	"log"
-	"strconv"
+	"strings"
 	"sync"
 	"testing"
+	"time"
 	"universalsdk/models"
-	"universalsdk/util"
+	"universalsdk/testutil"
+	"universalsdk/velocity"
 )
 
 type UsdkServiceSuite struct {
@@ -27,28 +31,28 @@ func (suite *UsdkServiceSuite) TestValidateSessionKey() {
 
 	// Test Unique Session Key
 	deviceCheckModel.CheckSessionKey = "123654"
-	err := validateSessionKey(deviceCheckModel, &sessionKeyMap)
+	err := validateSessionKey(deviceCheckModel, sessionKeyRecord{ReservedAt: time.Now()}, &sessionKeyMap)
 	if err != nil {
 		suite.T().Errorf("validate session key failure %s", err.Error())
 	}
 
 	// Test Unique Session Key
 	deviceCheckModel.CheckSessionKey = "369852"
-	err = validateSessionKey(deviceCheckModel, &sessionKeyMap)
+	err = validateSessionKey(deviceCheckModel, sessionKeyRecord{ReservedAt: time.Now()}, &sessionKeyMap)
 	if err != nil {
 		suite.T().Errorf("validate session key failure %s", err.Error())
 	}
 
 	// Test Duplicate Session Key
 	deviceCheckModel.CheckSessionKey = "123654"
-	err = validateSessionKey(deviceCheckModel, &sessionKeyMap)
+	err = validateSessionKey(deviceCheckModel, sessionKeyRecord{ReservedAt: time.Now()}, &sessionKeyMap)
 	if err == nil {
-		suite.T().Errorf("validate session key expecting failure got none %s", err.Error())
+		suite.T().Errorf("validate session key expecting failure got none")
 	}
 }
 
 func (suite *UsdkServiceSuite) TestValidateActivityData() {
-	activityDataMap := make(map[string]bool)
+	keys := NewKeyTracker(KeyScopeCollection)
 
 	keyValuePairObject1 := &models.KeyValuePairObject{KvpKey: "ip.address", KvpValue: "1.23.45.123", KvpType: models.EnumKVPType("general.string")}
 	keyValuePairObject2 := &models.KeyValuePairObject{KvpKey: "mac.address", KvpValue: "1.23.45.123", KvpType: models.EnumKVPType("general.string")}
@@ -57,14 +61,14 @@ func (suite *UsdkServiceSuite) TestValidateActivityData() {
 	deviceCheckModel := &models.DeviceCheckDetailsObject{ActivityData: keyValArray}
 
 	// Test Unique Key
-	err := validateActivityData(deviceCheckModel, activityDataMap)
+	err := validateActivityData(deviceCheckModel, keys)
 	if err != nil && len(err) > 0 {
 		suite.T().Errorf("validate activity data key uniqueness failure")
 	}
 
 	// Test duplicate Key
 	keyValuePairObject2.KvpType = "ip.address"
-	err = validateActivityData(deviceCheckModel, activityDataMap)
+	err = validateActivityData(deviceCheckModel, keys)
 	if err == nil || len(err) <= 0 {
 		suite.T().Errorf("validate activity data duplicate key failure")
 	}
@@ -73,8 +77,8 @@ func (suite *UsdkServiceSuite) TestValidateActivityData() {
 	keyValuePairObject2.KvpType = "web"
 	keyValuePairObject2.KvpType = models.EnumKVPTypeGeneralInteger
 	keyValuePairObject2.KvpValue = "www"
-	err = validateActivityData(deviceCheckModel, activityDataMap)
-	if err == nil && len(err) <= 0 {
+	err = validateActivityData(deviceCheckModel, keys)
+	if len(err) == 0 {
 		suite.T().Errorf("validate activity data - invalid data type ")
 	}
 
@@ -107,25 +111,595 @@ func (suite *UsdkServiceSuite) TestValidateDataType() {
 	// Test with Invalid values
 	err = validateDataType("123", models.EnumKVPTypeGeneralBool)
 	if err == nil {
-		suite.T().Errorf("expecting error, got none %s", err.Error())
+		suite.T().Errorf("expecting error, got none")
 	}
 	err = validateDataType("test", models.EnumKVPTypeGeneralFloat)
 	if err == nil {
-		suite.T().Errorf("expecting error, got none %s", err.Error())
+		suite.T().Errorf("expecting error, got none")
 	}
 	err = validateDataType("test", models.EnumKVPTypeGeneralInteger)
 	if err == nil {
-		suite.T().Errorf("expecting error, got none %s", err.Error())
+		suite.T().Errorf("expecting error, got none")
 	}
 }
 
+func (suite *UsdkServiceSuite) TestNormaliseValue() {
+	tests := []struct {
+		value     string
+		dataType  models.EnumKVPType
+		canonical string
+	}{
+		{"007", models.EnumKVPTypeGeneralInteger, "7"},
+		{"+42", models.EnumKVPTypeGeneralInteger, "42"},
+		{"-0", models.EnumKVPTypeGeneralInteger, "0"},
+		{"1e3", models.EnumKVPTypeGeneralFloat, "1000"},
+		{"1.50", models.EnumKVPTypeGeneralFloat, "1.5"},
+		{"-0.0", models.EnumKVPTypeGeneralFloat, "0"},
+		{"1e-7", models.EnumKVPTypeGeneralFloat, "1e-07"},
+		{"TRUE", models.EnumKVPTypeGeneralBool, "true"},
+		{"0", models.EnumKVPTypeGeneralBool, "false"},
+		{" Mixed Case ", models.EnumKVPTypeGeneralString, " Mixed Case "},
+		{"Jane Citizen", models.EnumKVPTypePiiName, "Jane Citizen"},
+		{"eyJhIjoxfQ==", models.EnumKVPTypeRawJSON, "eyJhIjoxfQ=="},
+	}
+	for _, test := range tests {
+		canonical, err := normaliseValue(test.value, test.dataType)
+		if err != nil {
+			suite.T().Errorf("normalise %s as %s failure %s", test.value, test.dataType, err)
+			continue
+		}
+		if canonical != test.canonical {
+			suite.T().Errorf("normalise %s as %s expecting %s got %s", test.value, test.dataType, test.canonical, canonical)
+		}
+	}
+
+	for _, value := range []string{"NaN", "Inf", "-Inf"} {
+		if _, err := normaliseValue(value, models.EnumKVPTypeGeneralFloat); err == nil {
+			suite.T().Errorf("normalise %s as float expecting error got none", value)
+		}
+	}
+
+	_, err := normaliseValue(`{"a":1}`, models.EnumKVPTypeRawJSON)
+	suite.EqualError(err, "kvpValue of raw.json should be base64 encoded")
+
+	// Errors are logged and audited, they never hold the value
+	_, err = normaliseValue("secret", models.EnumKVPTypeGeneralInteger)
+	suite.EqualError(err, "kvpValue is not a valid general.integer")
+	_, err = normaliseValue("99999999999999999999", models.EnumKVPTypeGeneralInteger)
+	suite.EqualError(err, "kvpValue is out of range for general.integer")
+	_, err = normaliseValue("Inf", models.EnumKVPTypeGeneralFloat)
+	suite.EqualError(err, "kvpValue is not a finite number")
+}
+
+func (suite *UsdkServiceSuite) TestValidateActivityDataAllocations() {
+	// Canonical values are validated and normalised without allocating
+	for _, kvp := range testutil.Batch(1, 5)[0].ActivityData {
+		allocs := testing.AllocsPerRun(100, func() {
+			if _, err := normaliseValue(kvp.KvpValue, kvp.KvpType); err != nil {
+				suite.Fail(err.Error())
+			}
+		})
+		suite.Zero(allocs, "%s %s", kvp.KvpType, kvp.KvpValue)
+	}
+
+	// The kvpKeys of a request share one map
+	collection := testutil.Batch(10, 10)
+	allocs := testing.AllocsPerRun(100, func() {
+		keys := NewKeyTracker(KeyScopeElement)
+		for _, elem := range collection {
+			if errs := validateActivityData(elem, keys); len(errs) > 0 {
+				suite.Fail(errs[0])
+			}
+		}
+	})
+	suite.LessOrEqual(allocs, 4.0)
+}
+
+func (suite *UsdkServiceSuite) TestDeviceCheckEchoNormalised() {
+	var sessionKeyMap sync.Map
+	usdkService := NewUsdkService(&sessionKeyMap, WithEchoNormalised(true))
+
+	checkRequest := mockRequest()
+	checkRequest[0].ActivityData = append(checkRequest[0].ActivityData,
+		&models.KeyValuePairObject{KvpKey: "login.attempts", KvpValue: "007", KvpType: models.EnumKVPTypeGeneralInteger},
+		&models.KeyValuePairObject{KvpKey: "risk.score", KvpValue: "1e3", KvpType: models.EnumKVPTypeGeneralFloat},
+		&models.KeyValuePairObject{KvpKey: "vpn", KvpValue: "TRUE", KvpType: models.EnumKVPTypeGeneralBool})
+
+	resp, err := usdkService.DeviceCheck(checkRequest)
+	if err != nil {
+		suite.T().Fatalf("Device Check failure %s", err)
+	}
+	if len(resp.Results) != 1 || resp.Results[0].CheckSessionKey != checkRequest[0].CheckSessionKey {
+		suite.T().Fatalf("Expecting one result for the check got %v", resp.Results)
+	}
+
+	kvps := resp.Results[0].ActivityData
+	values := []string{kvps[1].KvpValue, kvps[2].KvpValue, kvps[3].KvpValue}
+	if values[0] != "7" || values[1] != "1000" || values[2] != "true" {
+		suite.T().Errorf("Expecting normalised values 7, 1000, true got %v", values)
+	}
+
+	// Typed accessors give the parsed values
+	if i, err := kvps[1].IntValue(); err != nil || i != 7 {
+		suite.T().Errorf("Expecting IntValue 7 got %d %v", i, err)
+	}
+	if f, err := kvps[2].FloatValue(); err != nil || f != 1000 {
+		suite.T().Errorf("Expecting FloatValue 1000 got %f %v", f, err)
+	}
+	if b, err := kvps[3].BoolValue(); err != nil || !b {
+		suite.T().Errorf("Expecting BoolValue true got %t %v", b, err)
+	}
+	if _, err := kvps[0].IntValue(); err == nil {
+		suite.T().Errorf("Expecting IntValue of a general.string to fail")
+	}
+
+	// Results are only returned when enabled
+	resp, _ = createService().DeviceCheck(mockRequest())
+	if resp.Results != nil {
+		suite.T().Errorf("Expecting no results by default got %v", resp.Results)
+	}
+}
+
+func (suite *UsdkServiceSuite) TestSemanticCatalogue() {
+	catalogue, err := NewSemanticCatalogue(map[string]string{"client.ip": "ipv4", "user.agent": ""})
+	if err != nil {
+		suite.T().Fatalf("Semantic catalogue failure %s", err)
+	}
+
+	tests := []struct {
+		key   string
+		value string
+		valid bool
+	}{
+		{"ip.address", "1.23.45.123", true},
+		{"ip.address", "2001:db8::1", true},
+		{"ip.address", "1.23.45.323", false},
+		{"client.ip", "2001:db8::1", false},
+		{"ipv6.address", "1.23.45.123", false},
+		{"mac.address", "00:1a:2b:3c:4d:5e", true},
+		{"mac.address", "1.23.45.123", false},
+		{"country.code", "NZ", true},
+		{"country.code", "nz", false},
+		{"currency.code", "XYZ", false},
+		{"device.fingerprint", "d41d8cd98f00b204e9800998ecf8427e", true},
+		{"device.fingerprint", "d41d8cd98f00b204", false},
+		{"geo.latitude", "-36.8485", true},
+		{"geo.longitude", "181", false},
+		{"geo.location", "-36.8485,174.7633", true},
+		{"geo.location", "-36.8485", false},
+		{"email.address", "jane@example.com", true},
+		{"email.address", "Jane <jane@example.com>", false},
+		{"user.agent", "\x00", true},
+		{"login.attempts", "anything", true},
+	}
+	for _, test := range tests {
+		check := &models.DeviceCheckDetailsObject{ActivityData: []*models.KeyValuePairObject{
+			{KvpKey: test.key, KvpValue: test.value, KvpType: models.EnumKVPTypeGeneralString},
+		}}
+		errs := catalogue.validate(check)
+		if test.valid && len(errs) > 0 {
+			suite.T().Errorf("%s %s expecting valid got %v", test.key, test.value, errs)
+		}
+		if !test.valid && len(errs) != 1 {
+			suite.T().Errorf("%s %s expecting one error got %v", test.key, test.value, errs)
+		}
+		// errors are logged and audited, they never hold the value
+		for _, err := range errs {
+			suite.NotContains(err, test.value)
+		}
+	}
+
+	if _, err := NewSemanticCatalogue(map[string]string{"ip.address": "unknown"}); err == nil {
+		suite.T().Errorf("Expecting unknown validator to fail got none")
+	}
+}
+
+func (suite *UsdkServiceSuite) TestSemanticAmount() {
+	catalogue, _ := NewSemanticCatalogue(nil)
+
+	tests := []struct {
+		currency string
+		amount   string
+		valid    bool
+	}{
+		{"NZD", "10.50", true},
+		{"NZD", "10.505", false},
+		{"JPY", "1000", true},
+		{"JPY", "1000.5", false},
+		{"", "10", false},
+		{"NZD", "-1", false},
+	}
+	for _, test := range tests {
+		check := &models.DeviceCheckDetailsObject{ActivityData: []*models.KeyValuePairObject{
+			{KvpKey: "payment.amount", KvpValue: test.amount, KvpType: models.EnumKVPTypeGeneralFloat},
+		}}
+		if test.currency != "" {
+			check.ActivityData = append(check.ActivityData,
+				&models.KeyValuePairObject{KvpKey: "currency.code", KvpValue: test.currency, KvpType: models.EnumKVPTypeGeneralString})
+		}
+		errs := catalogue.validate(check)
+		if test.valid != (len(errs) == 0) {
+			suite.T().Errorf("%s %s expecting valid %t got %v", test.amount, test.currency, test.valid, errs)
+		}
+		for _, err := range errs {
+			suite.NotContains(err, test.amount)
+		}
+	}
+}
+
+func (suite *UsdkServiceSuite) TestDeviceCheckSemantic() {
+	catalogue, _ := NewSemanticCatalogue(nil)
+	var sessionKeyMap sync.Map
+	usdkService := NewUsdkService(&sessionKeyMap, WithSemanticCatalogue(catalogue))
+
+	if _, err := usdkService.DeviceCheck(mockRequest()); err != nil {
+		suite.T().Errorf("Device Check with valid ip.address failure %s", err)
+	}
+
+	checkRequest := mockRequest()
+	checkRequest[0].ActivityData = append(checkRequest[0].ActivityData,
+		&models.KeyValuePairObject{KvpKey: "mac.address", KvpValue: "1.23.45.123", KvpType: models.EnumKVPTypeGeneralString})
+	_, err := usdkService.DeviceCheck(checkRequest)
+	if err == nil {
+		suite.T().Fatalf("Device Check with invalid mac.address. Expecting failure got none")
+	}
+	if !strings.Contains(err.Error(), "KvpKey mac.address") {
+		suite.T().Errorf("Expecting error for mac.address got %s", err)
+	}
+
+	// Semantic validation is opt-in
+	checkRequest[0].CheckSessionKey += "0"
+	if _, err := createService().DeviceCheck(checkRequest); err != nil {
+		suite.T().Errorf("Device Check without semantic validation failure %s", err)
+	}
+}
+
+func (suite *UsdkServiceSuite) TestSchemaSet() {
+	schemas, err := NewSchemaSet([]ActivitySchema{
+		{
+			ActivityType: models.DeviceCheckDetailsObjectActivityTypePAYMENT,
+			Required: []RequiredKey{
+				{Key: "payment.amount", Type: models.EnumKVPTypeGeneralFloat},
+				{Key: "currency.code", Type: models.EnumKVPTypeGeneralString},
+			},
+		},
+		{
+			ActivityType: models.DeviceCheckDetailsObjectActivityTypePAYMENT,
+			CheckType:    models.DeviceCheckDetailsObjectCheckTypeBIOMETRIC,
+			Forbidden:    []string{"ip.address"},
+		},
+	})
+	if err != nil {
+		suite.T().Fatalf("Schema set failure %s", err)
+	}
+
+	payment := func(checkType string, kvps ...*models.KeyValuePairObject) *models.DeviceCheckDetailsObject {
+		return &models.DeviceCheckDetailsObject{ActivityType: "PAYMENT", CheckType: checkType, ActivityData: kvps}
+	}
+	amount := &models.KeyValuePairObject{KvpKey: "payment.amount", KvpValue: "10.50", KvpType: models.EnumKVPTypeGeneralFloat}
+	currency := &models.KeyValuePairObject{KvpKey: "currency.code", KvpValue: "NZD", KvpType: models.EnumKVPTypeGeneralString}
+	ip := &models.KeyValuePairObject{KvpKey: "ip.address", KvpValue: "1.23.45.123", KvpType: models.EnumKVPTypeGeneralString}
+	stringAmount := &models.KeyValuePairObject{KvpKey: "payment.amount", KvpValue: "10.50", KvpType: models.EnumKVPTypeGeneralString}
+
+	tests := []struct {
+		check  *models.DeviceCheckDetailsObject
+		errors []string
+	}{
+		{payment("DEVICE", amount, currency, ip), nil},
+		{payment("DEVICE", ip), []string{
+			"KvpKey payment.amount is required for activityType PAYMENT",
+			"KvpKey currency.code is required for activityType PAYMENT",
+		}},
+		{payment("DEVICE", stringAmount, currency), []string{
+			"KvpKey payment.amount should be general.float for activityType PAYMENT got general.string",
+		}},
+		// the checkType schema replaces the activityType one
+		{payment("BIOMETRIC", ip), []string{
+			"KvpKey ip.address is not allowed for activityType PAYMENT checkType BIOMETRIC",
+		}},
+		{&models.DeviceCheckDetailsObject{ActivityType: "LOGIN", ActivityData: []*models.KeyValuePairObject{ip}}, nil},
+	}
+	for i, test := range tests {
+		errs := schemas.validate(test.check)
+		if strings.Join(errs, ",") != strings.Join(test.errors, ",") {
+			suite.T().Errorf("test %d expecting %v got %v", i, test.errors, errs)
+		}
+	}
+
+	invalid := [][]ActivitySchema{
+		{{Required: []RequiredKey{{Key: "payment.amount", Type: models.EnumKVPTypeGeneralFloat}}}},
+		{{ActivityType: "PAYMENT", Required: []RequiredKey{{Key: "payment.amount", Type: "general.money"}}}},
+		{{ActivityType: "PAYMENT", Required: []RequiredKey{{Key: "ip.address", Type: models.EnumKVPTypeGeneralString}}, Forbidden: []string{"ip.address"}}},
+		{{ActivityType: "PAYMENT"}, {ActivityType: "PAYMENT"}},
+	}
+	for i, schemas := range invalid {
+		if _, err := NewSchemaSet(schemas); err == nil {
+			suite.T().Errorf("invalid schema %d expecting error got none", i)
+		}
+	}
+}
+
+func (suite *UsdkServiceSuite) TestDeviceCheckSchema() {
+	schemas, _ := NewSchemaSet([]ActivitySchema{{
+		ActivityType: models.DeviceCheckDetailsObjectActivityTypeSIGNUP,
+		Required:     []RequiredKey{{Key: "email.address", Type: models.EnumKVPTypeGeneralString}},
+	}})
+	var sessionKeyMap sync.Map
+	usdkService := NewUsdkService(&sessionKeyMap, WithSchemas(schemas))
+
+	_, err := usdkService.DeviceCheck(mockRequest())
+	if err == nil || !strings.Contains(err.Error(), "KvpKey email.address is required for activityType SIGNUP") {
+		suite.T().Errorf("Device Check SIGNUP without email.address. Expecting missing key error got %v", err)
+	}
+}
+
+func (suite *UsdkServiceSuite) TestActivityTypeRegistry() {
+	registry, err := NewActivityTypeRegistry([]VendorActivityTypes{
+		{Vendor: "biocatch", CheckType: models.DeviceCheckDetailsObjectCheckTypeBIOMETRIC, ActivityTypes: []string{"_LOGIN_3", "_PAYMENT_2"}},
+		{Vendor: "threatmetrix", ActivityTypes: []string{"_ACCOUNT_UPDATE"}},
+	})
+	if err != nil {
+		suite.T().Fatalf("Activity type registry failure %s", err)
+	}
+
+	tests := []struct {
+		activityType string
+		checkType    string
+		valid        bool
+	}{
+		{"_LOGIN_3", "BIOMETRIC", true},
+		{"_LOGIN_3", "DEVICE", false},
+		{"_LOGIN_4", "BIOMETRIC", false},
+		{"_ACCOUNT_UPDATE", "DEVICE", true},
+		{"LOGIN", "DEVICE", true},
+	}
+	for _, test := range tests {
+		err := registry.validate(&models.DeviceCheckDetailsObject{ActivityType: test.activityType, CheckType: test.checkType})
+		if test.valid != (err == nil) {
+			suite.T().Errorf("%s %s expecting valid %t got %v", test.activityType, test.checkType, test.valid, err)
+		}
+	}
+
+	err = registry.validate(&models.DeviceCheckDetailsObject{ActivityType: "_LOGIN_4", CheckType: "BIOMETRIC"})
+	expected := "activityType _LOGIN_4 is not a registered vendor activityType for checkType BIOMETRIC, valid choices are _ACCOUNT_UPDATE (threatmetrix), _LOGIN_3 (biocatch), _PAYMENT_2 (biocatch)"
+	if err == nil || err.Error() != expected {
+		suite.T().Errorf("Expecting error %s got %v", expected, err)
+	}
+
+	invalid := [][]VendorActivityTypes{
+		{{ActivityTypes: []string{"_LOGIN_3"}}},
+		{{Vendor: "biocatch", ActivityTypes: []string{"LOGIN_3"}}},
+		{{Vendor: "biocatch", CheckType: "VOICE", ActivityTypes: []string{"_LOGIN_3"}}},
+	}
+	for i, vendors := range invalid {
+		if _, err := NewActivityTypeRegistry(vendors); err == nil {
+			suite.T().Errorf("invalid registry %d expecting error got none", i)
+		}
+	}
+}
+
+func (suite *UsdkServiceSuite) TestDeviceCheckVendorActivityType() {
+	registry, _ := NewActivityTypeRegistry([]VendorActivityTypes{
+		{Vendor: "biocatch", CheckType: models.DeviceCheckDetailsObjectCheckTypeDEVICE, ActivityTypes: []string{"_LOGIN_3"}},
+	})
+	var sessionKeyMap sync.Map
+	usdkService := NewUsdkService(&sessionKeyMap, WithActivityTypeRegistry(registry))
+
+	checkRequest := mockRequest()
+	checkRequest[0].ActivityType = "_LOGIN_3"
+	if _, err := usdkService.DeviceCheck(checkRequest); err != nil {
+		suite.T().Errorf("Device Check with registered activityType failure %s", err)
+	}
+
+	checkRequest = mockRequest()
+	checkRequest[0].ActivityType = "_LOGIN_4"
+	if _, err := usdkService.DeviceCheck(checkRequest); err == nil {
+		suite.T().Errorf("Device Check with unregistered activityType. Expecting failure got none")
+	}
+
+	// Without a registry any vendor activityType is accepted
+	checkRequest[0].CheckSessionKey += "0"
+	if _, err := createService().DeviceCheck(checkRequest); err != nil {
+		suite.T().Errorf("Device Check without registry failure %s", err)
+	}
+}
+
+func (suite *UsdkServiceSuite) TestKeyScope() {
+	batch := func(sessionKey1, sessionKey2 string) models.DeviceCheckDetailsObjectCollection {
+		ip := &models.KeyValuePairObject{KvpKey: "ip.address", KvpValue: "1.23.45.123", KvpType: models.EnumKVPTypeGeneralString}
+		return models.DeviceCheckDetailsObjectCollection{
+			{CheckType: "DEVICE", ActivityType: "LOGIN", CheckSessionKey: sessionKey1, ActivityData: []*models.KeyValuePairObject{ip}},
+			{CheckType: "DEVICE", ActivityType: "LOGIN", CheckSessionKey: sessionKey2, ActivityData: []*models.KeyValuePairObject{ip}},
+		}
+	}
+
+	tests := []struct {
+		scope KeyScope
+		batch models.DeviceCheckDetailsObjectCollection
+		err   string
+	}{
+		{KeyScopeElement, batch("e1", "e2"), ""},
+		{KeyScopeCollection, batch("c1", "c2"), "activity data validation KvpKey ip.address is not unique within the request"},
+		{KeyScopeSession, batch("s1", "s2"), ""},
+		{KeyScopeSession, batch("", ""), `activity data validation KvpKey ip.address is not unique within checkSessionKey ""`},
+	}
+	for _, test := range tests {
+		var sessionKeyMap sync.Map
+		_, err := NewUsdkService(&sessionKeyMap, WithKeyScope(test.scope)).DeviceCheck(test.batch)
+		if test.err == "" && err != nil {
+			suite.T().Errorf("%s scope expecting success got %s", test.scope, err)
+		}
+		if test.err != "" && (err == nil || err.Error() != test.err) {
+			suite.T().Errorf("%s scope expecting %s got %v", test.scope, test.err, err)
+		}
+	}
+
+	// A duplicate within a check is reported in every scope
+	checkRequest := mockRequest()
+	checkRequest[0].ActivityData = append(checkRequest[0].ActivityData, checkRequest[0].ActivityData[0])
+	_, err := createService().DeviceCheck(checkRequest)
+	if err == nil || !strings.Contains(err.Error(), "KvpKey ip.address is not unique within the check") {
+		suite.T().Errorf("Expecting duplicate key within the check got %v", err)
+	}
+
+	if scope, err := ParseKeyScope(""); err != nil || scope != DefaultKeyScope {
+		suite.T().Errorf("Expecting default scope %s got %s %v", DefaultKeyScope, scope, err)
+	}
+	if _, err := ParseKeyScope("journey"); err == nil {
+		suite.T().Errorf("Expecting unknown scope to fail got none")
+	}
+}
+
+func (suite *UsdkServiceSuite) TestJourney() {
+	journeys := NewJourneyStore(DefaultTransitions(), DefaultJourneyTTL)
+	var sessionKeyMap sync.Map
+	usdkService := NewUsdkService(&sessionKeyMap, WithJourneyStore(journeys))
+	journeyService := NewJourneyService(journeys)
+
+	check := func(journeyID string, activityTypes ...string) error {
+		checkRequest := make(models.DeviceCheckDetailsObjectCollection, 0)
+		for _, activityType := range activityTypes {
+			elem := mockRequest()[0]
+			elem.JourneyID = journeyID
+			elem.ActivityType = activityType
+			checkRequest = append(checkRequest, elem)
+		}
+		_, err := usdkService.DeviceCheck(checkRequest)
+		return err
+	}
+
+	// Checks of a journey in one request follow each other
+	if err := check("j1", "SIGNUP", "PAYMENT", "CONFIRMATION"); err != nil {
+		suite.T().Fatalf("Journey failure %s", err)
+	}
+
+	err := check("j1", "CONFIRMATION")
+	expected := "activityType CONFIRMATION is not allowed after CONFIRMATION in journeyId j1, it is allowed after PAYMENT"
+	if err == nil || err.Error() != expected {
+		suite.T().Errorf("Expecting error %s got %v", expected, err)
+	}
+
+	// A rejected request records none of its checks
+	if err := check("j2", "LOGIN", "CONFIRMATION"); err == nil {
+		suite.T().Errorf("Expecting CONFIRMATION after LOGIN to fail got none")
+	}
+	if _, ok := journeyService.LookupJourney("j2"); ok {
+		suite.T().Errorf("Expecting no history for a rejected journey")
+	}
+
+	// Vendor activityTypes are allowed anywhere
+	if err := check("j3", "_LOGIN_3"); err != nil {
+		suite.T().Errorf("Journey with vendor activityType failure %s", err)
+	}
+
+	journey, ok := journeyService.LookupJourney("j1")
+	if !ok || len(journey.Steps) != 3 || journey.Steps[1].ActivityType != "PAYMENT" {
+		suite.T().Errorf("Expecting SIGNUP, PAYMENT, CONFIRMATION history got %v", journey)
+	}
+}
+
+func (suite *UsdkServiceSuite) TestDeviceCheckVelocity() {
+	checker, err := velocity.NewChecker(velocity.NewMemoryStore(), []velocity.Rule{
+		{Name: "signups-per-ip", ActivityType: "SIGNUP", Keys: []string{"ip.address"}, Window: time.Hour, Limit: 1, Action: velocity.ActionReject},
+		{Name: "checks-per-ip", Keys: []string{"ip.address"}, Window: time.Hour, Limit: 0, Action: velocity.ActionFlag},
+	})
+	if err != nil {
+		suite.T().Fatalf("Velocity checker failure %s", err)
+	}
+	var sessionKeyMap sync.Map
+	usdkService := NewUsdkService(&sessionKeyMap, WithVelocity(checker))
+
+	// The first SIGNUP is only flagged
+	resp, err := usdkService.DeviceCheck(mockRequest())
+	if err != nil || !resp.Puppy || len(resp.Flags) != 1 || resp.Flags[0].Rule != "checks-per-ip" {
+		suite.T().Fatalf("Expecting puppy with checks-per-ip flag got %#v %v", resp, err)
+	}
+
+	// The second one from the same ip.address is rejected
+	checkRequest := mockRequest()
+	resp, err = usdkService.DeviceCheck(checkRequest)
+	if err != nil || resp.Puppy || len(resp.Flags) != 2 {
+		suite.T().Fatalf("Expecting no puppy with two flags got %#v %v", resp, err)
+	}
+	flag := resp.Flags[0]
+	if flag.Rule != "signups-per-ip" || flag.Action != velocity.ActionReject || flag.Count != 2 || flag.Limit != 1 ||
+		flag.Window != "1h0m0s" || flag.CheckSessionKey != checkRequest[0].CheckSessionKey {
+		suite.T().Errorf("Expecting signups-per-ip flag got %#v", flag)
+	}
+}
+
+func (suite *UsdkServiceSuite) TestDeviceCheckVelocityNormalised() {
+	checker, _ := velocity.NewChecker(velocity.NewMemoryStore(), []velocity.Rule{
+		{Name: "checks-per-account", Keys: []string{"account.id"}, Window: time.Hour, Limit: 1, Action: velocity.ActionReject},
+	})
+	var sessionKeyMap sync.Map
+	usdkService := NewUsdkService(&sessionKeyMap, WithVelocity(checker))
+
+	// "007" and "7" are the same account once normalised
+	for i, value := range []string{"007", "+7"} {
+		resp, err := usdkService.DeviceCheck(testutil.Collection(fixtures.Check().KVP("account.id", value, "general.integer")))
+		suite.Require().NoError(err)
+		suite.Equal(i == 0, resp.Puppy, "check of account.id %s", value)
+	}
+}
+
+func (suite *UsdkServiceSuite) TestDeviceCheckVelocityJourney() {
+	checker, _ := velocity.NewChecker(velocity.NewMemoryStore(), []velocity.Rule{
+		{Name: "checks-per-ip", Keys: []string{"ip.address"}, Window: time.Hour, Limit: 1, Action: velocity.ActionReject},
+	})
+	var sessionKeyMap sync.Map
+	usdkService := NewUsdkService(&sessionKeyMap, WithVelocity(checker))
+
+	// Checks rejected for their journey transition are not counted
+	for i := 0; i < 3; i++ {
+		request := mockRequest()
+		request[0].JourneyID = "journey-1"
+		request[0].ActivityType = models.DeviceCheckDetailsObjectActivityTypeCONFIRMATION
+		_, err := usdkService.DeviceCheck(request)
+		suite.Require().Error(err)
+	}
+
+	resp, err := usdkService.DeviceCheck(mockRequest())
+	suite.Require().NoError(err)
+	suite.True(resp.Puppy)
+	suite.Empty(resp.Flags)
+}
+
+func (suite *UsdkServiceSuite) TestDeviceCheckVelocityStoreFailure() {
+	rules := []velocity.Rule{
+		{Name: "open", Keys: []string{"ip.address"}, Window: time.Hour, Limit: 5, Action: velocity.ActionReject},
+		{Name: "closed", Keys: []string{"ip.address"}, Window: time.Hour, Limit: 5, Action: velocity.ActionFlag, OnError: velocity.OnErrorClosed},
+	}
+	check := func(rules ...velocity.Rule) *models.DeviceCheckResponseObject {
+		checker, err := velocity.NewChecker(failingStore{}, rules)
+		suite.Require().NoError(err)
+		var sessionKeyMap sync.Map
+		resp, err := NewUsdkService(&sessionKeyMap, WithVelocity(checker)).DeviceCheck(mockRequest())
+		suite.Require().NoError(err)
+		return resp
+	}
+
+	// A rule failing open passes the check, flagged as not enforced
+	resp := check(rules[0])
+	suite.True(resp.Puppy)
+	suite.Require().Len(resp.Flags, 1)
+	suite.Equal(models.VelocityFlagObject{Action: velocity.ActionFlag, CheckSessionKey: resp.Flags[0].CheckSessionKey, Limit: 5, Rule: "open", Window: "1h0m0s", NotEnforced: true}, *resp.Flags[0])
+
+	// A rule failing closed rejects it
+	resp = check(rules...)
+	suite.False(resp.Puppy)
+	suite.Require().Len(resp.Flags, 2)
+	suite.Equal(velocity.ActionReject, resp.Flags[1].Action)
+	suite.True(resp.Flags[1].NotEnforced)
+}
+
 func (suite *UsdkServiceSuite) TestDeviceCheck() {
 	mockRequest := mockRequest()
 	usdkService := createService()
 	resp, err := usdkService.DeviceCheck(mockRequest)
 
 	if err != nil {
-		suite.T().Errorf("Device Check failure %s", err.Error())
+		suite.T().Fatalf("Device Check failure %s", err.Error())
 	}
 
 	if resp.Puppy != true {
@@ -134,6 +708,51 @@ func (suite *UsdkServiceSuite) TestDeviceCheck() {
 
 }
 
+func (suite *UsdkServiceSuite) TestJourneyTTL() {
+	var sessionKeyMap sync.Map
+	clock := testutil.NewClock(testutil.Epoch)
+	journeys := NewJourneyStore(DefaultTransitions(), time.Hour, WithJourneyClock(clock.Now))
+	usdkService := NewUsdkService(&sessionKeyMap, WithJourneyStore(journeys), WithClock(clock.Now))
+	journeyService := NewJourneyService(journeys)
+
+	check := func(journeyID, activityType string) error {
+		request := mockRequest()
+		request[0].JourneyID = journeyID
+		request[0].ActivityType = activityType
+		_, err := usdkService.DeviceCheck(request)
+		return err
+	}
+	suite.Require().NoError(check("journey-1", "LOGIN"))
+	suite.Require().NoError(check("journey-2", "LOGIN"))
+
+	// A check within the TTL of the last one continues the journey
+	clock.Advance(50 * time.Minute)
+	suite.Require().NoError(check("journey-1", "PAYMENT"))
+
+	// journey-2 expired and is dropped by the next check, a payment can't start it again
+	clock.Advance(20 * time.Minute)
+	suite.Require().NoError(check("journey-3", "LOGIN"))
+	_, ok := journeyService.LookupJourney("journey-2")
+	suite.False(ok)
+	journey, ok := journeyService.LookupJourney("journey-1")
+	suite.Require().True(ok)
+	suite.Len(journey.Steps, 2)
+	suite.Error(check("journey-2", "PAYMENT"))
+}
+
+func (suite *UsdkServiceSuite) TestDeviceCheckNullElements() {
+	var sessionKeyMap sync.Map
+	usdkService := NewUsdkService(&sessionKeyMap, WithReplayTTL(time.Minute))
+
+	_, err := usdkService.DeviceCheck(models.DeviceCheckDetailsObjectCollection{nil})
+	suite.EqualError(err, "0 in body is required")
+
+	request := mockRequest()
+	request[0].ActivityData = append(request[0].ActivityData, nil)
+	_, err = usdkService.DeviceCheck(request)
+	suite.EqualError(err, "0.activityData.1 in body is required")
+}
+
 func (suite *UsdkServiceSuite) TestDeviceCheckWithSameSessionRequest() {
 	mockRequest := mockSameSessionKeyRequest()
 	usdkService := createService()
@@ -154,45 +773,222 @@ func (suite *UsdkServiceSuite) TestDeviceCheckWithInvalidActivityDataKeyType() {
 	}
 }
 
-func mockRequest() models.DeviceCheckDetailsObjectCollection {
-	rand := strconv.Itoa(util.GenerateRandomInRange(1000000, 20000000))
-	keyValuePairObject := &models.KeyValuePairObject{KvpKey: "ip.address", KvpValue: "1.23.45.123", KvpType: models.EnumKVPType("general.string")}
-	keyValArray := make([]*models.KeyValuePairObject, 0)
-	keyValArray = append(keyValArray, keyValuePairObject)
+func (suite *UsdkServiceSuite) TestDeviceCheckReplay() {
+	var sessionKeyMap sync.Map
+	usdkService := NewUsdkService(&sessionKeyMap, WithReplayTTL(time.Minute))
+
+	// Identical retry gets the original response
+	original := mockRequest()
+	resp, err := usdkService.DeviceCheck(original)
+	if err != nil {
+		suite.T().Fatalf("Device Check failure %s", err)
+	}
+	replayed, err := usdkService.DeviceCheck(original)
+	if err != nil {
+		suite.T().Errorf("Device Check replay failure %s", err)
+	}
+	if replayed == nil || replayed.Puppy != resp.Puppy {
+		suite.T().Errorf("Expecting the original response got %v", replayed)
+	}
 
-	deviceCheckDetail1 := &models.DeviceCheckDetailsObject{CheckType: "DEVICE", ActivityType: "SIGNUP", CheckSessionKey: rand, ActivityData: keyValArray}
-	mockDeviceCheckCollection := &models.DeviceCheckDetailsObjectCollection{deviceCheckDetail1}
-	return *mockDeviceCheckCollection
+	// Different payload reusing the key is a conflict
+	conflicting := mockRequest()
+	conflicting[0].CheckSessionKey = original[0].CheckSessionKey
+	conflicting[0].ActivityType = "LOGIN"
+	if _, err := usdkService.DeviceCheck(conflicting); err == nil {
+		suite.T().Errorf("Device Check with reused session key. Expecting failure got none")
+	}
+
+	// A failed request replays its error
+	failing := mockActivityKeyWithInvalidDataTypeRequest()
+	_, firstErr := usdkService.DeviceCheck(failing)
+	_, replayedErr := usdkService.DeviceCheck(failing)
+	if firstErr == nil || replayedErr == nil || firstErr.Error() != replayedErr.Error() {
+		suite.T().Errorf("Expecting the original error to be replayed got '%v' and '%v'", firstErr, replayedErr)
+	}
+
+	// Same key twice within one request is never a replay
+	sameKey := mockSameSessionKeyRequest()
+	_, err = usdkService.DeviceCheck(sameKey)
+	if err == nil {
+		suite.T().Errorf("Device Check With Same Session Key Request. Expecting Failure got none")
+	}
+	_, err = usdkService.DeviceCheck(sameKey)
+	if err == nil {
+		suite.T().Errorf("Device Check replay With Same Session Key Request. Expecting Failure got none")
+	}
 }
 
-func mockSameSessionKeyRequest() models.DeviceCheckDetailsObjectCollection {
-	rand := strconv.Itoa(util.GenerateRandomInRange(1000000, 20000000))
-	keyValuePairObject := &models.KeyValuePairObject{KvpKey: "ip.address", KvpValue: "1.23.45.123", KvpType: models.EnumKVPType("general.string")}
-	keyValArray := make([]*models.KeyValuePairObject, 0)
-	keyValArray = append(keyValArray, keyValuePairObject)
+func (suite *UsdkServiceSuite) TestDeviceCheckReplayExpired() {
+	var sessionKeyMap sync.Map
+	clock := testutil.NewClock(testutil.Epoch)
+	usdkService := NewUsdkService(&sessionKeyMap, WithReplayTTL(time.Minute), WithClock(clock.Now))
+
+	mockRequest := mockRequest()
+	if _, err := usdkService.DeviceCheck(mockRequest); err != nil {
+		suite.T().Fatalf("Device Check failure %s", err)
+	}
+	clock.Advance(time.Minute + time.Millisecond)
+	if _, err := usdkService.DeviceCheck(mockRequest); err == nil {
+		suite.T().Errorf("Device Check retry after TTL. Expecting failure got none")
+	}
+}
+
+func (suite *UsdkServiceSuite) TestDeviceCheckReplayInFlight() {
+	var sessionKeyMap sync.Map
+	clock := testutil.NewClock(testutil.Epoch)
+	usdkService := NewUsdkService(&sessionKeyMap, WithReplayTTL(time.Minute), WithClock(clock.Now))
+
+	// The original request reserved the key and is still running
+	request := mockRequest()
+	fingerprint := requestFingerprint(request)
+	done := make(chan struct{})
+	sessionKeyMap.Store(request[0].CheckSessionKey, &sessionKeyRecord{ReservedAt: clock.Now(), Fingerprint: fingerprint, Done: done})
+
+	replayed := make(chan error, 1)
+	go func() {
+		resp, err := usdkService.DeviceCheck(request)
+		if err == nil && !resp.Puppy {
+			err = fmt.Errorf("expecting the original response got %v", resp)
+		}
+		replayed <- err
+	}()
+
+	select {
+	case err := <-replayed:
+		suite.T().Fatalf("Retry returned before the original request completed: %v", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+	storeResponse(request, fingerprint, &sessionKeyMap, &models.DeviceCheckResponseObject{PuppyObject: models.PuppyObject{Puppy: true}}, nil)
+	close(done)
+	suite.NoError(<-replayed)
+
+	// A retry gives up waiting with its context
+	other := mockRequest()
+	sessionKeyMap.Store(other[0].CheckSessionKey, &sessionKeyRecord{ReservedAt: clock.Now(), Fingerprint: requestFingerprint(other), Done: make(chan struct{})})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := usdkService.DeviceCheckContext(ctx, other)
+	suite.ErrorIs(err, context.DeadlineExceeded)
+
+	// A request that never completed its records is not waited for
+	abandoned := mockRequest()
+	closed := make(chan struct{})
+	close(closed)
+	sessionKeyMap.Store(abandoned[0].CheckSessionKey, &sessionKeyRecord{ReservedAt: clock.Now(), Fingerprint: requestFingerprint(abandoned), Done: closed})
+	_, err = usdkService.DeviceCheck(abandoned)
+	suite.EqualError(err, "checkSessionKey should be unique")
+}
+
+func (suite *UsdkServiceSuite) TestDeviceCheckReplaySweep() {
+	var sessionKeyMap sync.Map
+	clock := testutil.NewClock(testutil.Epoch)
+	usdkService := NewUsdkService(&sessionKeyMap, WithReplayTTL(time.Minute), WithClock(clock.Now), WithEchoNormalised(true))
+
+	request := mockRequest()
+	_, err := usdkService.DeviceCheck(request)
+	suite.Require().NoError(err)
+	value, _ := sessionKeyMap.Load(request[0].CheckSessionKey)
+	suite.NotNil(value.(*sessionKeyRecord).Response)
+
+	// Within the TTL the stored response is kept
+	clock.Advance(30 * time.Second)
+	_, err = usdkService.DeviceCheck(mockRequest())
+	suite.Require().NoError(err)
+	value, _ = sessionKeyMap.Load(request[0].CheckSessionKey)
+	suite.NotNil(value.(*sessionKeyRecord).Response)
+
+	// The next check after the TTL forgets it, the key stays reserved
+	clock.Advance(time.Minute)
+	_, err = usdkService.DeviceCheck(mockRequest())
+	suite.Require().NoError(err)
+	value, _ = sessionKeyMap.Load(request[0].CheckSessionKey)
+	record := value.(*sessionKeyRecord)
+	suite.True(record.Completed)
+	suite.Nil(record.Response)
+	suite.Empty(record.Error)
+	_, err = usdkService.DeviceCheck(request)
+	suite.EqualError(err, "checkSessionKey should be unique")
+}
+
+func (suite *UsdkServiceSuite) TestSessionKeyService() {
+	var sessionKeyMap sync.Map
+	usdkService := NewUsdkService(&sessionKeyMap)
+	sessionKeyService := NewSessionKeyService(&sessionKeyMap)
+
+	before := time.Now()
+	for _, key := range []string{"abc-1", "abc-2", "xyz-1"} {
+		mockRequest := mockRequest()
+		mockRequest[0].CheckSessionKey = key
+		if _, err := usdkService.DeviceCheck(mockRequest); err != nil {
+			suite.T().Fatalf("Device Check failure %s", err)
+		}
+	}
+
+	sessionKeyObj, ok := sessionKeyService.LookupSessionKey("abc-2")
+	if !ok {
+		suite.T().Fatalf("Expecting session key abc-2 to be reserved")
+	}
+	if time.Time(sessionKeyObj.ReservedAt).Before(before) {
+		suite.T().Errorf("Expecting reservation time after %s got %s", before, sessionKeyObj.ReservedAt)
+	}
+
+	if _, ok := sessionKeyService.LookupSessionKey("unknown"); ok {
+		suite.T().Errorf("Expecting session key unknown not to be reserved")
+	}
+
+	if count := sessionKeyService.CountSessionKeys("abc-"); count != 2 {
+		suite.T().Errorf("Expecting 2 session keys with prefix abc- got %d", count)
+	}
+	if list := sessionKeyService.ListSessionKeys(""); len(list) != 3 || list[0].CheckSessionKey != "abc-1" {
+		suite.T().Errorf("Expecting 3 session keys oldest first got %v", list)
+	}
+
+	// A released session key can be used again
+	if !sessionKeyService.DeleteSessionKey("abc-1") {
+		suite.T().Errorf("Expecting session key abc-1 to be deleted")
+	}
+	if sessionKeyService.DeleteSessionKey("abc-1") {
+		suite.T().Errorf("Expecting second delete of abc-1 to report not found")
+	}
+	mockRequest := mockRequest()
+	mockRequest[0].CheckSessionKey = "abc-1"
+	if _, err := usdkService.DeviceCheck(mockRequest); err != nil {
+		suite.T().Errorf("Device Check with released session key failure %s", err)
+	}
+}
 
-	deviceCheckDetail1 := &models.DeviceCheckDetailsObject{CheckType: "DEVICE", ActivityType: "SIGNUP", CheckSessionKey: rand, ActivityData: keyValArray}
-	deviceCheckDetail2 := &models.DeviceCheckDetailsObject{CheckType: "DEVICE", ActivityType: "SIGNUP", CheckSessionKey: rand}
-	mockDeviceCheckCollection := &models.DeviceCheckDetailsObjectCollection{deviceCheckDetail1, deviceCheckDetail2}
-	return *mockDeviceCheckCollection
+func mockRequest() models.DeviceCheckDetailsObjectCollection {
+	return testutil.Collection(fixtures.Check().IP())
+}
+
+func mockSameSessionKeyRequest() models.DeviceCheckDetailsObjectCollection {
+	key := fixtures.SessionKey()
+	return testutil.Collection(testutil.NewCheck(key).IP(), testutil.NewCheck(key))
 }
 
 func mockActivityKeyWithInvalidDataTypeRequest() models.DeviceCheckDetailsObjectCollection {
-	rand := strconv.Itoa(util.GenerateRandomInRange(1000000, 20000000))
-	rand2 := strconv.Itoa(util.GenerateRandomInRange(3000000, 40000000))
+	key, key2 := fixtures.SessionKey(), fixtures.SessionKey()
+	kvps := func(b *testutil.CheckBuilder) *testutil.CheckBuilder {
+		return b.KVP("ip.address", testutil.MockIP, "general.bool").KVP("ip.address", "asdfh", "general.float")
+	}
+	return testutil.Collection(kvps(testutil.NewCheck(key)), kvps(testutil.NewCheck(key2)))
+}
 
-	keyValuePairObject := &models.KeyValuePairObject{KvpKey: "ip.address", KvpValue: "1.23.45.123", KvpType: models.EnumKVPType("general.bool")}
-	keyValuePairObject2 := &models.KeyValuePairObject{KvpKey: "ip.address", KvpValue: "asdfh", KvpType: models.EnumKVPType("general.float")}
-	keyValArray := make([]*models.KeyValuePairObject, 0)
-	keyValArray = append(keyValArray, keyValuePairObject)
-	keyValArray = append(keyValArray, keyValuePairObject2)
+// failingStore is a velocity store that is down
+type failingStore struct{}
 
-	deviceCheckDetail1 := &models.DeviceCheckDetailsObject{CheckType: "DEVICE", ActivityType: "SIGNUP", CheckSessionKey: rand, ActivityData: keyValArray}
-	deviceCheckDetail2 := &models.DeviceCheckDetailsObject{CheckType: "DEVICE", ActivityType: "SIGNUP", CheckSessionKey: rand2, ActivityData: keyValArray}
-	mockDeviceCheckCollection := &models.DeviceCheckDetailsObjectCollection{deviceCheckDetail1, deviceCheckDetail2}
-	return *mockDeviceCheckCollection
+func (failingStore) Add(context.Context, string, time.Time, time.Duration) (int64, error) {
+	return 0, fmt.Errorf("connection refused")
 }
 
+func (failingStore) Close() error {
+	return nil
+}
+
+// fixtures make the session keys of the mock requests, the same on every run
+var fixtures = testutil.New(1)
+
 func createService() UsdkService {
 	var sessionKeyMap sync.Map
 	usdkService := NewUsdkService(&sessionKeyMap)
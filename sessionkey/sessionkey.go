@@ -0,0 +1,72 @@
+// Package sessionkey generates checkSessionKeys as version 7 UUIDs: a
+// millisecond timestamp followed by 74 random bits, so keys are unique
+// without coordination and sort by the time they were created.
+package sessionkey
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Generator creates session keys with its clock and source of randomness.
+// The zero Generator uses time.Now and crypto/rand.
+type Generator struct {
+	Now  func() time.Time
+	Rand io.Reader
+}
+
+// New returns a random session key created now
+func New() (string, error) {
+	return Generator{}.New()
+}
+
+// New returns a session key of the current time of the generator
+func (g Generator) New() (string, error) {
+	now, random := time.Now, rand.Reader
+	if g.Now != nil {
+		now = g.Now
+	}
+	if g.Rand != nil {
+		random = g.Rand
+	}
+
+	var uuid [16]byte
+	ms := uint64(now().UnixMilli())
+	binary.BigEndian.PutUint64(uuid[0:8], ms<<16)
+	if _, err := io.ReadFull(random, uuid[6:]); err != nil {
+		return "", fmt.Errorf("session key: %v", err)
+	}
+	uuid[6] = 0x70 | uuid[6]&0x0f // version 7
+	uuid[8] = 0x80 | uuid[8]&0x3f // RFC 9562 variant
+
+	var buf [36]byte
+	hex.Encode(buf[0:8], uuid[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], uuid[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], uuid[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], uuid[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], uuid[10:])
+	return string(buf[:]), nil
+}
+
+// Time returns the creation time of a session key made by New, false for
+// any other string
+func Time(key string) (time.Time, bool) {
+	if len(key) != 36 || key[8] != '-' || key[13] != '-' || key[18] != '-' || key[23] != '-' || key[14] != '7' {
+		return time.Time{}, false
+	}
+	var uuid [16]byte
+	src := key[0:8] + key[9:13] + key[14:18] + key[19:23] + key[24:]
+	if _, err := hex.Decode(uuid[:], []byte(src)); err != nil || uuid[8]&0xc0 != 0x80 {
+		return time.Time{}, false
+	}
+	ms := binary.BigEndian.Uint64(uuid[0:8]) >> 16
+	return time.UnixMilli(int64(ms)).UTC(), true
+}
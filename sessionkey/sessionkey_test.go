@@ -0,0 +1,70 @@
+package sessionkey
+
+import (
+	"bytes"
+	"regexp"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+var uuidV7 = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+type SessionKeySuite struct {
+	suite.Suite
+}
+
+func TestSessionKeySuite(t *testing.T) {
+	suite.Run(t, new(SessionKeySuite))
+}
+
+func (suite *SessionKeySuite) TestNew() {
+	seen := make(map[string]bool)
+	for i := 0; i < 1000; i++ {
+		key, err := New()
+		suite.Require().NoError(err)
+		suite.Regexp(uuidV7, key)
+		suite.False(seen[key], "duplicate key %s", key)
+		seen[key] = true
+	}
+}
+
+func (suite *SessionKeySuite) TestDeterministic() {
+	at := time.Date(2020, 1, 2, 3, 4, 5, 678000000, time.UTC)
+	g := Generator{Now: func() time.Time { return at }, Rand: bytes.NewReader(make([]byte, 10))}
+
+	key, err := g.New()
+	suite.Require().NoError(err)
+	suite.Equal("016f6435-cf2e-7000-8000-000000000000", key)
+
+	created, ok := Time(key)
+	suite.True(ok)
+	suite.Equal(at, created)
+
+	// The source of randomness is exhausted
+	_, err = g.New()
+	suite.Error(err)
+}
+
+func (suite *SessionKeySuite) TestOrder() {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	g := Generator{Now: func() time.Time { return now }}
+
+	var keys []string
+	for i := 0; i < 100; i++ {
+		key, _ := g.New()
+		keys = append(keys, key)
+		now = now.Add(time.Millisecond)
+	}
+	suite.True(sort.StringsAreSorted(keys))
+}
+
+func (suite *SessionKeySuite) TestTime() {
+	for _, key := range []string{"", "not a key", "0123456789abcdef0123456789abcdef",
+		"016f64c2-0a26-4000-8000-000000000000", "016f64c2-0a26-7000-0000-000000000000", "016f64c2-0a26-7000-8000-00000000000g"} {
+		_, ok := Time(key)
+		suite.False(ok, key)
+	}
+}
@@ -0,0 +1,37 @@
+package signing
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"fmt"
+	"universalsdk/config"
+)
+
+// NewSignerFromConfig creates a Signer with the configured keys, or returns
+// nil when signing is not configured
+func NewSignerFromConfig(cfg config.SigningConfig, opts ...Option) (*Signer, error) {
+	if cfg.Current == "" {
+		return nil, nil
+	}
+
+	keys := make([]Key, 0, len(cfg.Keys))
+	for _, c := range cfg.Keys {
+		key := Key{ID: c.ID}
+		if c.PrivateKey != "" {
+			seed, err := base64.StdEncoding.DecodeString(c.PrivateKey)
+			if err != nil || len(seed) != ed25519.SeedSize {
+				return nil, fmt.Errorf("signing key %s: private key should be %d base64 encoded bytes", c.ID, ed25519.SeedSize)
+			}
+			key.PrivateKey = ed25519.NewKeyFromSeed(seed)
+		}
+		if c.PublicKey != "" {
+			public, err := base64.StdEncoding.DecodeString(c.PublicKey)
+			if err != nil || len(public) != ed25519.PublicKeySize {
+				return nil, fmt.Errorf("signing key %s: public key should be %d base64 encoded bytes", c.ID, ed25519.PublicKeySize)
+			}
+			key.PublicKey = public
+		}
+		keys = append(keys, key)
+	}
+	return NewSigner(cfg.Current, keys, opts...)
+}
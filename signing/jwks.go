@@ -0,0 +1,60 @@
+package signing
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+)
+
+// JWK is the JSON Web Key (RFC 8037) of an Ed25519 public key
+type JWK struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	Kid string `json:"kid"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	X   string `json:"x"`
+}
+
+// JWKS is a JSON Web Key Set, as served at /.well-known/jwks.json
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// NewJWK returns the JWK of the public key of key
+func NewJWK(key Key) JWK {
+	return JWK{
+		Kty: "OKP",
+		Crv: "Ed25519",
+		Kid: key.ID,
+		Use: "sig",
+		Alg: Algorithm,
+		X:   base64.RawURLEncoding.EncodeToString(key.PublicKey),
+	}
+}
+
+// ParseJWKS reads the Ed25519 public keys of a JWKS, other keys are skipped
+func ParseJWKS(data []byte) ([]Key, error) {
+	var jwks JWKS
+	if err := json.Unmarshal(data, &jwks); err != nil {
+		return nil, fmt.Errorf("invalid JWKS: %v", err)
+	}
+	return jwks.PublicKeys()
+}
+
+// PublicKeys returns the Ed25519 public keys of the set, other keys are skipped
+func (j *JWKS) PublicKeys() ([]Key, error) {
+	keys := make([]Key, 0, len(j.Keys))
+	for _, jwk := range j.Keys {
+		if jwk.Kty != "OKP" || jwk.Crv != "Ed25519" || (jwk.Use != "" && jwk.Use != "sig") {
+			continue
+		}
+		public, err := base64.RawURLEncoding.DecodeString(jwk.X)
+		if err != nil || len(public) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid JWKS: key %s should be a base64url encoded Ed25519 public key", jwk.Kid)
+		}
+		keys = append(keys, Key{ID: jwk.Kid, PublicKey: public})
+	}
+	return keys, nil
+}
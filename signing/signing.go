@@ -0,0 +1,230 @@
+// Package signing signs device check responses, so a backend handed a
+// verdict by client-side code can tell it was given by the server.
+//
+// A response is signed with a detached JWS (RFC 7515, appendix F) using
+// Ed25519 (RFC 8037): the payload is the response body as encoded, before
+// any compression, and the protected header holds the session keys of the
+// checks, the verdict and the time it was signed:
+//
+//	{"alg":"EdDSA","kid":"s1","iat":1760000000,"sessionKeys":["..."],"puppy":true}
+//
+// The signature is sent in the X-Usdk-Signature header as
+// BASE64URL(header) + ".." + BASE64URL(signature). The public keys are
+// published as a JWKS, from which a Verifier checks signatures.
+package signing
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Header is the response header carrying the signature
+const Header = "X-Usdk-Signature"
+
+// Trailer is the gRPC trailer carrying the signature of a gRPC response
+const Trailer = "x-usdk-signature"
+
+// Algorithm is the JWS algorithm of the signatures
+const Algorithm = "EdDSA"
+
+var (
+	// ErrInvalidSignature is returned for malformed signatures and ones not matching the body
+	ErrInvalidSignature = errors.New("invalid response signature")
+
+	// ErrUnknownKey is returned for signatures of a key that is not published
+	ErrUnknownKey = errors.New("unknown signing key")
+
+	// ErrExpired is returned for signatures older than the max age of the Verifier
+	ErrExpired = errors.New("response signature expired")
+)
+
+// Key is an Ed25519 signing key. Keys without a PrivateKey are published
+// and verified but can't sign.
+type Key struct {
+	ID         string
+	PrivateKey ed25519.PrivateKey
+	PublicKey  ed25519.PublicKey
+}
+
+// GenerateKey creates a new random key
+func GenerateKey(id string) (Key, error) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return Key{}, err
+	}
+	return Key{ID: id, PrivateKey: private, PublicKey: public}, nil
+}
+
+// Claims are signed along with the response body
+type Claims struct {
+	// Session keys of the checks of the request, in request order
+	SessionKeys []string
+
+	// Verdict of the response
+	Puppy bool
+
+	// When the response was signed, to the second
+	IssuedAt time.Time
+
+	// Key the response was signed with, set by Verify
+	KeyID string
+}
+
+// HasSessionKey reports whether sessionKey is one of the checks of the response
+func (c *Claims) HasSessionKey(sessionKey string) bool {
+	for _, key := range c.SessionKeys {
+		if key == sessionKey {
+			return true
+		}
+	}
+	return false
+}
+
+// protectedHeader is the JWS protected header holding the claims
+type protectedHeader struct {
+	Alg         string   `json:"alg"`
+	Kid         string   `json:"kid"`
+	IssuedAt    int64    `json:"iat"`
+	SessionKeys []string `json:"sessionKeys"`
+	Puppy       bool     `json:"puppy"`
+}
+
+// Option configures a Signer or Verifier
+type Option func(*options)
+
+type options struct {
+	now        func() time.Time
+	maxAge     time.Duration
+	httpClient *http.Client
+}
+
+// WithClock replaces time.Now, the time responses are signed at and
+// signatures are verified at
+func WithClock(now func() time.Time) Option {
+	return func(o *options) {
+		o.now = now
+	}
+}
+
+// WithMaxAge sets how long a signature is accepted by a Verifier, 5m by
+// default and without limit when 0
+func WithMaxAge(maxAge time.Duration) Option {
+	return func(o *options) {
+		o.maxAge = maxAge
+	}
+}
+
+// WithHTTPClient sets the http.Client a Verifier fetches the JWKS with
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(o *options) {
+		o.httpClient = httpClient
+	}
+}
+
+func newOptions(opts []Option) options {
+	o := options{now: time.Now, maxAge: 5 * time.Minute, httpClient: &http.Client{Timeout: 10 * time.Second}}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// Signer signs responses with the current key and publishes every key
+type Signer struct {
+	current Key
+	keys    []Key
+	now     func() time.Time
+}
+
+// NewSigner signs with the key with id current. The other keys are only
+// published: the previous key until the responses it signed expire, or the
+// next key ahead of a rotation.
+func NewSigner(current string, keys []Key, opts ...Option) (*Signer, error) {
+	s := &Signer{now: newOptions(opts).now}
+
+	ids := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		if key.ID == "" || ids[key.ID] {
+			return nil, fmt.Errorf("signing key id %q should be non empty and unique", key.ID)
+		}
+		ids[key.ID] = true
+
+		if key.PrivateKey != nil {
+			if len(key.PrivateKey) != ed25519.PrivateKeySize {
+				return nil, fmt.Errorf("signing key %s: private key should be %d bytes", key.ID, ed25519.PrivateKeySize)
+			}
+			public := key.PrivateKey.Public().(ed25519.PublicKey)
+			if key.PublicKey != nil && !public.Equal(key.PublicKey) {
+				return nil, fmt.Errorf("signing key %s: public key does not match the private key", key.ID)
+			}
+			key.PublicKey = public
+		}
+		if len(key.PublicKey) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("signing key %s: public key should be %d bytes", key.ID, ed25519.PublicKeySize)
+		}
+
+		if key.ID == current {
+			if key.PrivateKey == nil {
+				return nil, fmt.Errorf("signing key %s: the current key needs a private key", key.ID)
+			}
+			s.current = key
+		}
+		s.keys = append(s.keys, Key{ID: key.ID, PublicKey: key.PublicKey})
+	}
+	if s.current.ID == "" {
+		return nil, fmt.Errorf("current signing key %q is missing", current)
+	}
+	return s, nil
+}
+
+// KeyID returns the id of the key responses are signed with
+func (s *Signer) KeyID() string {
+	return s.current.ID
+}
+
+// Sign returns the detached JWS of body and the claims, signed now
+func (s *Signer) Sign(body []byte, claims Claims) (string, error) {
+	header, err := json.Marshal(protectedHeader{
+		Alg:         Algorithm,
+		Kid:         s.current.ID,
+		IssuedAt:    s.now().Unix(),
+		SessionKeys: claims.SessionKeys,
+		Puppy:       claims.Puppy,
+	})
+	if err != nil {
+		return "", err
+	}
+	return signDetached(s.current.PrivateKey, header, body), nil
+}
+
+// JWKS returns the public keys, current one first
+func (s *Signer) JWKS() *JWKS {
+	jwks := &JWKS{Keys: make([]JWK, 0, len(s.keys))}
+	jwks.Keys = append(jwks.Keys, NewJWK(s.current))
+	for _, key := range s.keys {
+		if key.ID != s.current.ID {
+			jwks.Keys = append(jwks.Keys, NewJWK(key))
+		}
+	}
+	return jwks
+}
+
+// signDetached signs header and payload, leaving the payload out of the JWS
+func signDetached(privateKey ed25519.PrivateKey, header, payload []byte) string {
+	protected := base64.RawURLEncoding.EncodeToString(header)
+	signature := ed25519.Sign(privateKey, signingInput(protected, payload))
+	return protected + ".." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func signingInput(protected string, payload []byte) []byte {
+	input := make([]byte, 0, len(protected)+1+base64.RawURLEncoding.EncodedLen(len(payload)))
+	input = append(input, protected...)
+	input = append(input, '.')
+	return base64.RawURLEncoding.AppendEncode(input, payload)
+}
@@ -0,0 +1,247 @@
+package signing
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+	"universalsdk/config"
+	"universalsdk/testutil"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type SigningSuite struct {
+	suite.Suite
+	clock  *testutil.Clock
+	key    Key
+	signer *Signer
+}
+
+func TestSigningSuite(t *testing.T) {
+	suite.Run(t, new(SigningSuite))
+}
+
+func (suite *SigningSuite) SetupTest() {
+	var err error
+	suite.clock = testutil.NewClock(testutil.Epoch)
+	suite.key, err = GenerateKey("s1")
+	suite.Require().NoError(err)
+	suite.signer, err = NewSigner("s1", []Key{suite.key}, WithClock(suite.clock.Now))
+	suite.Require().NoError(err)
+}
+
+// TestRFC8037 signs the Ed25519 example of RFC 8037 appendix A.4, detached
+func (suite *SigningSuite) TestRFC8037() {
+	seed, _ := base64.RawURLEncoding.DecodeString("nWGxne_9WmC6hEr0kuwsxERJxWl7MmkZcDusAxyuf2A")
+	signature := signDetached(ed25519.NewKeyFromSeed(seed), []byte(`{"alg":"EdDSA"}`), []byte("Example of Ed25519 signing"))
+	suite.Equal("eyJhbGciOiJFZERTQSJ9..hgyY0il_MGCjP0JzlnLWG1PPOt7-09PGcvMg3AIbQR6dWbhijcNR4ki4iylGjg5BhVsPt9g7sVvpAr_MuM0KAg", signature)
+	suite.Equal("11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo", NewJWK(Key{PublicKey: ed25519.NewKeyFromSeed(seed).Public().(ed25519.PublicKey)}).X)
+}
+
+func (suite *SigningSuite) TestRoundTrip() {
+	body := []byte(`{"puppy":true}`)
+	signature, err := suite.signer.Sign(body, Claims{SessionKeys: []string{"a", "b"}, Puppy: true})
+	suite.Require().NoError(err)
+	suite.Equal(2, strings.Count(signature, "."))
+	suite.Contains(signature, "..")
+
+	verifier := NewVerifier(suite.signer.JWKS().mustKeys(), WithClock(suite.clock.Now))
+	claims, err := verifier.Verify(context.Background(), body, signature)
+	suite.Require().NoError(err)
+	suite.Equal(&Claims{SessionKeys: []string{"a", "b"}, Puppy: true, IssuedAt: testutil.Epoch, KeyID: "s1"}, claims)
+	suite.True(claims.HasSessionKey("b"))
+	suite.False(claims.HasSessionKey("c"))
+}
+
+func (suite *SigningSuite) TestTampering() {
+	body := []byte(`{"puppy":false}`)
+	signature, err := suite.signer.Sign(body, Claims{SessionKeys: []string{"a"}})
+	suite.Require().NoError(err)
+	verifier := NewVerifier([]Key{suite.key}, WithClock(suite.clock.Now))
+	verify := func(body []byte, signature string) error {
+		_, err := verifier.Verify(context.Background(), body, signature)
+		return err
+	}
+
+	suite.ErrorIs(verify([]byte(`{"puppy":true}`), signature), ErrInvalidSignature)
+
+	// The verdict of the header can't be changed either
+	parts := strings.Split(signature, ".")
+	forged := strings.Replace(string(mustDecode(parts[0])), `"puppy":false`, `"puppy":true`, 1)
+	suite.ErrorIs(verify(body, base64.RawURLEncoding.EncodeToString([]byte(forged))+".."+parts[2]), ErrInvalidSignature)
+
+	none := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none","kid":"s1","puppy":true}`))
+	suite.ErrorIs(verify(body, none+".."), ErrInvalidSignature)
+
+	attached := parts[0] + "." + base64.RawURLEncoding.EncodeToString(body) + "." + parts[2]
+	suite.ErrorIs(verify(body, attached), ErrInvalidSignature)
+
+	for _, malformed := range []string{"", "..", "a.b", parts[0] + ".." + parts[2] + "!"} {
+		suite.ErrorIs(verify(body, malformed), ErrInvalidSignature, malformed)
+	}
+
+	// Signed by another key with the same id
+	other, _ := GenerateKey("s1")
+	otherSigner, _ := NewSigner("s1", []Key{other}, WithClock(suite.clock.Now))
+	signature, _ = otherSigner.Sign(body, Claims{})
+	suite.ErrorIs(verify(body, signature), ErrInvalidSignature)
+}
+
+func (suite *SigningSuite) TestMaxAge() {
+	body := []byte(`{"puppy":true}`)
+	signature, _ := suite.signer.Sign(body, Claims{Puppy: true})
+	verifier := NewVerifier([]Key{suite.key}, WithClock(suite.clock.Now), WithMaxAge(time.Minute))
+
+	suite.clock.Advance(time.Minute)
+	_, err := verifier.Verify(context.Background(), body, signature)
+	suite.NoError(err)
+
+	suite.clock.Advance(time.Second)
+	_, err = verifier.Verify(context.Background(), body, signature)
+	suite.ErrorIs(err, ErrExpired)
+
+	_, err = NewVerifier([]Key{suite.key}, WithClock(suite.clock.Now), WithMaxAge(0)).Verify(context.Background(), body, signature)
+	suite.NoError(err)
+
+	// Signed by a server with its clock ahead
+	suite.clock.Set(testutil.Epoch.Add(-2 * time.Minute))
+	_, err = verifier.Verify(context.Background(), body, signature)
+	suite.ErrorIs(err, ErrInvalidSignature)
+}
+
+func (suite *SigningSuite) TestRotation() {
+	next, _ := GenerateKey("s2")
+	var published atomic.Value
+	published.Store([]Key{suite.key})
+	var fetches int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&fetches, 1)
+		keys := published.Load().([]Key)
+		signer, _ := NewSigner(keys[0].ID, keys)
+		json.NewEncoder(w).Encode(signer.JWKS())
+	}))
+	defer server.Close()
+
+	verifier := NewJWKSVerifier(server.URL, WithClock(suite.clock.Now))
+	body := []byte(`{"puppy":true}`)
+	signature, _ := suite.signer.Sign(body, Claims{Puppy: true})
+	for i := 0; i < 3; i++ {
+		_, err := verifier.Verify(context.Background(), body, signature)
+		suite.Require().NoError(err)
+	}
+	suite.EqualValues(1, atomic.LoadInt32(&fetches))
+
+	// The next key signs before the verifier fetched the JWKS again
+	published.Store([]Key{next, {ID: "s1", PublicKey: suite.key.PublicKey}})
+	nextSigner, err := NewSigner("s2", published.Load().([]Key), WithClock(suite.clock.Now))
+	suite.Require().NoError(err)
+	nextSignature, _ := nextSigner.Sign(body, Claims{Puppy: true})
+	suite.clock.Advance(10 * time.Second)
+	_, err = verifier.Verify(context.Background(), body, nextSignature)
+	suite.ErrorIs(err, ErrUnknownKey)
+	suite.EqualValues(1, atomic.LoadInt32(&fetches))
+
+	suite.clock.Advance(refreshInterval)
+	claims, err := verifier.Verify(context.Background(), body, nextSignature)
+	suite.Require().NoError(err)
+	suite.Equal("s2", claims.KeyID)
+	suite.EqualValues(2, atomic.LoadInt32(&fetches))
+
+	// The previous key still verifies until it is no longer published
+	_, err = verifier.Verify(context.Background(), body, signature)
+	suite.NoError(err)
+
+	_, err = NewJWKSVerifier(server.URL+"/missing", WithHTTPClient(&http.Client{Transport: failingTransport{}})).Verify(context.Background(), body, signature)
+	suite.Error(err)
+	suite.False(errors.Is(err, ErrInvalidSignature))
+}
+
+func (suite *SigningSuite) TestJWKS() {
+	retired, _ := GenerateKey("s0")
+	signer, err := NewSigner("s1", []Key{{ID: "s0", PublicKey: retired.PublicKey}, suite.key})
+	suite.Require().NoError(err)
+
+	data, _ := json.Marshal(signer.JWKS())
+	suite.NotContains(string(data), `"d"`)
+	keys, err := ParseJWKS(data)
+	suite.Require().NoError(err)
+	suite.Equal([]Key{{ID: "s1", PublicKey: suite.key.PublicKey}, {ID: "s0", PublicKey: retired.PublicKey}}, keys)
+
+	// Keys of other types are skipped
+	keys, err = ParseJWKS([]byte(`{"keys":[{"kty":"RSA","kid":"r1","n":"AQAB"},{"kty":"OKP","crv":"X25519","kid":"x1","x":"AA"}]}`))
+	suite.NoError(err)
+	suite.Empty(keys)
+
+	_, err = ParseJWKS([]byte(`{"keys":[{"kty":"OKP","crv":"Ed25519","kid":"s1","x":"AA"}]}`))
+	suite.Error(err)
+}
+
+func (suite *SigningSuite) TestNewSigner() {
+	retired, _ := GenerateKey("s0")
+	tests := []struct {
+		current string
+		keys    []Key
+		err     string
+	}{
+		{"s2", []Key{suite.key}, `current signing key "s2" is missing`},
+		{"s0", []Key{{ID: "s0", PublicKey: retired.PublicKey}}, "needs a private key"},
+		{"s1", []Key{suite.key, suite.key}, "non empty and unique"},
+		{"s1", []Key{suite.key, {ID: ""}}, "non empty and unique"},
+		{"s1", []Key{{ID: "s1", PrivateKey: suite.key.PrivateKey, PublicKey: retired.PublicKey}}, "does not match"},
+		{"s1", []Key{suite.key, {ID: "s0"}}, "public key should be 32 bytes"},
+	}
+	for _, test := range tests {
+		_, err := NewSigner(test.current, test.keys)
+		suite.ErrorContains(err, test.err)
+	}
+}
+
+func (suite *SigningSuite) TestNewSignerFromConfig() {
+	signer, err := NewSignerFromConfig(config.SigningConfig{})
+	suite.NoError(err)
+	suite.Nil(signer)
+
+	seed := base64.StdEncoding.EncodeToString(suite.key.PrivateKey.Seed())
+	public := base64.StdEncoding.EncodeToString(suite.key.PublicKey)
+	retired, _ := GenerateKey("s0")
+	signer, err = NewSignerFromConfig(config.SigningConfig{Current: "s1", Keys: []config.SigningKeyConfig{
+		{ID: "s0", PublicKey: base64.StdEncoding.EncodeToString(retired.PublicKey)},
+		{ID: "s1", PrivateKey: seed, PublicKey: public},
+	}})
+	suite.Require().NoError(err)
+	suite.Equal("s1", signer.KeyID())
+	suite.Len(signer.JWKS().Keys, 2)
+
+	_, err = NewSignerFromConfig(config.SigningConfig{Current: "s1", Keys: []config.SigningKeyConfig{{ID: "s1", PrivateKey: public[:20]}}})
+	suite.ErrorContains(err, "private key should be 32 base64 encoded bytes")
+}
+
+func (j *JWKS) mustKeys() []Key {
+	keys, err := j.PublicKeys()
+	if err != nil {
+		panic(err)
+	}
+	return keys
+}
+
+func mustDecode(s string) []byte {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
+
+type failingTransport struct{}
+
+func (failingTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	return nil, errors.New("connection refused")
+}
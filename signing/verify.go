@@ -0,0 +1,168 @@
+package signing
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// clockSkew is how far in the future a signature may have been signed
+const clockSkew = time.Minute
+
+// refreshInterval is how often a Verifier fetches the JWKS again at most,
+// so signatures of unknown keys can't flood the server with requests
+const refreshInterval = time.Minute
+
+// Verifier checks the signatures of responses, for the backends verdicts
+// are handed to:
+//
+//	verifier := signing.NewJWKSVerifier("https://usdk.example.com/.well-known/jwks.json")
+//	claims, err := verifier.Verify(ctx, body, signature)
+//	if err != nil || !claims.Puppy || !claims.HasSessionKey(sessionKey) {
+//		// the verdict can't be trusted
+//	}
+type Verifier struct {
+	options
+
+	mu      sync.RWMutex
+	keys    map[string]ed25519.PublicKey
+	jwksURL string
+	fetched time.Time
+}
+
+// NewVerifier verifies signatures of the given public keys
+func NewVerifier(keys []Key, opts ...Option) *Verifier {
+	v := &Verifier{options: newOptions(opts)}
+	v.setKeys(keys)
+	return v
+}
+
+// NewJWKSVerifier verifies signatures of the keys published at jwksURL.
+// The keys are fetched on first use and again for a key the Verifier
+// doesn't know yet, picking up rotated keys.
+func NewJWKSVerifier(jwksURL string, opts ...Option) *Verifier {
+	return &Verifier{options: newOptions(opts), jwksURL: jwksURL}
+}
+
+// Verify checks that signature signs body and returns the signed claims.
+// Signatures older than the max age are rejected with ErrExpired.
+func (v *Verifier) Verify(ctx context.Context, body []byte, signature string) (*Claims, error) {
+	parts := strings.Split(signature, ".")
+	if len(parts) != 3 || parts[1] != "" {
+		return nil, fmt.Errorf("%w: should be a detached JWS", ErrInvalidSignature)
+	}
+	data, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+	var header protectedHeader
+	if err := json.Unmarshal(data, &header); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+	if header.Alg != Algorithm {
+		return nil, fmt.Errorf("%w: algorithm %q, expected %s", ErrInvalidSignature, header.Alg, Algorithm)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+
+	public, err := v.key(ctx, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	if !ed25519.Verify(public, signingInput(parts[0], body), sig) {
+		return nil, ErrInvalidSignature
+	}
+
+	issuedAt := time.Unix(header.IssuedAt, 0).UTC()
+	now := v.now()
+	if issuedAt.After(now.Add(clockSkew)) {
+		return nil, fmt.Errorf("%w: signed in the future at %s", ErrInvalidSignature, issuedAt.Format(time.RFC3339))
+	}
+	if v.maxAge > 0 && now.Sub(issuedAt) > v.maxAge {
+		return nil, fmt.Errorf("%w: signed at %s", ErrExpired, issuedAt.Format(time.RFC3339))
+	}
+
+	return &Claims{SessionKeys: header.SessionKeys, Puppy: header.Puppy, IssuedAt: issuedAt, KeyID: header.Kid}, nil
+}
+
+// key returns the public key with id, fetching the JWKS when the key isn't known
+func (v *Verifier) key(ctx context.Context, id string) (ed25519.PublicKey, error) {
+	v.mu.RLock()
+	public, ok := v.keys[id]
+	v.mu.RUnlock()
+	if ok {
+		return public, nil
+	}
+	if v.jwksURL == "" {
+		return nil, fmt.Errorf("%w %s", ErrUnknownKey, id)
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	// another call may have fetched the keys meanwhile
+	if public, ok := v.keys[id]; ok {
+		return public, nil
+	}
+	if !v.fetched.IsZero() && v.now().Sub(v.fetched) < refreshInterval {
+		return nil, fmt.Errorf("%w %s", ErrUnknownKey, id)
+	}
+	if err := v.fetch(ctx); err != nil {
+		return nil, err
+	}
+	if public, ok := v.keys[id]; ok {
+		return public, nil
+	}
+	return nil, fmt.Errorf("%w %s", ErrUnknownKey, id)
+}
+
+// fetch replaces the keys with the ones published at the JWKS URL, called with mu held
+func (v *Verifier) fetch(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", v.jwksURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := v.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("fetching JWKS: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("fetching JWKS: HTTP %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("fetching JWKS: %v", err)
+	}
+	keys, err := ParseJWKS(data)
+	if err != nil {
+		return err
+	}
+
+	v.fetched = v.now()
+	v.keys = make(map[string]ed25519.PublicKey, len(keys))
+	for _, key := range keys {
+		v.keys[key.ID] = key.PublicKey
+	}
+	return nil
+}
+
+func (v *Verifier) setKeys(keys []Key) {
+	v.keys = make(map[string]ed25519.PublicKey, len(keys))
+	for _, key := range keys {
+		public := key.PublicKey
+		if public == nil && key.PrivateKey != nil {
+			public = key.PrivateKey.Public().(ed25519.PublicKey)
+		}
+		v.keys[key.ID] = public
+	}
+}
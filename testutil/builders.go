@@ -0,0 +1,128 @@
+package testutil
+
+import (
+	"fmt"
+	"strconv"
+
+	"universalsdk/models"
+)
+
+// MockIP is the ip.address of the checks built by IP
+const MockIP = "1.23.45.123"
+
+// CheckBuilder builds a DeviceCheckDetailsObject, each method returns the
+// builder so calls can be chained
+type CheckBuilder struct {
+	check models.DeviceCheckDetailsObject
+}
+
+// NewCheck returns a builder of a DEVICE SIGNUP check without activity data
+func NewCheck(sessionKey string) *CheckBuilder {
+	return &CheckBuilder{check: models.DeviceCheckDetailsObject{CheckType: "DEVICE", ActivityType: "SIGNUP", CheckSessionKey: sessionKey}}
+}
+
+// SessionKey sets the checkSessionKey
+func (b *CheckBuilder) SessionKey(sessionKey string) *CheckBuilder {
+	b.check.CheckSessionKey = sessionKey
+	return b
+}
+
+// CheckType sets the checkType
+func (b *CheckBuilder) CheckType(checkType string) *CheckBuilder {
+	b.check.CheckType = checkType
+	return b
+}
+
+// ActivityType sets the activityType
+func (b *CheckBuilder) ActivityType(activityType string) *CheckBuilder {
+	b.check.ActivityType = activityType
+	return b
+}
+
+// JourneyID sets the journeyId
+func (b *CheckBuilder) JourneyID(journeyID string) *CheckBuilder {
+	b.check.JourneyID = journeyID
+	return b
+}
+
+// KVP appends a key value pair to the activity data
+func (b *CheckBuilder) KVP(key, value string, kvpType models.EnumKVPType) *CheckBuilder {
+	b.check.ActivityData = append(b.check.ActivityData, KVP(key, value, kvpType))
+	return b
+}
+
+// String appends a general.string key value pair to the activity data
+func (b *CheckBuilder) String(key, value string) *CheckBuilder {
+	return b.KVP(key, value, models.EnumKVPTypeGeneralString)
+}
+
+// IP appends the ip.address the mock requests come from
+func (b *CheckBuilder) IP() *CheckBuilder {
+	return b.String("ip.address", MockIP)
+}
+
+// Build returns a copy of the check, later calls on the builder don't change it
+func (b *CheckBuilder) Build() *models.DeviceCheckDetailsObject {
+	check := b.check
+	check.ActivityData = nil
+	for _, kvp := range b.check.ActivityData {
+		copied := *kvp
+		check.ActivityData = append(check.ActivityData, &copied)
+	}
+	return &check
+}
+
+// Collection builds a collection of the checks
+func Collection(checks ...*CheckBuilder) models.DeviceCheckDetailsObjectCollection {
+	collection := make(models.DeviceCheckDetailsObjectCollection, 0, len(checks))
+	for _, check := range checks {
+		collection = append(collection, check.Build())
+	}
+	return collection
+}
+
+// KVP returns a key value pair
+func KVP(key, value string, kvpType models.EnumKVPType) *models.KeyValuePairObject {
+	return &models.KeyValuePairObject{KvpKey: key, KvpValue: value, KvpType: kvpType}
+}
+
+// batchValues are the activity data cycled through by Batch, one per kvpType
+var batchValues = []struct {
+	value   string
+	kvpType models.EnumKVPType
+}{
+	{MockIP, models.EnumKVPTypeGeneralString},
+	{"42", models.EnumKVPTypeGeneralInteger},
+	{"0.25", models.EnumKVPTypeGeneralFloat},
+	{"true", models.EnumKVPTypeGeneralBool},
+	{"Jane Citizen", models.EnumKVPTypePiiName},
+}
+
+// Batch returns a valid collection of checks with kvps activity data each,
+// the same on every call. Each check has its own session key.
+func Batch(checks, kvps int) models.DeviceCheckDetailsObjectCollection {
+	builders := make([]*CheckBuilder, 0, checks)
+	for i := 0; i < checks; i++ {
+		check := NewCheck(fmt.Sprintf("01700000-0000-7000-8000-%012d", i))
+		for j := 0; j < kvps; j++ {
+			v := batchValues[j%len(batchValues)]
+			check.KVP("batch.key."+strconv.Itoa(j), v.value, v.kvpType)
+		}
+		builders = append(builders, check)
+	}
+	return Collection(builders...)
+}
+
+// BatchSize is a shape of Batch the benchmarks are run with
+type BatchSize struct {
+	Name   string
+	Checks int
+	KVPs   int
+}
+
+// BatchSizes go from a typical signup check to a large batch request
+var BatchSizes = []BatchSize{
+	{Name: "checks=1,kvps=4", Checks: 1, KVPs: 4},
+	{Name: "checks=10,kvps=10", Checks: 10, KVPs: 10},
+	{Name: "checks=100,kvps=20", Checks: 100, KVPs: 20},
+}
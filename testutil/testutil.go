@@ -0,0 +1,109 @@
+// Package testutil holds the fixtures shared by the tests of the other
+// packages: a fake clock, seeded randomness, session keys derived from
+// both, and builders for device check collections. A test seeded the same
+// way builds the same requests on every run.
+package testutil
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+
+	"universalsdk/sessionkey"
+)
+
+// Epoch is the time fake clocks start at unless told otherwise
+var Epoch = time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// Clock is a fake clock that only moves when told to. It is safe for
+// concurrent use.
+type Clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewClock returns a Clock stopped at start
+func NewClock(start time.Time) *Clock {
+	return &Clock{now: start}
+}
+
+// Now returns the current time of the clock, it can be passed wherever a
+// func() time.Time is expected
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Advance moves the clock forward by d and returns the new time
+func (c *Clock) Advance(d time.Duration) time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	return c.now
+}
+
+// Set moves the clock to t
+func (c *Clock) Set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = t
+}
+
+// Rand is a seeded source of randomness, safe for concurrent use unlike
+// the *rand.Rand it wraps. It implements io.Reader.
+type Rand struct {
+	mu   sync.Mutex
+	rand *rand.Rand
+}
+
+// NewRand returns a Rand seeded with seed
+func NewRand(seed int64) *Rand {
+	return &Rand{rand: rand.New(rand.NewSource(seed))}
+}
+
+// Read fills p with random bytes, it never fails
+func (r *Rand) Read(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.rand.Read(p)
+}
+
+// Intn returns a random int in [0, n)
+func (r *Rand) Intn(n int) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.rand.Intn(n)
+}
+
+// Fixtures bundles a clock and randomness seeded together
+type Fixtures struct {
+	Clock *Clock
+	Rand  *Rand
+}
+
+// New returns Fixtures with a clock at Epoch and randomness seeded with seed
+func New(seed int64) *Fixtures {
+	return &Fixtures{Clock: NewClock(Epoch), Rand: NewRand(seed)}
+}
+
+// SessionKeys returns a session key generator using the clock and
+// randomness of the fixtures
+func (f *Fixtures) SessionKeys() sessionkey.Generator {
+	return sessionkey.Generator{Now: f.Clock.Now, Rand: f.Rand}
+}
+
+// SessionKey returns the next session key of the fixtures
+func (f *Fixtures) SessionKey() string {
+	key, err := f.SessionKeys().New()
+	if err != nil {
+		// Rand never fails
+		panic(err)
+	}
+	return key
+}
+
+// Check returns a builder of a DEVICE SIGNUP check with the next session key
+func (f *Fixtures) Check() *CheckBuilder {
+	return NewCheck(f.SessionKey())
+}
@@ -0,0 +1,66 @@
+package testutil
+
+import (
+	"testing"
+	"time"
+
+	"universalsdk/models"
+	"universalsdk/sessionkey"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type TestUtilSuite struct {
+	suite.Suite
+}
+
+func TestTestUtilSuite(t *testing.T) {
+	suite.Run(t, new(TestUtilSuite))
+}
+
+func (suite *TestUtilSuite) TestClock() {
+	clock := NewClock(Epoch)
+	suite.Equal(Epoch, clock.Now())
+	suite.Equal(Epoch.Add(time.Minute), clock.Advance(time.Minute))
+	suite.Equal(Epoch.Add(time.Minute), clock.Now())
+
+	clock.Set(Epoch)
+	suite.Equal(Epoch, clock.Now())
+}
+
+func (suite *TestUtilSuite) TestSeeded() {
+	a, b := New(42), New(42)
+	for i := 0; i < 10; i++ {
+		suite.Equal(a.SessionKey(), b.SessionKey())
+	}
+	suite.NotEqual(New(1).SessionKey(), New(2).SessionKey())
+
+	// Keys are stamped with the fake clock
+	f := New(1)
+	f.Clock.Advance(time.Hour)
+	created, ok := sessionkey.Time(f.SessionKey())
+	suite.True(ok)
+	suite.Equal(Epoch.Add(time.Hour), created)
+}
+
+func (suite *TestUtilSuite) TestBuilders() {
+	builder := NewCheck("key-1").ActivityType("LOGIN").JourneyID("j-1").IP().KVP("user.age", "42", models.EnumKVPTypeGeneralInteger)
+	collection := Collection(builder, NewCheck("key-2"))
+
+	suite.Require().Len(collection, 2)
+	suite.Equal(&models.DeviceCheckDetailsObject{
+		CheckType:       "DEVICE",
+		ActivityType:    "LOGIN",
+		CheckSessionKey: "key-1",
+		JourneyID:       "j-1",
+		ActivityData: []*models.KeyValuePairObject{
+			KVP("ip.address", MockIP, models.EnumKVPTypeGeneralString),
+			KVP("user.age", "42", models.EnumKVPTypeGeneralInteger),
+		},
+	}, collection[0])
+	suite.Empty(collection[1].ActivityData)
+
+	// Built checks don't share activity data with the builder
+	collection[0].ActivityData[0].KvpValue = "changed"
+	suite.Equal(MockIP, builder.Build().ActivityData[0].KvpValue)
+}
@@ -2,11 +2,8 @@ package util
 
 import (
 	"encoding/json"
-	"math/rand"
-	"mime"
+	"net"
 	"net/http"
-	"strings"
-	"time"
 )
 
 func RespondWithObject(w http.ResponseWriter, data interface{}) {
@@ -21,30 +18,28 @@ func RespondWithErrorObject(w http.ResponseWriter, data interface{}) {
 	json.NewEncoder(w).Encode(data)
 }
 
-func GenerateRandomInRange(min int, max int) int {
-	rand.Seed(time.Now().UnixNano())
-	result := rand.Intn(max-min) + min
-	return result
+// Identify the caller of a request, used for auditing.
+// The X-Caller-Id header is preferred, falling back to the remote host.
+func CallerIdentity(r *http.Request) string {
+	if caller := r.Header.Get("X-Caller-Id"); caller != "" {
+		return caller
+	}
+	return RemoteHost(r)
 }
 
-// Determine whether the request `content-type` includes a
-// server-acceptable mime-type
-//
-// Failure should yield an Eror
-func HasContentType(r *http.Request, mimetype string) bool {
-	contentType := r.Header.Get("Content-type")
-	if contentType == "" {
-		return mimetype == "application/octet-stream"
+// RemoteHost is the host the request came from
+func RemoteHost(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
 	}
+	return host
+}
 
-	for _, v := range strings.Split(contentType, ",") {
-		t, _, err := mime.ParseMediaType(v)
-		if err != nil {
-			break
-		}
-		if t == mimetype {
-			return true
-		}
+func RespondWithStatus(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Add("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if data != nil {
+		json.NewEncoder(w).Encode(data)
 	}
-	return false
 }
@@ -0,0 +1,51 @@
+package velocity
+
+import (
+	"fmt"
+	"universalsdk/config"
+	"universalsdk/envelope"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// NewCheckerFromConfig creates a Checker with the configured rules and
+// backend. The redis backend is shared, its counters are keyed with the
+// encryptor, which it requires.
+func NewCheckerFromConfig(cfg config.VelocityConfig, encryptor *envelope.Encryptor) (*Checker, error) {
+	rules := make([]Rule, 0, len(cfg.Rules))
+	for _, r := range cfg.Rules {
+		rules = append(rules, Rule{
+			Name:         r.Name,
+			ActivityType: r.ActivityType,
+			Keys:         r.Keys,
+			Window:       r.Window,
+			Limit:        r.Limit,
+			Action:       r.Action,
+			OnError:      r.OnError,
+		})
+	}
+
+	var store Store
+	switch cfg.Backend {
+	case "", "memory":
+		store = NewMemoryStore()
+	case "redis":
+		if encryptor == nil {
+			return nil, fmt.Errorf("velocity backend redis requires encryption.keyFile to key its counters")
+		}
+		store = NewRedisStore(redis.NewClient(&redis.Options{
+			Addr:     cfg.Redis.Addr,
+			Password: cfg.Redis.Password,
+			DB:       cfg.Redis.DB,
+		}), cfg.Redis.Prefix)
+	default:
+		return nil, fmt.Errorf("velocity backend %s invalid, valid backends are memory, redis", cfg.Backend)
+	}
+
+	checker, err := NewChecker(store, rules, WithEncryptor(encryptor))
+	if err != nil {
+		store.Close()
+		return nil, err
+	}
+	return checker, nil
+}
@@ -0,0 +1,57 @@
+package velocity
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisStore keeps the events in Redis sorted sets scored in microseconds,
+// so every server sharing the Redis instance counts the same events.
+type RedisStore struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisStore returns a RedisStore using client, every key is prefixed with prefix
+func NewRedisStore(client *redis.Client, prefix string) *RedisStore {
+	return &RedisStore{client: client, prefix: prefix}
+}
+
+func (s *RedisStore) Add(ctx context.Context, key string, t time.Time, window time.Duration) (int64, error) {
+	member, err := eventMember(t)
+	if err != nil {
+		return 0, err
+	}
+
+	key = s.prefix + key
+	var count *redis.IntCmd
+	_, err = s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZRemRangeByScore(ctx, key, "-inf", strconv.FormatInt(t.Add(-window).UnixMicro(), 10))
+		pipe.ZAdd(ctx, key, redis.Z{Score: float64(t.UnixMicro()), Member: member})
+		count = pipe.ZCard(ctx, key)
+		pipe.PExpire(ctx, key, window)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return count.Val(), nil
+}
+
+func (s *RedisStore) Close() error {
+	return s.client.Close()
+}
+
+// eventMember returns a unique sorted set member for an event at t
+func eventMember(t time.Time) (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return strconv.FormatInt(t.UnixNano(), 10) + "-" + hex.EncodeToString(b), nil
+}
@@ -0,0 +1,70 @@
+package velocity
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Store counts events per key in sliding windows. Implementations must be
+// safe for concurrent use.
+type Store interface {
+	// Add records an event of key at t and returns the number of events of
+	// key within window up to and including t.
+	Add(ctx context.Context, key string, t time.Time, window time.Duration) (int64, error)
+	Close() error
+}
+
+// Number of Adds after which the MemoryStore drops idle keys
+const memorySweepInterval = 1024
+
+// MemoryStore keeps the events in process, for a single server
+type MemoryStore struct {
+	mu     sync.Mutex
+	events map[string][]time.Time
+	expiry map[string]time.Time
+	adds   int
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{events: make(map[string][]time.Time), expiry: make(map[string]time.Time)}
+}
+
+func (s *MemoryStore) Add(ctx context.Context, key string, t time.Time, window time.Duration) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	events := prune(s.events[key], t.Add(-window))
+	events = append(events, t)
+	s.events[key] = events
+	s.expiry[key] = t.Add(window)
+
+	s.adds++
+	if s.adds%memorySweepInterval == 0 {
+		s.sweep(t)
+	}
+	return int64(len(events)), nil
+}
+
+func (s *MemoryStore) Close() error {
+	return nil
+}
+
+// sweep drops the keys without events in their last window
+func (s *MemoryStore) sweep(now time.Time) {
+	for key, expiry := range s.expiry {
+		if expiry.Before(now) {
+			delete(s.events, key)
+			delete(s.expiry, key)
+		}
+	}
+}
+
+// prune drops the events at or before since, events are in time order
+func prune(events []time.Time, since time.Time) []time.Time {
+	i := 0
+	for i < len(events) && !events[i].After(since) {
+		i++
+	}
+	return events[i:]
+}
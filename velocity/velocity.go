@@ -0,0 +1,179 @@
+// Package velocity counts checks in sliding windows, keyed on activity data
+// values such as ip.address, and reports the rules whose limit they exceed.
+package velocity
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+	"universalsdk/envelope"
+	"universalsdk/models"
+)
+
+const (
+	// ActionReject makes the check fail when the rule trips
+	ActionReject = "reject"
+
+	// ActionFlag passes the check but reports the rule that tripped
+	ActionFlag = "flag"
+)
+
+const (
+	// OnErrorOpen passes the check when its counter can't be updated
+	OnErrorOpen = "open"
+
+	// OnErrorClosed fails the check when its counter can't be updated
+	OnErrorClosed = "closed"
+)
+
+// Rule counts the checks of an activityType sharing the values of Keys
+// within Window. It trips when the count exceeds Limit.
+type Rule struct {
+	// Name of the counter, reported when the rule trips
+	Name string
+
+	// activityType counted, every activityType when empty
+	ActivityType string
+
+	// kvpKeys whose values identify the counter, checks missing any of them are not counted
+	Keys []string
+
+	Window time.Duration
+	Limit  int64
+
+	// ActionReject or ActionFlag
+	Action string
+
+	// OnErrorOpen, the default when empty, or OnErrorClosed
+	OnError string
+}
+
+// Trip is a rule exceeded by a check, or not enforced because its counter
+// couldn't be updated
+type Trip struct {
+	Rule  Rule
+	Count int64
+
+	// Why the rule was not enforced, nil when it was
+	Err error
+}
+
+// Checker applies the velocity rules to checks
+type Checker struct {
+	store     Store
+	rules     []Rule
+	encryptor *envelope.Encryptor
+}
+
+// Option configures a Checker
+type Option func(*Checker)
+
+// WithEncryptor keys the counters with the deterministic tokens of the
+// encryptor, so a shared store holds nothing the values can be recovered
+// from by hashing candidates. Counters start over when the key rotates.
+func WithEncryptor(encryptor *envelope.Encryptor) Option {
+	return func(c *Checker) {
+		c.encryptor = encryptor
+	}
+}
+
+// NewChecker validates the rules and returns a Checker counting in store
+func NewChecker(store Store, rules []Rule, opts ...Option) (*Checker, error) {
+	names := make(map[string]bool)
+	for _, rule := range rules {
+		switch {
+		case rule.Name == "":
+			return nil, fmt.Errorf("velocity rule requires a name")
+		case names[rule.Name]:
+			return nil, fmt.Errorf("duplicate velocity rule %s", rule.Name)
+		case len(rule.Keys) == 0:
+			return nil, fmt.Errorf("velocity rule %s requires at least one kvpKey", rule.Name)
+		case rule.Window <= 0:
+			return nil, fmt.Errorf("velocity rule %s requires a positive window", rule.Name)
+		case rule.Limit < 0:
+			return nil, fmt.Errorf("velocity rule %s limit should not be negative", rule.Name)
+		case rule.Action != ActionReject && rule.Action != ActionFlag:
+			return nil, fmt.Errorf("velocity rule %s action %s invalid, valid actions are %s, %s", rule.Name, rule.Action, ActionReject, ActionFlag)
+		case rule.OnError != "" && rule.OnError != OnErrorOpen && rule.OnError != OnErrorClosed:
+			return nil, fmt.Errorf("velocity rule %s onError %s invalid, valid values are %s, %s", rule.Name, rule.OnError, OnErrorOpen, OnErrorClosed)
+		}
+		names[rule.Name] = true
+	}
+	c := &Checker{store: store, rules: rules}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// Check counts the check against every rule that applies to it at now and
+// returns the rules it trips. A rule whose counter can't be updated is
+// returned with the store error in Err, the error of Check joins them.
+func (c *Checker) Check(ctx context.Context, check *models.DeviceCheckDetailsObject, now time.Time) ([]Trip, error) {
+	var trips []Trip
+	var errs []error
+	for _, rule := range c.rules {
+		key, ok := counterKey(rule, check)
+		if !ok {
+			continue
+		}
+
+		var count int64
+		var err error
+		if c.encryptor != nil {
+			key, err = c.encryptor.Token(rule.Name, key)
+		}
+		if err == nil {
+			count, err = c.store.Add(ctx, rule.Name+":"+key, now, rule.Window)
+		}
+		if err != nil {
+			err = fmt.Errorf("velocity rule %s: %v", rule.Name, err)
+			trips = append(trips, Trip{Rule: rule, Err: err})
+			errs = append(errs, err)
+			continue
+		}
+		if count > rule.Limit {
+			trips = append(trips, Trip{Rule: rule, Count: count})
+		}
+	}
+	return trips, errors.Join(errs...)
+}
+
+// FailsClosed reports whether the rule fails the check when its counter can't be updated
+func (r Rule) FailsClosed() bool {
+	return r.OnError == OnErrorClosed
+}
+
+// Close closes the store
+func (c *Checker) Close() error {
+	return c.store.Close()
+}
+
+// counterKey identifies the counter of the check for the rule. Values are
+// hashed so stores don't hold the raw identifiers, and the Checker keys
+// the hash with its encryptor, if any.
+func counterKey(rule Rule, check *models.DeviceCheckDetailsObject) (string, bool) {
+	if rule.ActivityType != "" && rule.ActivityType != check.ActivityType {
+		return "", false
+	}
+
+	values := make(map[string]string)
+	for _, kvp := range check.ActivityData {
+		if kvp != nil {
+			values[kvp.KvpKey] = kvp.KvpValue
+		}
+	}
+
+	h := sha256.New()
+	for _, key := range rule.Keys {
+		value, ok := values[key]
+		if !ok {
+			return "", false
+		}
+		fmt.Fprintf(h, "%s=%s\x00", key, value)
+	}
+	return hex.EncodeToString(h.Sum(nil)), true
+}
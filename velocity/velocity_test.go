@@ -0,0 +1,196 @@
+package velocity
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"universalsdk/envelope"
+	"universalsdk/models"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/suite"
+)
+
+type VelocitySuite struct {
+	suite.Suite
+}
+
+func TestVelocitySuite(t *testing.T) {
+	suite.Run(t, new(VelocitySuite))
+}
+
+func (suite *VelocitySuite) TestMemoryStore() {
+	suite.testStore(NewMemoryStore())
+}
+
+func (suite *VelocitySuite) TestRedisStore() {
+	server := miniredis.RunT(suite.T())
+	store := NewRedisStore(redis.NewClient(&redis.Options{Addr: server.Addr()}), "test:")
+	suite.testStore(store)
+	suite.True(server.Exists("test:ip"))
+}
+
+// testStore checks the sliding window of a store
+func (suite *VelocitySuite) testStore(store Store) {
+	defer store.Close()
+	ctx := context.Background()
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	for i, expected := range []int64{1, 2, 3} {
+		count, err := store.Add(ctx, "ip", start.Add(time.Duration(i)*time.Minute), time.Hour)
+		suite.Require().NoError(err)
+		suite.Equal(expected, count)
+	}
+
+	// The first event leaves the window an hour later
+	count, err := store.Add(ctx, "ip", start.Add(time.Hour), time.Hour)
+	suite.Require().NoError(err)
+	suite.Equal(int64(3), count)
+
+	count, err = store.Add(ctx, "other", start, time.Hour)
+	suite.Require().NoError(err)
+	suite.Equal(int64(1), count)
+}
+
+func (suite *VelocitySuite) TestChecker() {
+	checker, err := NewChecker(NewMemoryStore(), []Rule{
+		{Name: "signups-per-ip", ActivityType: "SIGNUP", Keys: []string{"ip.address"}, Window: time.Hour, Limit: 2, Action: ActionReject},
+		{Name: "checks-per-device", Keys: []string{"ip.address", "device.fingerprint"}, Window: time.Hour, Limit: 1, Action: ActionFlag},
+	})
+	suite.Require().NoError(err)
+
+	check := func(activityType, ip string) *models.DeviceCheckDetailsObject {
+		return &models.DeviceCheckDetailsObject{ActivityType: activityType, ActivityData: []*models.KeyValuePairObject{
+			{KvpKey: "ip.address", KvpValue: ip, KvpType: models.EnumKVPTypeGeneralString},
+		}}
+	}
+	now := time.Now()
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		trips, err := checker.Check(ctx, check("SIGNUP", "1.2.3.4"), now)
+		suite.Require().NoError(err)
+		suite.Empty(trips)
+	}
+
+	// Other activity types and other addresses have their own count
+	trips, _ := checker.Check(ctx, check("LOGIN", "1.2.3.4"), now)
+	suite.Empty(trips)
+	trips, _ = checker.Check(ctx, check("SIGNUP", "5.6.7.8"), now)
+	suite.Empty(trips)
+
+	trips, _ = checker.Check(ctx, check("SIGNUP", "1.2.3.4"), now)
+	suite.Require().Len(trips, 1)
+	suite.Equal("signups-per-ip", trips[0].Rule.Name)
+	suite.Equal(int64(3), trips[0].Count)
+
+	// A rule only counts checks with all of its keys
+	withDevice := check("LOGIN", "1.2.3.4")
+	withDevice.ActivityData = append(withDevice.ActivityData,
+		&models.KeyValuePairObject{KvpKey: "device.fingerprint", KvpValue: "abc", KvpType: models.EnumKVPTypeGeneralString})
+	trips, _ = checker.Check(ctx, withDevice, now)
+	suite.Empty(trips)
+	trips, _ = checker.Check(ctx, withDevice, now)
+	suite.Require().Len(trips, 1)
+	suite.Equal(ActionFlag, trips[0].Rule.Action)
+}
+
+func (suite *VelocitySuite) TestStoreFailure() {
+	store := &flakyStore{Store: NewMemoryStore(), fail: "down"}
+	checker, err := NewChecker(store, []Rule{
+		{Name: "down", Keys: []string{"ip.address"}, Window: time.Hour, Limit: 5, Action: ActionReject},
+		{Name: "up", Keys: []string{"ip.address"}, Window: time.Hour, Limit: 0, Action: ActionFlag},
+	})
+	suite.Require().NoError(err)
+
+	// The failing rule is returned with its error, the next rule is still counted
+	check := &models.DeviceCheckDetailsObject{ActivityData: []*models.KeyValuePairObject{
+		{KvpKey: "ip.address", KvpValue: "1.2.3.4", KvpType: models.EnumKVPTypeGeneralString},
+	}}
+	trips, err := checker.Check(context.Background(), check, time.Now())
+	suite.ErrorContains(err, "velocity rule down: connection refused")
+	suite.Require().Len(trips, 2)
+	suite.Equal("down", trips[0].Rule.Name)
+	suite.Error(trips[0].Err)
+	suite.False(trips[0].Rule.FailsClosed())
+	suite.Equal(Trip{Rule: trips[1].Rule, Count: 1}, trips[1])
+}
+
+func (suite *VelocitySuite) TestEncryptedCounters() {
+	keyFile := filepath.Join(suite.T().TempDir(), "keys.json")
+	_, err := envelope.RotateKeyFile(keyFile)
+	suite.Require().NoError(err)
+	provider, err := envelope.NewLocalKeyProvider(keyFile)
+	suite.Require().NoError(err)
+
+	store := &recordingStore{Store: NewMemoryStore()}
+	checker, err := NewChecker(store, []Rule{
+		{Name: "ip", Keys: []string{"ip.address"}, Window: time.Hour, Limit: 1, Action: ActionFlag},
+	}, WithEncryptor(envelope.NewEncryptor(provider)))
+	suite.Require().NoError(err)
+
+	check := &models.DeviceCheckDetailsObject{ActivityData: []*models.KeyValuePairObject{
+		{KvpKey: "ip.address", KvpValue: "1.2.3.4", KvpType: models.EnumKVPTypeGeneralString},
+	}}
+	checker.Check(context.Background(), check, time.Now())
+	trips, err := checker.Check(context.Background(), check, time.Now())
+	suite.Require().NoError(err)
+	suite.Require().Len(trips, 1)
+	suite.Equal(int64(2), trips[0].Count)
+
+	// The counter is keyed: not the plain hash anyone could compute from the value
+	hash, _ := counterKey(checker.rules[0], check)
+	suite.Require().Len(store.keys, 2)
+	suite.Equal(store.keys[0], store.keys[1])
+	suite.True(strings.HasPrefix(store.keys[0], "ip:"))
+	suite.NotContains(store.keys[0], hash)
+}
+
+func (suite *VelocitySuite) TestInvalidRules() {
+	valid := Rule{Name: "ip", Keys: []string{"ip.address"}, Window: time.Hour, Limit: 1, Action: ActionFlag}
+	invalid := []func(r *Rule){
+		func(r *Rule) { r.Name = "" },
+		func(r *Rule) { r.Keys = nil },
+		func(r *Rule) { r.Window = 0 },
+		func(r *Rule) { r.Limit = -1 },
+		func(r *Rule) { r.Action = "block" },
+		func(r *Rule) { r.OnError = "ignore" },
+	}
+	for i, change := range invalid {
+		rule := valid
+		change(&rule)
+		_, err := NewChecker(NewMemoryStore(), []Rule{rule})
+		suite.Error(err, "rule %d", i)
+	}
+
+	_, err := NewChecker(NewMemoryStore(), []Rule{valid, valid})
+	suite.Error(err)
+}
+
+// recordingStore records the keys of the counters
+type recordingStore struct {
+	Store
+	keys []string
+}
+
+func (s *recordingStore) Add(ctx context.Context, key string, t time.Time, window time.Duration) (int64, error) {
+	s.keys = append(s.keys, key)
+	return s.Store.Add(ctx, key, t, window)
+}
+
+// flakyStore fails the counters of the rule named fail
+type flakyStore struct {
+	Store
+	fail string
+}
+
+func (s *flakyStore) Add(ctx context.Context, key string, t time.Time, window time.Duration) (int64, error) {
+	if strings.HasPrefix(key, s.fail+":") {
+		return 0, errors.New("connection refused")
+	}
+	return s.Store.Add(ctx, key, t, window)
+}